@@ -298,8 +298,10 @@ func (c *Chain[B]) Validate() {
 //
 // This must called only once per chain per process.
 //
-// **Caveats** Two chain in the same Go binary will not work today as `bstream` uses global
-// variables to store configuration which presents multiple chain to exist in the same process.
+// **Caveats** Two chain types in the same Go binary will not work today as `bstream` uses global
+// variables to store configuration which presents multiple chain to exist in the same process. The
+// Firehose app can still serve several networks of this chain type (see 'firehose-chains-config') as
+// long as they share the same first streamable block.
 func (c *Chain[B]) Init() {
 	c.BlockEncoder = NewBlockEncoder()
 
@@ -1,22 +1,34 @@
 package apps
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/url"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
 	"github.com/streamingfast/bstream/transform"
 	"github.com/streamingfast/dauth"
 	discoveryservice "github.com/streamingfast/dgrpc/server/discovery-service"
 	"github.com/streamingfast/dmetrics"
+	"github.com/streamingfast/dstore"
 	firecore "github.com/streamingfast/firehose-core"
+	"github.com/streamingfast/firehose-core/firehose/accesslog"
+	"github.com/streamingfast/firehose-core/firehose/admission"
 	"github.com/streamingfast/firehose-core/firehose/app/firehose"
+	"github.com/streamingfast/firehose-core/firehose/fieldmask"
+	"github.com/streamingfast/firehose-core/firehose/quota"
 	"github.com/streamingfast/firehose-core/firehose/server"
+	fcjson "github.com/streamingfast/firehose-core/json"
 	"github.com/streamingfast/firehose-core/launcher"
+	fcproto "github.com/streamingfast/firehose-core/proto"
 	"github.com/streamingfast/logging"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 var metricset = dmetrics.NewSet()
@@ -33,8 +45,30 @@ func RegisterFirehoseApp[B firecore.Block](chain *firecore.Chain[B], rootLog *za
 		RegisterFlags: func(cmd *cobra.Command) error {
 			cmd.Flags().String("firehose-grpc-listen-addr", firecore.FirehoseGRPCServingAddr, "Address on which the firehose will listen")
 			cmd.Flags().String("firehose-discovery-service-url", "", "Url to configure the gRPC discovery service") //traffic-director://xds?vpc_network=vpc-global&use_xds_reds=true
+			cmd.Flags().Bool("firehose-enable-connect-web", false, "Serve the firehose services through a Connect server speaking gRPC, gRPC-Web and Connect on the 'firehose-grpc-listen-addr' address(es) so that web clients can consume them directly, cannot be used with 'firehose-discovery-service-url'")
+			cmd.Flags().String("firehose-chains-config", "", "YAML file listing other chains served by this firehose next to the main one, under a 'chains' key, each with a 'name' and 'merged-blocks-store-url', 'one-blocks-store-url' and optionally 'aliases', 'forked-blocks-store-url', 'index-store-url', 'live-blocks-addr', 'grpc-listen-addr' and 'first-streamable-block' (defaults to 'common-first-streamable-block', a lower one is raised to it), their index store also holds their block hash index files when 'firehose-block-hash-index' is enabled, their requests are those carrying their name in the 'x-firehose-chain' header or received on their own 'grpc-listen-addr'")
 			cmd.Flags().Int("firehose-rate-limit-bucket-size", -1, "Rate limit bucket size (default: no rate limit)")
 			cmd.Flags().Duration("firehose-rate-limit-bucket-fill-rate", 10*time.Second, "Rate limit bucket refill rate (default: 10s)")
+			cmd.Flags().Duration("firehose-drain-timeout", 10*time.Second, "On shutdown (or when 'common-system-shutdown-signal-delay' starts), how long active streams are given to reach a block boundary and end with an 'Unavailable' status carrying their last cursor before the server stops")
+			cmd.Flags().String("firehose-admin-listen-addr", "", "Address on which the firehose admin HTTP endpoints listen, 'POST /drain' starts draining the server and 'GET /drain' reports its status, they are never served on the gRPC nor HTTP gateway listen addresses, a loopback address is required unless 'firehose-admin-auth-token' is set (disabled if empty)")
+			cmd.Flags().String("firehose-admin-auth-token", "", "Bearer token required by the firehose admin HTTP endpoints ('Authorization: Bearer <token>' header), mandatory when 'firehose-admin-listen-addr' is not a loopback address")
+			cmd.Flags().Int("firehose-admission-max-historical-streams", 0, "Maximum number of concurrent historical streams (not served from the live segment), new ones are queued then rejected with 'ResourceExhausted' above it, live-only streams are always admitted (0 means unlimited)")
+			cmd.Flags().Int("firehose-admission-max-inflight-preprocessing", 0, "Number of merged blocks being preprocessed (transforms) across all streams above which new historical streams are queued then rejected with 'ResourceExhausted' (0 means unlimited)")
+			cmd.Flags().String("firehose-admission-max-heap", "0", "Heap usage (e.g. '24GiB') above which new historical streams are queued then rejected with 'ResourceExhausted' ('0' means unlimited)")
+			cmd.Flags().Duration("firehose-admission-queue-timeout", 0, "How long a historical stream waits to be admitted when the server is overloaded before being rejected (0 rejects right away)")
+			cmd.Flags().Duration("firehose-admission-retry-after", 10*time.Second, "Delay suggested to clients rejected because the server is overloaded, sent in the 'retry-after' header and the status 'google.rpc.RetryInfo' detail")
+			cmd.Flags().String("firehose-http-listen-addr", "", "Address on which the firehose HTTP gateway listens, serving 'Blocks' as Server-Sent Events or NDJSON on '/v2/blocks', 'Block' on '/v2/block' and 'Info' on '/v2/info' as JSON (disabled if empty)")
+			cmd.Flags().String("firehose-http-bytes-encoding", "hex", "Encoding for bytes fields in JSON rendered by the firehose HTTP gateway, either 'hex', 'base58' or 'base64'")
+			cmd.Flags().String("firehose-access-log-sink", "", "Sink receiving one structured access log record per 'Blocks' request (params, caller, duration, termination status and reason, metering totals), either 'file:///path/access.jsonl?max-size=100MiB&max-backups=10' (JSON lines, rotated), 'http(s)://host/path' (NDJSON batches POSTed) or 'grpc(s)://host:port' (pushed to 'sf.firehose.accesslog.v1.AccessLog/Push'), all accept 'buffer', 'batch' and 'flush-interval' query parameters (disabled if empty)")
+			cmd.Flags().Int("firehose-batch-fetch-concurrency", 8, "Number of blocks resolved concurrently for a single 'sf.firehose.batchfetch.v1.BatchFetch/Blocks' call")
+			cmd.Flags().String("firehose-block-cache-size", "0", "Maximum size of the decoded merged bundles kept in memory to serve single block requests ('Block' and 'BatchFetch/Blocks') of nearby blocks without reading the merged blocks store again, '0' disables the cache. Concurrent misses on the same bundle share a single load, the block served is metered with its share of its bundle compressed bytes whether it was cached or not, which differs from the bytes read until the block without the cache")
+			cmd.Flags().Bool("firehose-block-hash-index", false, "Load the block hash index files written by the merger (see 'merger-write-block-hash-index') from the index store to serve merged blocks requested by hash alone, keeps ~100 bytes of memory per merged block")
+			cmd.Flags().Duration("firehose-live-info-ttl", time.Second, "How long the live info (head block, LIB, lowest and highest merged blocks, live capability) reported along 'Info' responses and on the HTTP gateway '/v2/live' endpoint is cached, '0' disables it")
+			cmd.Flags().Duration("firehose-resume-token-ttl", 15*time.Minute, "How long the last cursor of a stream is remembered after the stream ended, clients can resume using the 'x-firehose-resume-token' header received when the stream started, tokens only resume streams of the authenticated user that started them")
+			cmd.Flags().Duration("firehose-metering-aggregation-window", 0, "When non-zero, 'Blocks' requests emit one metering event aggregating the usage of this period instead of one per block, the remaining usage being emitted when the stream ends")
+			cmd.Flags().Uint64("firehose-metering-aggregation-max-blocks", 0, "When non-zero, 'Blocks' requests emit one metering event aggregating the usage of this number of blocks instead of one per block, combined with 'firehose-metering-aggregation-window' the first limit reached triggers the emission")
+			cmd.Flags().String("firehose-quota-file", "", "YAML (or JSON) file of usage quotas (blocks and egress bytes per period, per user or API key) enforced on 'Blocks' and 'Block' requests, see the 'firehose/quota' package for its format, accepts '{data-dir}' and any dstore URL (disabled if empty)")
+			cmd.Flags().Bool("firehose-quota-from-auth", false, "Enforce the usage quotas set by the auth plugin in the 'x-sf-quota-blocks', 'x-sf-quota-egress-bytes' and 'x-sf-quota-period' trusted headers, which take precedence over 'firehose-quota-file'")
 
 			return nil
 		},
@@ -51,6 +85,15 @@ func RegisterFirehoseApp[B firecore.Block](chain *firecore.Chain[B], rootLog *za
 			}
 
 			rawServiceDiscoveryURL := viper.GetString("firehose-discovery-service-url")
+			if rawServiceDiscoveryURL != "" && viper.GetBool("firehose-enable-connect-web") {
+				return nil, fmt.Errorf("flags 'firehose-enable-connect-web' and 'firehose-discovery-service-url' cannot be used together")
+			}
+
+			adminListenAddr := viper.GetString("firehose-admin-listen-addr")
+			if adminListenAddr != "" && viper.GetString("firehose-admin-auth-token") == "" && !isLoopbackListenAddr(adminListenAddr) {
+				return nil, fmt.Errorf("flag 'firehose-admin-listen-addr' %q is not a loopback address, 'firehose-admin-auth-token' must be set to authenticate the admin endpoints", adminListenAddr)
+			}
+
 			var serviceDiscoveryURL *url.URL
 			if rawServiceDiscoveryURL != "" {
 				serviceDiscoveryURL, err = url.Parse(rawServiceDiscoveryURL)
@@ -68,17 +111,49 @@ func RegisterFirehoseApp[B firecore.Block](chain *firecore.Chain[B], rootLog *za
 				return nil, fmt.Errorf("unable to initialize indexes: %w", err)
 			}
 
-			registry := transform.NewRegistry()
-			for _, factory := range chain.BlockTransformerFactories {
-				transformer, err := factory(indexStore, possibleIndexSizes)
-				if err != nil {
-					return nil, fmt.Errorf("unable to create transformer: %w", err)
+			protoRegistry, err := fcproto.NewRegistry(chain.BlockFileDescriptor())
+			if err != nil {
+				return nil, fmt.Errorf("unable to create proto registry: %w", err)
+			}
+
+			newTransformRegistry := func(indexStore dstore.Store) (*transform.Registry, error) {
+				registry := transform.NewRegistry()
+				for _, factory := range chain.BlockTransformerFactories {
+					transformer, err := factory(indexStore, possibleIndexSizes)
+					if err != nil {
+						return nil, fmt.Errorf("unable to create transformer: %w", err)
+					}
+
+					registry.Register(transformer)
 				}
 
-				registry.Register(transformer)
+				if _, found := chain.BlockTransformerFactories[fieldmask.MessageName]; !found {
+					// Generic chains (using `pbbstream.Block` as their block type) only know the payload type at runtime
+					var blockDescriptor protoreflect.MessageDescriptor
+					if block := chain.BlockFactory(); !isGenericBlock(block) {
+						blockDescriptor = block.ProtoReflect().Descriptor()
+					}
+
+					registry.Register(fieldmask.NewFactory(protoRegistry, blockDescriptor))
+				}
+
+				return registry, nil
+			}
+
+			registry, err := newTransformRegistry(indexStore)
+			if err != nil {
+				return nil, err
 			}
 
-			var serverOptions []server.Option
+			var chains []*firehose.ChainConfig
+			if chainsConfig := viper.GetString("firehose-chains-config"); chainsConfig != "" {
+				chains, err = loadFirehoseChains(chainsConfig, runtime.AbsDataDir, runtime.InfoServer, viper.GetBool("firehose-block-hash-index"), newTransformRegistry)
+				if err != nil {
+					return nil, fmt.Errorf("unable to load firehose chains config: %w", err)
+				}
+			}
+
+			var serverOptions, mainServerOptions []server.Option
 
 			limiterSize := viper.GetInt("firehose-rate-limit-bucket-size")
 			limiterRefillRate := viper.GetDuration("firehose-rate-limit-bucket-fill-rate")
@@ -86,15 +161,94 @@ func RegisterFirehoseApp[B firecore.Block](chain *firecore.Chain[B], rootLog *za
 				serverOptions = append(serverOptions, server.WithLeakyBucketLimiter(limiterSize, limiterRefillRate))
 			}
 
+			admissionMaxHeap, err := humanize.ParseBytes(viper.GetString("firehose-admission-max-heap"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid 'firehose-admission-max-heap' value: %w", err)
+			}
+
+			admissionConfig := admission.Config{
+				MaxHistoricalStreams:     viper.GetInt("firehose-admission-max-historical-streams"),
+				MaxInflightPreprocessing: viper.GetInt64("firehose-admission-max-inflight-preprocessing"),
+				MaxHeapBytes:             admissionMaxHeap,
+				QueueTimeout:             viper.GetDuration("firehose-admission-queue-timeout"),
+				RetryAfter:               viper.GetDuration("firehose-admission-retry-after"),
+			}
+			if admissionConfig.MaxHistoricalStreams > 0 || admissionConfig.MaxInflightPreprocessing > 0 || admissionConfig.MaxHeapBytes > 0 {
+				serverOptions = append(serverOptions, server.WithAdmissionControl(admissionConfig))
+			}
+
+			if adminListenAddr != "" {
+				mainServerOptions = append(mainServerOptions, server.WithAdminListenAddr(adminListenAddr, viper.GetString("firehose-admin-auth-token")))
+			}
+
+			if viper.GetBool("firehose-enable-connect-web") {
+				serverOptions = append(serverOptions, server.WithConnectWeb())
+			}
+
+			if httpListenAddr := viper.GetString("firehose-http-listen-addr"); httpListenAddr != "" {
+				marshaller := fcjson.NewMarshaller(protoRegistry, fcjson.WithBytesEncoding(viper.GetString("firehose-http-bytes-encoding")))
+				mainServerOptions = append(mainServerOptions, server.WithHTTPGateway(httpListenAddr, marshaller))
+			}
+
+			var accessLogSink accesslog.Sink
+			if accessLogSinkConfig := viper.GetString("firehose-access-log-sink"); accessLogSinkConfig != "" {
+				accessLogSink, err = accesslog.New(accessLogSinkConfig, appLogger)
+				if err != nil {
+					return nil, fmt.Errorf("unable to initialize access log sink: %w", err)
+				}
+			}
+
+			serverOptions = append(serverOptions, server.WithBatchFetchConcurrency(viper.GetInt("firehose-batch-fetch-concurrency")))
+			serverOptions = append(serverOptions, server.WithResumeTokenTTL(viper.GetDuration("firehose-resume-token-ttl")))
+			serverOptions = append(serverOptions, server.WithMeteringAggregation(viper.GetDuration("firehose-metering-aggregation-window"), viper.GetUint64("firehose-metering-aggregation-max-blocks")))
+
+			quotaFromAuth := viper.GetBool("firehose-quota-from-auth")
+			if quotaFile := viper.GetString("firehose-quota-file"); quotaFile != "" || quotaFromAuth {
+				var quotas *quota.File
+				if quotaFile != "" {
+					quotas, err = quota.ReadFile(context.Background(), firecore.MustReplaceDataDir(runtime.AbsDataDir, quotaFile))
+					if err != nil {
+						return nil, fmt.Errorf("unable to load quotas: %w", err)
+					}
+				}
+
+				serverOptions = append(serverOptions, server.WithQuotas(quota.NewManager(quotas, quotaFromAuth, appLogger)))
+			}
+
+			blockCacheSize, err := humanize.ParseBytes(viper.GetString("firehose-block-cache-size"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid 'firehose-block-cache-size' value: %w", err)
+			}
+
+			var blockHashIndexStoreURL string
+			if viper.GetBool("firehose-block-hash-index") {
+				blockHashIndexStoreURL, err = firecore.GetIndexStoreURL(runtime.AbsDataDir)
+				if err != nil {
+					return nil, err
+				}
+
+				if blockHashIndexStoreURL == "" {
+					return nil, fmt.Errorf("flag 'common-index-store-url' must be set when 'firehose-block-hash-index' is enabled")
+				}
+			}
+
 			return firehose.New(appLogger, appTracer, &firehose.Config{
 				MergedBlocksStoreURL:    mergedBlocksStoreURL,
 				OneBlocksStoreURL:       oneBlocksStoreURL,
 				ForkedBlocksStoreURL:    forkedBlocksStoreURL,
+				BlockHashIndexStoreURL:  blockHashIndexStoreURL,
+				BlockCacheSizeBytes:     blockCacheSize,
+				LiveInfoTTL:             viper.GetDuration("firehose-live-info-ttl"),
 				BlockStreamAddr:         viper.GetString("common-live-blocks-addr"),
 				GRPCListenAddr:          viper.GetString("firehose-grpc-listen-addr"),
 				GRPCShutdownGracePeriod: 1 * time.Second,
+				DrainTimeout:            viper.GetDuration("firehose-drain-timeout"),
 				ServiceDiscoveryURL:     serviceDiscoveryURL,
 				ServerOptions:           serverOptions,
+				MainServerOptions:       mainServerOptions,
+				AccessLogSink:           accessLogSink,
+				ChainName:               viper.GetString("advertise-chain-name"),
+				Chains:                  chains,
 			}, &firehose.Modules{
 				Authenticator:         authenticator,
 				HeadTimeDriftMetric:   headTimeDriftmetric,
@@ -106,3 +260,24 @@ func RegisterFirehoseApp[B firecore.Block](chain *firecore.Chain[B], rootLog *za
 		},
 	})
 }
+
+func isGenericBlock(block firecore.Block) bool {
+	_, ok := block.(*pbbstream.Block)
+	return ok
+}
+
+// isLoopbackListenAddr returns whether [addr] only listens on a loopback interface, an address
+// without host listens on every interface.
+func isLoopbackListenAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,86 @@
+package apps
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/streamingfast/bstream/transform"
+	"github.com/streamingfast/dstore"
+	firecore "github.com/streamingfast/firehose-core"
+	"github.com/streamingfast/firehose-core/firehose/app/firehose"
+	"github.com/streamingfast/firehose-core/firehose/info"
+	"gopkg.in/yaml.v2"
+)
+
+type firehoseChainsFile struct {
+	Chains []*firehoseChainEntry `yaml:"chains"`
+}
+
+type firehoseChainEntry struct {
+	Name                 string   `yaml:"name"`
+	Aliases              []string `yaml:"aliases"`
+	MergedBlocksStoreURL string   `yaml:"merged-blocks-store-url"`
+	OneBlocksStoreURL    string   `yaml:"one-blocks-store-url"`
+	ForkedBlocksStoreURL string   `yaml:"forked-blocks-store-url"`
+	IndexStoreURL        string   `yaml:"index-store-url"`
+	LiveBlocksAddr       string   `yaml:"live-blocks-addr"`
+	GRPCListenAddr       string   `yaml:"grpc-listen-addr"`
+	FirstStreamableBlock *uint64  `yaml:"first-streamable-block"`
+}
+
+// loadFirehoseChains reads the other chains to serve from the YAML file at [path], each gets its own
+// info server (derived from [infoServer]) and transform registry (from [newTransformRegistry] with
+// the chain's own index store, if any). With [blockHashIndex], the chain's index store is also
+// where its block hash index files are loaded from, like the main chain's.
+func loadFirehoseChains(path string, dataDir string, infoServer *info.InfoServer, blockHashIndex bool, newTransformRegistry func(indexStore dstore.Store) (*transform.Registry, error)) ([]*firehose.ChainConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file firehoseChainsFile
+	if err := yaml.UnmarshalStrict(content, &file); err != nil {
+		return nil, fmt.Errorf("parse %q: %w", path, err)
+	}
+
+	chains := make([]*firehose.ChainConfig, len(file.Chains))
+	for i, entry := range file.Chains {
+		indexStoreURL := firecore.MustReplaceDataDir(dataDir, entry.IndexStoreURL)
+
+		var indexStore dstore.Store
+		if indexStoreURL != "" {
+			indexStore, err = dstore.NewStore(indexStoreURL, "", "", false)
+			if err != nil {
+				return nil, fmt.Errorf("chain %q: unable to create index store: %w", entry.Name, err)
+			}
+		}
+
+		registry, err := newTransformRegistry(indexStore)
+		if err != nil {
+			return nil, fmt.Errorf("chain %q: %w", entry.Name, err)
+		}
+
+		var blockHashIndexStoreURL string
+		if blockHashIndex {
+			if indexStoreURL == "" {
+				return nil, fmt.Errorf("chain %q: 'index-store-url' must be set when 'firehose-block-hash-index' is enabled", entry.Name)
+			}
+			blockHashIndexStoreURL = indexStoreURL
+		}
+
+		chains[i] = &firehose.ChainConfig{
+			Name:                   entry.Name,
+			MergedBlocksStoreURL:   firecore.MustReplaceDataDir(dataDir, entry.MergedBlocksStoreURL),
+			OneBlocksStoreURL:      firecore.MustReplaceDataDir(dataDir, entry.OneBlocksStoreURL),
+			ForkedBlocksStoreURL:   firecore.MustReplaceDataDir(dataDir, entry.ForkedBlocksStoreURL),
+			BlockHashIndexStoreURL: blockHashIndexStoreURL,
+			BlockStreamAddr:        entry.LiveBlocksAddr,
+			GRPCListenAddr:         entry.GRPCListenAddr,
+			TransformRegistry:      registry,
+			FirstStreamableBlock:   entry.FirstStreamableBlock,
+		}
+		chains[i].InfoServer = infoServer.ForChain(entry.Name, entry.Aliases, chains[i].FirstStreamableBlockNum())
+	}
+
+	return chains, nil
+}
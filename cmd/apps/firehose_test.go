@@ -0,0 +1,28 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isLoopbackListenAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"localhost:9000", true},
+		{"127.0.0.1:9000", true},
+		{"[::1]:9000", true},
+		{":9000", false},
+		{"0.0.0.0:9000", false},
+		{"10.0.0.1:9000", false},
+		{"invalid", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, isLoopbackListenAddr(tt.addr))
+		})
+	}
+}
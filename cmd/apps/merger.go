@@ -1,6 +1,7 @@
 package apps
 
 import (
+	"fmt"
 	"time"
 
 	firecore "github.com/streamingfast/firehose-core"
@@ -23,6 +24,7 @@ func RegisterMergerApp(rootLog *zap.Logger) {
 			cmd.Flags().Uint64("merger-stop-block", 0, "If non-zero, merger will trigger shutdown when blocks have been merged up to this block")
 			cmd.Flags().Duration("merger-time-between-store-lookups", 1*time.Second, "Delay between source store polling (should be higher for remote storage)")
 			cmd.Flags().Duration("merger-time-between-store-pruning", time.Minute, "Delay between source store pruning loops")
+			cmd.Flags().Bool("merger-write-block-hash-index", false, "Write a block hash to block number index file in the index store (common-index-store-url) for each merged bundle, required by the firehose to fetch blocks by hash alone")
 			cmd.Flags().Int("merger-delete-threads", 8, "Number of threads for deleting files in parallel (increase this in case the merger isn't able to keep up with deleting one-block files).")
 			return nil
 		},
@@ -32,16 +34,30 @@ func RegisterMergerApp(rootLog *zap.Logger) {
 				return nil, err
 			}
 
+			var blockHashIndexStoreURL string
+			if viper.GetBool("merger-write-block-hash-index") {
+				blockHashIndexStoreURL, err = firecore.GetIndexStoreURL(runtime.AbsDataDir)
+				if err != nil {
+					return nil, err
+				}
+
+				if blockHashIndexStoreURL == "" {
+					return nil, fmt.Errorf("flag 'common-index-store-url' must be set when 'merger-write-block-hash-index' is enabled")
+				}
+			}
+
 			return merger.New(&merger.Config{
 				GRPCListenAddr:               viper.GetString("merger-grpc-listen-addr"),
 				PruneForkedBlocksAfter:       viper.GetUint64("merger-prune-forked-blocks-after"),
 				StorageOneBlockFilesPath:     oneBlocksStoreURL,
 				StorageMergedBlocksFilesPath: mergedBlocksStoreURL,
 				StorageForkedBlocksFilesPath: forkedBlocksStoreURL,
+				StorageBlockHashIndexPath:    blockHashIndexStoreURL,
 				StopBlock:                    viper.GetUint64("merger-stop-block"),
 				TimeBetweenPruning:           viper.GetDuration("merger-time-between-store-pruning"),
 				TimeBetweenPolling:           viper.GetDuration("merger-time-between-store-lookups"),
 				FilesDeleteThreads:           viper.GetInt("merger-delete-threads"),
+				ChainIdentity:                advertisedChainIdentity(rootLog),
 			}), nil
 		},
 	})
@@ -7,6 +7,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/dmetrics"
+	firecore "github.com/streamingfast/firehose-core"
+	"github.com/streamingfast/firehose-core/metering/spool"
 	"go.uber.org/zap"
 )
 
@@ -23,11 +26,26 @@ func GetCommonMeteringPluginValue() string {
 // for the application. It reads the `common-metering-plugin` flag
 // from the command and returns the plugin after expanding the
 // environment variables in it meaning 'paymentGateway://test?token=${TOKEN}'.
+//
+// When the `common-metering-spool` flag is set, the events are spooled on
+// local disk and replayed to the plugin, see the metering/spool package.
 func GetCommonMeteringPlugin(cmd *cobra.Command, logger *zap.Logger) (dmetering.EventEmitter, error) {
 	// We keep cmd as argument for future proofing, at which point we are going to break
 	// GetCommonMeteringPluginValue above.
 	_ = cmd
 
+	if spoolConfig := viper.GetString("common-metering-spool"); spoolConfig != "" {
+		spoolConfig = firecore.MustReplaceDataDir(viper.GetString("global-data-dir"), spoolConfig)
+
+		eventEmitter, err := spool.New(spoolConfig, GetCommonMeteringPluginValue(), logger)
+		if err != nil {
+			return nil, fmt.Errorf("new metering spool: %w", err)
+		}
+
+		dmetrics.Register(spool.MetricSet)
+		return eventEmitter, nil
+	}
+
 	eventEmitter, err := dmetering.New(GetCommonMeteringPluginValue(), logger)
 	if err != nil {
 		return nil, fmt.Errorf("new metering plugin: %w", err)
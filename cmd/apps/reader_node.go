@@ -2,7 +2,9 @@ package apps
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"time"
@@ -17,6 +19,7 @@ import (
 	"github.com/streamingfast/firehose-core/launcher"
 	nodeManager "github.com/streamingfast/firehose-core/node-manager"
 	nodeManagerApp "github.com/streamingfast/firehose-core/node-manager/app/node_manager"
+	"github.com/streamingfast/firehose-core/node-manager/backup"
 	"github.com/streamingfast/firehose-core/node-manager/metrics"
 	reader "github.com/streamingfast/firehose-core/node-manager/mindreader"
 	"github.com/streamingfast/firehose-core/node-manager/operator"
@@ -43,6 +46,15 @@ func RegisterReaderNodeApp[B firecore.Block](chain *firecore.Chain[B], rootLog *
 			cmd.Flags().String("reader-node-data-dir", "{data-dir}/reader/data", "Directory for node data")
 			cmd.Flags().Bool("reader-node-debug-firehose-logs", false, "[DEV] Prints firehose instrumentation logs to standard output, should be use for debugging purposes only")
 			cmd.Flags().String("reader-node-manager-api-addr", firecore.ReaderNodeManagerAPIAddr, "Acme node manager API address")
+			cmd.Flags().String("reader-node-manager-api-auth-file", "", cli.FlagDescription(`
+				YAML file listing the bearer tokens and TLS client certificate common names allowed to call the node manager API, each
+				with a 'read' (GET endpoints) or 'admin' (every endpoint, including commands) role, see the CHANGELOG for the format. When
+				empty, the API is open to anyone reaching it. The '/healthz' and '/v1/ping' endpoints are never authenticated.
+			`))
+			cmd.Flags().String("reader-node-manager-api-tls-cert", "", "TLS certificate file serving the node manager API over HTTPS, requires 'reader-node-manager-api-tls-key'")
+			cmd.Flags().String("reader-node-manager-api-tls-key", "", "TLS private key file of 'reader-node-manager-api-tls-cert'")
+			cmd.Flags().String("reader-node-manager-api-tls-client-ca", "", "CA certificates file verifying the TLS client certificates of the node manager API callers, required by 'clients' entries of 'reader-node-manager-api-auth-file'")
+			cmd.Flags().String("reader-node-manager-api-audit-log", "", "File the audit entries of the node manager API commands (caller, time, params and outcome) are appended to as JSON lines, on top of being logged")
 			cmd.Flags().Duration("reader-node-readiness-max-latency", 30*time.Second, "Determine the maximum head block latency at which the instance will be determined healthy. Some chains have more regular block production than others.")
 			cmd.Flags().String("reader-node-arguments", "", string(cli.Description(`
 				Defines the node arguments that will be passed to the node on execution. Supports templating, where we will replace certain sub-string with the appropriate value
@@ -55,7 +67,37 @@ func RegisterReaderNodeApp[B firecore.Block](chain *firecore.Chain[B], rootLog *
 
 				Example: 'run blockchain -start {start-block-num} -end {stop-block-num}' may yield 'run blockchain -start 200 -end 500'
 			`)))
-			cmd.Flags().StringSlice("reader-node-backups", []string{}, "Repeatable, space-separated key=values definitions for backups. Example: 'type=gke-pvc-snapshot prefix= tag=v1 freq-blocks=1000 freq-time= project=myproj'")
+			cmd.Flags().StringSlice("reader-node-backups", []string{}, cli.FlagDescription(`
+				Repeatable, space-separated key=values definitions for backups. Example: 'type=gke-pvc-snapshot prefix= tag=v1 freq-blocks=1000 freq-time= project=myproj'.
+
+				The 'tarball' type archives the node data dir as a zstd compressed tarball to the dstore URL 'store' (local path, gs://, s3://, etc.),
+				named '<prefix><last seen block>-<time>.tar.zst', optional keys are 'prefix', 'keep' (number of backups kept, 0 keeps all),
+				'data-dir' (defaults to 'reader-node-data-dir') and 'requires-stop' (default true). Example: 'type=tarball store=gs://bucket/backups keep=5 freq-blocks=100000'.
+				A backup URL can be used as 'reader-node-bootstrap-data-url' to bootstrap a new node.
+			`))
+			cmd.Flags().String("reader-node-backup-catalog-url", "", cli.FlagDescription(`
+				When set, every completed backup is recorded as a JSON entry (block num and hash, node version, size, duration, module and URL)
+				in the backup catalog at this dstore URL, which should be dedicated to it. The catalog allows restoring with '/v1/restore'
+				params 'atOrBeforeBlock=<num>' or 'compatible=true', is listed by '/v1/list_backups' and can bootstrap a new node with
+				'reader-node-bootstrap-data-url' set to 'catalog+<catalog url>'.
+			`))
+			cmd.Flags().String("reader-node-version", "", "Version of the node binary, recorded in the backup catalog and used to select compatible backups on restore and bootstrap")
+			cmd.Flags().Int("reader-node-restart-max", 0, cli.FlagDescription(`
+				Number of times the node process is restarted within 'reader-node-restart-window' when it exits outside of an operator
+				command, the reader node shuts down when it exits once more. When 0, the default, the reader node shuts down as soon as
+				the node process exits. Restarts are reported on the '/v1/restarts' operator API endpoint.
+			`))
+			cmd.Flags().Duration("reader-node-restart-window", 10*time.Minute, "Time window over which the 'reader-node-restart-max' restarts are counted")
+			cmd.Flags().Duration("reader-node-restart-backoff", time.Second, "Delay before restarting the node process, doubled on every subsequent restart within 'reader-node-restart-window'")
+			cmd.Flags().Duration("reader-node-restart-max-backoff", time.Minute, "Maximum delay before restarting the node process")
+			cmd.Flags().Int("reader-node-restart-recovery-after", 0, cli.FlagDescription(`
+				Number of consecutive restarts within 'reader-node-restart-window' after which 'reader-node-restart-recovery-action' is
+				run before restarting the node process, 0 disables it.
+			`))
+			cmd.Flags().String("reader-node-restart-recovery-action", "restore", cli.FlagDescription(`
+				Recovery action run after 'reader-node-restart-recovery-after' consecutive restarts, 'restore' restores the latest backup
+				of the restorable backup module configured in 'reader-node-backups'.
+			`))
 			cmd.Flags().String("reader-node-grpc-listen-addr", firecore.ReaderNodeGRPCAddr, "The gRPC listening address to use for serving real-time blocks")
 			cmd.Flags().Bool("reader-node-discard-after-stop-num", false, "Ignore remaining blocks being processed after stop num (only useful if we discard the reader data after reprocessing a chunk of blocks)")
 			cmd.Flags().String("reader-node-working-dir", "{data-dir}/reader/work", "Path where reader will stores its files")
@@ -91,11 +133,38 @@ func RegisterReaderNodeApp[B firecore.Block](chain *firecore.Chain[B], rootLog *
 
 			backupModules, backupSchedules, err := operator.ParseBackupConfigs(appLogger, backupConfigs, map[string]operator.BackupModuleFactory{
 				"gke-pvc-snapshot": gkeSnapshotterFactory,
+				"tarball":          backup.NewTarballFactory(nodeDataDir, appLogger),
 			})
 			if err != nil {
 				return nil, fmt.Errorf("parse backup configs: %w", err)
 			}
 
+			var backupCatalog operator.BackupCatalog
+			if catalogURL := viper.GetString("reader-node-backup-catalog-url"); catalogURL != "" {
+				if backupCatalog, err = backup.NewDstoreCatalog(firecore.MustReplaceDataDir(sfDataDir, catalogURL)); err != nil {
+					return nil, err
+				}
+			}
+
+			restartPolicy, err := readerNodeRestartPolicy()
+			if err != nil {
+				return nil, err
+			}
+
+			apiAuth, apiTLSConfig, err := readerNodeManagerAPISecurity()
+			if err != nil {
+				return nil, err
+			}
+
+			var auditSink operator.AuditSink
+			if auditLog := viper.GetString("reader-node-manager-api-audit-log"); auditLog != "" {
+				fileSink, err := operator.NewFileAuditSink(firecore.MustReplaceDataDir(sfDataDir, auditLog))
+				if err != nil {
+					return nil, err
+				}
+				auditSink = fileSink
+			}
+
 			ctx, cancel := context.WithTimeout(context.Background(), 4*time.Minute)
 			defer cancel()
 
@@ -154,11 +223,21 @@ func RegisterReaderNodeApp[B firecore.Block](chain *firecore.Chain[B], rootLog *
 					ShutdownDelay:              shutdownDelay,
 					EnableSupervisorMonitoring: true,
 					Bootstrapper:               bootstrapper,
+					RestartPolicy:              restartPolicy,
+					APIAuth:                    apiAuth,
+					APITLSConfig:               apiTLSConfig,
+					AuditSink:                  auditSink,
+					BackupCatalog:              backupCatalog,
+					NodeVersion:                viper.GetString("reader-node-version"),
 				})
 			if err != nil {
 				return nil, fmt.Errorf("unable to create chain operator: %w", err)
 			}
 
+			if closer, ok := auditSink.(io.Closer); ok {
+				chainOperator.OnTerminated(func(error) { closer.Close() })
+			}
+
 			for name, mod := range backupModules {
 				appLogger.Info("registering backup module", zap.String("name", name), zap.Any("module", mod))
 				err := chainOperator.RegisterBackupModule(name, mod)
@@ -201,11 +280,13 @@ func RegisterReaderNodeApp[B firecore.Block](chain *firecore.Chain[B], rootLog *
 			if err != nil {
 				return nil, fmt.Errorf("new reader plugin: %w", err)
 			}
+			readerPlugin.VerifyChainIdentity(advertisedChainIdentity(appLogger))
 
 			superviser.RegisterLogPlugin(readerPlugin)
 
 			return nodeManagerApp.New(&nodeManagerApp.Config{
 				HTTPAddr: httpAddr,
+				HTTPTLS:  apiTLSConfig != nil,
 				GRPCAddr: gprcListenAddr,
 			}, &nodeManagerApp.Modules{
 				Operator:                   chainOperator,
@@ -222,6 +303,64 @@ func RegisterReaderNodeApp[B firecore.Block](chain *firecore.Chain[B], rootLog *
 	})
 }
 
+// readerNodeRestartPolicy returns the restart policy of the reader node flags, nil when restarts are disabled.
+func readerNodeRestartPolicy() (*operator.RestartPolicy, error) {
+	maxRestarts := viper.GetInt("reader-node-restart-max")
+	if maxRestarts <= 0 {
+		return nil, nil
+	}
+
+	policy := &operator.RestartPolicy{
+		MaxRestarts:    maxRestarts,
+		Window:         viper.GetDuration("reader-node-restart-window"),
+		InitialBackoff: viper.GetDuration("reader-node-restart-backoff"),
+		MaxBackoff:     viper.GetDuration("reader-node-restart-max-backoff"),
+	}
+
+	if recoveryAfter := viper.GetInt("reader-node-restart-recovery-after"); recoveryAfter > 0 {
+		switch action := viper.GetString("reader-node-restart-recovery-action"); action {
+		case "restore":
+			policy.RecoveryAfter = recoveryAfter
+			policy.RecoveryAction = operator.RestoreBackupRecovery("latest")
+		default:
+			return nil, fmt.Errorf("invalid 'reader-node-restart-recovery-action' value %q, must be 'restore'", action)
+		}
+	}
+
+	return policy, nil
+}
+
+// readerNodeManagerAPISecurity returns the authentication and TLS configuration of the node manager API flags,
+// nil when not configured.
+func readerNodeManagerAPISecurity() (apiAuth *operator.APIAuth, tlsConfig *tls.Config, err error) {
+	if authFile := viper.GetString("reader-node-manager-api-auth-file"); authFile != "" {
+		if apiAuth, err = operator.ReadAPIAuthFile(authFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	certFile := viper.GetString("reader-node-manager-api-tls-cert")
+	keyFile := viper.GetString("reader-node-manager-api-tls-key")
+	clientCAFile := viper.GetString("reader-node-manager-api-tls-client-ca")
+
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, nil, fmt.Errorf("'reader-node-manager-api-tls-client-ca' requires 'reader-node-manager-api-tls-cert' and 'reader-node-manager-api-tls-key'")
+		}
+		return apiAuth, nil, nil
+	}
+
+	if certFile == "" || keyFile == "" {
+		return nil, nil, fmt.Errorf("both 'reader-node-manager-api-tls-cert' and 'reader-node-manager-api-tls-key' must be set")
+	}
+
+	if tlsConfig, err = operator.NewAPITLSConfig(certFile, keyFile, clientCAFile); err != nil {
+		return nil, nil, fmt.Errorf("node manager API TLS: %w", err)
+	}
+
+	return apiAuth, tlsConfig, nil
+}
+
 var variablesRegex = regexp.MustCompile(`\{(data-dir|node-data-dir|hostname|start-block-num|stop-block-num)\}`)
 
 // buildNodeArguments will resolve and split the given string into arguments, replacing the variables with the appropriate values.
@@ -259,5 +398,10 @@ func createNodeArgumentsResolver(dataDir, nodeDataDir, hostname string, startBlo
 }
 
 func gkeSnapshotterFactory(conf operator.BackupModuleConfig) (operator.BackupModule, error) {
-	return snapshotter.NewGKEPVCSnapshotter(conf)
+	mod, err := snapshotter.NewGKEPVCSnapshotter(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return operator.AdaptLegacyBackupModule(mod), nil
 }
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/streamingfast/bstream"
 	"github.com/streamingfast/cli"
 	"github.com/streamingfast/cli/sflags"
@@ -28,6 +29,7 @@ import (
 	firecore "github.com/streamingfast/firehose-core"
 	info "github.com/streamingfast/firehose-core/firehose/info"
 	"github.com/streamingfast/firehose-core/launcher"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
 	tracing "github.com/streamingfast/sf-tracing"
 	"go.uber.org/zap"
@@ -84,6 +86,12 @@ func start[B firecore.Block](cmd *cobra.Command, dataDir string, args []string,
 	}()
 	dmetering.SetDefaultEmitter(eventEmitter)
 
+	if registry := sflags.MustGetString(cmd, "common-well-known-registry"); registry != "" {
+		if err := wellknown.LoadRegistry(context.Background(), firecore.MustReplaceDataDir(dataDirAbs, registry)); err != nil {
+			return fmt.Errorf("unable to load well-known registry: %w", err)
+		}
+	}
+
 	blockIDEncoding := pbfirehose.InfoResponse_BLOCK_ID_ENCODING_UNSET
 	if enc := sflags.MustGetString(cmd, "advertise-block-id-encoding"); enc != "" {
 		v, found := pbfirehose.InfoResponse_BlockIdEncoding_value[enc]
@@ -158,3 +166,20 @@ func start[B firecore.Block](cmd *cobra.Command, dataDir string, args []string,
 
 	return
 }
+
+// advertisedChainIdentity returns the well-known chain named by 'advertise-chain-name' when it has
+// checkpoints, the reader and the merger refuse to write blocks which do not match them.
+func advertisedChainIdentity(logger *zap.Logger) *wellknown.Chain {
+	chainName := viper.GetString("advertise-chain-name")
+	if chainName == "" {
+		return nil
+	}
+
+	chain := wellknown.WellKnownProtocols.ChainByName(chainName)
+	if !chain.HasCheckpoints() {
+		logger.Debug("no well-known checkpoints for the advertised chain, blocks are not checked against them", zap.String("chain_name", chainName))
+		return nil
+	}
+
+	return chain
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/streamingfast/firehose-core/cmd/apps"
 	"github.com/streamingfast/firehose-core/cmd/tools"
 	"github.com/streamingfast/firehose-core/launcher"
+	dmeteringprometheus "github.com/streamingfast/firehose-core/metering/prometheus"
 	paymentGatewayMetering "github.com/streamingfast/payment-gateway/metering"
 	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
 
@@ -45,6 +46,7 @@ func Main[B firecore.Block](chain *firecore.Chain[B]) {
 	dmeteringgrpc.Register()
 	dmeteringlogger.Register()
 	dmeteringfile.Register()
+	dmeteringprometheus.Register()
 	paymentGatewayMetering.Register()
 
 	chain.Validate()
@@ -196,6 +198,17 @@ func registerCommonFlags[B firecore.Block](chain *firecore.Chain[B]) {
 			recommend using the short form lowercase version.
 		`, strings.Join(acceptedEncodings, ", ")))
 
+		cmd.Flags().String("common-well-known-registry", "", cli.FlagDescription(`
+			[COMMON] YAML (or JSON) registry of well-known chains merged over the built-in one at startup, used to infer and
+			validate the advertised chain name from the genesis block. Either a local path, a dstore URL or an 'http(s)://'
+			URL. It lists protocols under 'protocols', each with 'name', 'block-type', 'buf-build-url', 'bytes-encoding' and
+			'chains' (each with 'name', 'aliases', 'genesis-block-id', 'genesis-block-number' and 'checkpoints', a list of
+			final blocks 'number' and 'id'). Protocols are matched by name and chains within them by name, matching entries
+			override the built-in ones and others are added. The checkpoints of the advertised chain are verified by the
+			firehose/substreams info endpoint on the merged blocks at startup, and by the reader and the merger as blocks
+			pass, which refuse to write blocks from another network.
+		`))
+
 		cmd.Flags().String("common-index-store-url", firecore.IndexStoreURL, "[COMMON] Store URL where to read/write index files (if used on the chain).")
 		cmd.Flags().IntSlice("common-index-block-sizes", []int{100000, 10000, 1000, 100}, "[COMMON] Index bundle sizes that that are considered valid when looking for block indexes")
 
@@ -224,7 +237,16 @@ func registerCommonFlags[B firecore.Block](chain *firecore.Chain[B]) {
 
 		// Authentication, metering and rate limiter plugins
 		cmd.Flags().String("common-auth-plugin", "null://", "[COMMON] Auth plugin URI, see streamingfast/dauth repository")
-		cmd.Flags().String("common-metering-plugin", "null://", "[COMMON] Metering plugin URI, see streamingfast/dmetering repository")
+		cmd.Flags().String("common-metering-plugin", "null://", "[COMMON] Metering plugin URI, see streamingfast/dmetering repository, 'prometheus://' exposes the usage as Prometheus counters per user, API key and endpoint (see firehose-core 'metering/prometheus' package for its options)")
+		cmd.Flags().String("common-metering-spool", "", cli.FlagDescription(`
+			[COMMON] Directory where metering events are spooled before being delivered to the metering plugin, replaying them
+			when the plugin backend recovers from an outage or after a restart (disabled if empty). Query parameters:
+			'max-size' (default '1GiB', events are dropped once reached), 'fsync' ('always', 'interval' (default) or 'never'),
+			'fsync-interval' (default '1s'), 'batch' (default '100') and 'flush-interval' (default '1s'), e.g.
+			'{data-dir}/metering-spool?max-size=512MiB&fsync=always'. Requires the 'grpc://' plugin, delivery is at-least-once,
+			acknowledged by the backend which receives the event IDs to deduplicate in the field 100 of each event and in
+			the 'x-metering-event-ids' header.
+		`))
 
 		// System Behavior
 		cmd.Flags().Uint64("common-auto-mem-limit-percent", 0, "[COMMON] Automatically sets GOMEMLIMIT to a percentage of memory limit from cgroup (useful for container environments)")
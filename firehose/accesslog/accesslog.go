@@ -0,0 +1,148 @@
+// Package accesslog records one structured entry per Firehose request (who asked for what, how it
+// ended and how much it consumed) and ships them to a pluggable sink.
+//
+// Sinks are configured through a URL:
+//
+//   - `file:///var/log/firehose/access.jsonl?max-size=100MiB&max-backups=10`: JSON lines appended to a
+//     local file, rotated once it reaches `max-size` (default `100MiB`), keeping the `max-backups`
+//     (default `10`, `0` keeps them all) most recent rotated files
+//   - `http://host/path` or `https://host/path`: batches of records POSTed as newline delimited JSON
+//   - `grpc://host:port` (plain text) or `grpcs://host:port` (TLS): batches of records sent to the
+//     [PushMethod] unary method, as a `google.protobuf.ListValue` of `google.protobuf.Struct` (one per
+//     record), expecting a `google.protobuf.Empty` response
+//
+// All sinks accept the `buffer` (records queued before being dropped, default `10000`), `batch`
+// (records per write or push, default `100`) and `flush-interval` (default `1s`) query parameters.
+// Writing a record never blocks the request, records are dropped (see the
+// `firehose_access_log_dropped_records` metric) when the sink cannot keep up.
+package accesslog
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Record is the access log entry of a single request.
+type Record struct {
+	Timestamp  time.Time `json:"timestamp"`
+	StartTime  time.Time `json:"start_time"`
+	DurationMs int64     `json:"duration_ms"`
+	Endpoint   string    `json:"endpoint"`
+
+	UserID   string `json:"user_id,omitempty"`
+	APIKeyID string `json:"api_key_id,omitempty"`
+	RealIP   string `json:"real_ip,omitempty"`
+	Meta     string `json:"meta,omitempty"`
+
+	StartBlockNum   int64       `json:"start_block_num"`
+	StopBlockNum    uint64      `json:"stop_block_num,omitempty"`
+	Cursor          string      `json:"cursor,omitempty"`
+	FinalBlocksOnly bool        `json:"final_blocks_only"`
+	Transforms      []Transform `json:"transforms,omitempty"`
+
+	// Status is the gRPC code the request ended with and Reason the `google.rpc.ErrorInfo` reason
+	// attached to it (`COMPLETED` when the request ended successfully)
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	Error  string `json:"error,omitempty"`
+
+	LastCursor  string `json:"last_cursor,omitempty"`
+	BlocksSent  uint64 `json:"blocks_sent"`
+	EgressBytes uint64 `json:"egress_bytes"`
+	ReadBytes   uint64 `json:"read_bytes"`
+}
+
+// Transform describes a transform of the request, Value is its JSON rendering when its type is
+// known to this process.
+type Transform struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// Sink receives the access log records.
+type Sink interface {
+	// Write queues [record] to be written, it never blocks
+	Write(record *Record)
+
+	// Close writes the records still queued and releases the sink, records written afterward are dropped
+	Close() error
+}
+
+const (
+	defaultBufferSize    = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+)
+
+// New creates the sink configured by [config], see the package documentation for the supported URLs.
+func New(config string, logger *zap.Logger) (Sink, error) {
+	u, err := url.Parse(config)
+	if err != nil {
+		return nil, fmt.Errorf("parse access log sink %q: %w", config, err)
+	}
+
+	options, err := newBatchOptions(u)
+	if err != nil {
+		return nil, err
+	}
+
+	logger = logger.Named("access_log").With(zap.String("sink", u.Scheme))
+
+	switch u.Scheme {
+	case "file":
+		return newFileSink(u, options, logger)
+	case "http", "https":
+		return newHTTPSink(u, options, logger), nil
+	case "grpc", "grpcs":
+		return newGRPCSink(u, options, logger)
+	}
+
+	return nil, fmt.Errorf("unsupported access log sink %q, supported sinks are 'file', 'http', 'https', 'grpc' and 'grpcs'", u.Scheme)
+}
+
+type batchOptions struct {
+	bufferSize    int
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// newBatchOptions reads the batching query parameters of [u], removing them from it.
+func newBatchOptions(u *url.URL) (batchOptions, error) {
+	options := batchOptions{
+		bufferSize:    defaultBufferSize,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+	}
+
+	query := u.Query()
+	var err error
+
+	if value := query.Get("buffer"); value != "" {
+		if options.bufferSize, err = strconv.Atoi(value); err != nil || options.bufferSize <= 0 {
+			return options, fmt.Errorf("invalid buffer value %q, must be a positive integer", value)
+		}
+	}
+
+	if value := query.Get("batch"); value != "" {
+		if options.batchSize, err = strconv.Atoi(value); err != nil || options.batchSize <= 0 {
+			return options, fmt.Errorf("invalid batch value %q, must be a positive integer", value)
+		}
+	}
+
+	if value := query.Get("flush-interval"); value != "" {
+		if options.flushInterval, err = time.ParseDuration(value); err != nil || options.flushInterval <= 0 {
+			return options, fmt.Errorf("invalid flush-interval value %q, must be a positive duration", value)
+		}
+	}
+
+	query.Del("buffer")
+	query.Del("batch")
+	query.Del("flush-interval")
+	u.RawQuery = query.Encode()
+
+	return options, nil
+}
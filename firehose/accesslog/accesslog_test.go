@@ -0,0 +1,128 @@
+package accesslog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		config      string
+		expectedErr string
+	}{
+		{"s3://bucket/access", `unsupported access log sink "s3", supported sinks are 'file', 'http', 'https', 'grpc' and 'grpcs'`},
+		{"file:///tmp/access.jsonl?buffer=0", `invalid buffer value "0", must be a positive integer`},
+		{"http://localhost/access?flush-interval=often", `invalid flush-interval value "often", must be a positive duration`},
+		{"file:///tmp/access.jsonl?max-size=big", `invalid max-size value "big", must be a positive size like '100MiB'`},
+		{"grpc://", `grpc access log sink requires an endpoint, e.g. 'grpc://localhost:9000'`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.config, func(t *testing.T) {
+			_, err := New(test.config, zap.NewNop())
+			require.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestFileSink_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "access.jsonl")
+
+	// Each record is ~250 bytes, so every batch of 2 rotates the file
+	sink, err := New("file://"+path+"?max-size=300B&max-backups=2&batch=2", zap.NewNop())
+	require.NoError(t, err)
+
+	for i := int64(0); i < 8; i++ {
+		sink.Write(&Record{Endpoint: "sf.firehose.v2.Stream/Blocks", StartBlockNum: i})
+	}
+	require.NoError(t, sink.Close())
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	records := readRecords(t, path)
+	require.Len(t, records, 2)
+	assert.Equal(t, int64(6), records[0].StartBlockNum)
+	assert.Equal(t, int64(7), records[1].StartBlockNum)
+
+	// Records written after close are dropped
+	sink.Write(&Record{StartBlockNum: 8})
+	assert.Len(t, readRecords(t, path), 2)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		query = r.URL.RawQuery
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	sink, err := New(server.URL+"/access?token=abc&batch=10", zap.NewNop())
+	require.NoError(t, err)
+
+	sink.Write(&Record{Endpoint: "sf.firehose.v2.Stream/Blocks", Reason: "COMPLETED", BlocksSent: 10})
+	sink.Write(&Record{Endpoint: "sf.firehose.v2.Stream/Blocks", Reason: "CANCELED", BlocksSent: 3})
+	require.NoError(t, sink.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, "token=abc", query)
+	require.Len(t, received, 2)
+
+	var record Record
+	require.NoError(t, json.Unmarshal([]byte(received[1]), &record))
+	assert.Equal(t, "CANCELED", record.Reason)
+	assert.Equal(t, uint64(3), record.BlocksSent)
+}
+
+func TestToListValue(t *testing.T) {
+	list, err := toListValue([]*Record{{Endpoint: "sf.firehose.v2.Stream/Blocks", StartBlockNum: -10, Transforms: []Transform{{Type: "type.googleapis.com/google.protobuf.FieldMask"}}}})
+	require.NoError(t, err)
+	require.Len(t, list.Values, 1)
+
+	fields := list.Values[0].GetStructValue().Fields
+	assert.Equal(t, "sf.firehose.v2.Stream/Blocks", fields["endpoint"].GetStringValue())
+	assert.Equal(t, float64(-10), fields["start_block_num"].GetNumberValue())
+	assert.Equal(t, "type.googleapis.com/google.protobuf.FieldMask", fields["transforms"].GetListValue().Values[0].GetStructValue().Fields["type"].GetStringValue())
+}
+
+func readRecords(t *testing.T, path string) (out []*Record) {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := &Record{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), record))
+		out = append(out, record)
+	}
+	require.NoError(t, scanner.Err())
+
+	return out
+}
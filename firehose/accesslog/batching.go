@@ -0,0 +1,110 @@
+package accesslog
+
+import (
+	"sync"
+	"time"
+
+	fhmetrics "github.com/streamingfast/firehose-core/firehose/metrics"
+	"go.uber.org/zap"
+)
+
+// batchingSink queues records and hands them by batches to [write] from a single goroutine, it's
+// the base of every sink.
+type batchingSink struct {
+	options batchOptions
+	write   func(records []*Record) error
+	release func() error
+	logger  *zap.Logger
+
+	mu      sync.RWMutex
+	closed  bool
+	records chan *Record
+	done    chan struct{}
+}
+
+func newBatchingSink(options batchOptions, write func(records []*Record) error, release func() error, logger *zap.Logger) *batchingSink {
+	s := &batchingSink{
+		options: options,
+		write:   write,
+		release: release,
+		logger:  logger,
+		records: make(chan *Record, options.bufferSize),
+		done:    make(chan struct{}),
+	}
+
+	go s.run()
+	return s
+}
+
+func (s *batchingSink) Write(record *Record) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		fhmetrics.AccessLogDroppedRecords.Inc()
+		return
+	}
+
+	select {
+	case s.records <- record:
+	default:
+		fhmetrics.AccessLogDroppedRecords.Inc()
+	}
+}
+
+func (s *batchingSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.records)
+	s.mu.Unlock()
+
+	<-s.done
+
+	if s.release != nil {
+		return s.release()
+	}
+	return nil
+}
+
+func (s *batchingSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.options.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Record, 0, s.options.batchSize)
+	for {
+		select {
+		case record, ok := <-s.records:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+
+			batch = append(batch, record)
+			if len(batch) >= s.options.batchSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (s *batchingSink) flush(batch []*Record) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := s.write(batch); err != nil {
+		fhmetrics.AccessLogDroppedRecords.AddInt(len(batch))
+		s.logger.Warn("unable to write access log records, dropping them", zap.Int("count", len(batch)), zap.Error(err))
+	}
+}
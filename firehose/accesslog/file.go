@@ -0,0 +1,170 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxFileSize    = 100 * 1024 * 1024
+	defaultMaxFileBackups = 10
+
+	rotatedFileTimeLayout = "20060102T150405.000000000"
+)
+
+func newFileSink(u *url.URL, options batchOptions, logger *zap.Logger) (Sink, error) {
+	// `file://./access.jsonl` puts the `.` in the host, so it's kept for relative paths
+	path := u.Host + u.Path
+	if path == "" {
+		return nil, fmt.Errorf("file access log sink requires a path, e.g. 'file:///var/log/firehose/access.jsonl'")
+	}
+
+	query := u.Query()
+	maxSize := uint64(defaultMaxFileSize)
+	if value := query.Get("max-size"); value != "" {
+		size, err := humanize.ParseBytes(value)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("invalid max-size value %q, must be a positive size like '100MiB'", value)
+		}
+		maxSize = size
+	}
+
+	maxBackups := defaultMaxFileBackups
+	if value := query.Get("max-backups"); value != "" {
+		backups, err := strconv.Atoi(value)
+		if err != nil || backups < 0 {
+			return nil, fmt.Errorf("invalid max-backups value %q, must be a positive integer", value)
+		}
+		maxBackups = backups
+	}
+
+	file, err := openRotatingFile(path, int64(maxSize), maxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("writing access log to file", zap.String("path", path), zap.Uint64("max_size", maxSize), zap.Int("max_backups", maxBackups))
+	return newBatchingSink(options, file.write, file.close, logger), nil
+}
+
+// rotatingFile appends JSON lines to [path], moving it aside once it reaches [maxSize]. Rotated
+// files are named `<path>.<UTC time of rotation>` so that they sort chronologically.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create access log directory: %w", err)
+	}
+
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open access log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat access log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) write(records []*Record) error {
+	lines, err := encodeLines(records)
+	if err != nil {
+		return err
+	}
+
+	if f.size > 0 && f.size+int64(len(lines)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return fmt.Errorf("rotate access log file: %w", err)
+		}
+	}
+
+	n, err := f.file.Write(lines)
+	f.size += int64(n)
+	return err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.path, f.path+"."+time.Now().UTC().Format(rotatedFileTimeLayout)); err != nil {
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return f.removeOldBackups()
+}
+
+func (f *rotatingFile) removeOldBackups() error {
+	if f.maxBackups == 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(backups)
+	for len(backups) > f.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+func (f *rotatingFile) close() error {
+	return f.file.Close()
+}
+
+// encodeLines renders [records] as newline delimited JSON.
+func encodeLines(records []*Record) ([]byte, error) {
+	buffer := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buffer)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return nil, fmt.Errorf("encode access log record: %w", err)
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,98 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/streamingfast/dgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// PushMethod is the gRPC method receiving the records pushed by the `grpc://` and `grpcs://` sinks.
+const PushMethod = "/sf.firehose.accesslog.v1.AccessLog/Push"
+
+const pushTimeout = 10 * time.Second
+
+func newHTTPSink(u *url.URL, options batchOptions, logger *zap.Logger) Sink {
+	endpoint := u.String()
+	client := &http.Client{Timeout: pushTimeout}
+
+	logger.Info("pushing access log over http", zap.String("endpoint", u.Redacted()))
+	return newBatchingSink(options, func(records []*Record) error {
+		body, err := encodeLines(records)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Post(endpoint, "application/x-ndjson", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("push rejected with status %s", resp.Status)
+		}
+		return nil
+	}, nil, logger)
+}
+
+func newGRPCSink(u *url.URL, options batchOptions, logger *zap.Logger) (Sink, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("grpc access log sink requires an endpoint, e.g. 'grpc://localhost:9000'")
+	}
+
+	var conn *grpc.ClientConn
+	var err error
+	if u.Scheme == "grpcs" {
+		conn, err = dgrpc.NewExternalClientConn(u.Host)
+	} else {
+		conn, err = dgrpc.NewInternalNoWaitClientConn(u.Host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create access log gRPC client: %w", err)
+	}
+
+	logger.Info("pushing access log over grpc", zap.String("endpoint", u.Host))
+	return newBatchingSink(options, func(records []*Record) error {
+		request, err := toListValue(records)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+		defer cancel()
+
+		return conn.Invoke(ctx, PushMethod, request, &emptypb.Empty{})
+	}, conn.Close, logger), nil
+}
+
+// toListValue converts [records] to structs through their JSON rendering, so that field names are the
+// same whatever the sink.
+func toListValue(records []*Record) (*structpb.ListValue, error) {
+	list := &structpb.ListValue{Values: make([]*structpb.Value, len(records))}
+	for i, record := range records {
+		content, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("encode access log record: %w", err)
+		}
+
+		value := &structpb.Struct{}
+		if err := value.UnmarshalJSON(content); err != nil {
+			return nil, fmt.Errorf("convert access log record: %w", err)
+		}
+		list.Values[i] = structpb.NewStructValue(value)
+	}
+
+	return list, nil
+}
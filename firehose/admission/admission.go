@@ -0,0 +1,201 @@
+// Package admission decides whether a new Firehose stream can start given the current server load.
+//
+// Live-only streams (served from the hub's memory) are always admitted. Historical streams, which
+// read and preprocess merged blocks, are admitted only while the number of active historical streams,
+// the in-flight merged blocks preprocessing and the heap usage are all below their configured limit.
+// Otherwise they wait in a queue for up to the configured queue timeout and are rejected with
+// `ResourceExhausted` if the server is still overloaded after that.
+package admission
+
+import (
+	"context"
+	"fmt"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	fhmetrics "github.com/streamingfast/firehose-core/firehose/metrics"
+	"go.uber.org/zap"
+)
+
+// pollInterval is how often a queued stream re-checks the load that is not signaled on
+// change (in-flight preprocessing and heap usage).
+const pollInterval = 250 * time.Millisecond
+
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+type Config struct {
+	// MaxHistoricalStreams is the maximum number of concurrent historical streams, 0 means unlimited
+	MaxHistoricalStreams int
+
+	// MaxInflightPreprocessing is the maximum number of merged blocks being preprocessed (transforms)
+	// across all streams above which new historical streams are not admitted, 0 means unlimited
+	MaxInflightPreprocessing int64
+
+	// MaxHeapBytes is the heap usage above which new historical streams are not admitted, 0 means unlimited
+	MaxHeapBytes uint64
+
+	// QueueTimeout is how long a historical stream waits to be admitted before being rejected,
+	// 0 rejects it right away
+	QueueTimeout time.Duration
+
+	// RetryAfter is the delay suggested to rejected clients before retrying
+	RetryAfter time.Duration
+}
+
+// RejectedError is returned by [Controller.Admit] when a stream is not admitted.
+type RejectedError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("server overloaded (%s), retry in %s", e.Reason, e.RetryAfter)
+}
+
+type Controller struct {
+	config                Config
+	inflightPreprocessing func() int64
+	heapBytes             func() uint64
+	logger                *zap.Logger
+
+	mu                sync.Mutex
+	historicalStreams int
+	liveStreams       int
+	// released is closed (and replaced) each time a historical stream ends, waking up queued streams
+	released chan struct{}
+}
+
+// NewController creates a controller enforcing [config], [inflightPreprocessing] reports the number
+// of merged blocks currently being preprocessed, it can be nil when unknown.
+func NewController(config Config, inflightPreprocessing func() int64, logger *zap.Logger) *Controller {
+	if inflightPreprocessing == nil {
+		inflightPreprocessing = func() int64 { return 0 }
+	}
+
+	return &Controller{
+		config:                config,
+		inflightPreprocessing: inflightPreprocessing,
+		heapBytes:             readHeapBytes,
+		logger:                logger,
+		released:              make(chan struct{}),
+	}
+}
+
+// Admit admits a new stream, waiting in queue up to the configured timeout for historical ones. The
+// returned release func must be called once the admitted stream ends. A [RejectedError] is returned
+// when the stream could not be admitted.
+func (c *Controller) Admit(ctx context.Context, liveOnly bool) (release func(), err error) {
+	if liveOnly {
+		c.mu.Lock()
+		c.liveStreams++
+		c.updateMetricsLocked()
+		c.mu.Unlock()
+
+		return c.releaseFunc(func() { c.liveStreams-- }), nil
+	}
+
+	reason, released := c.tryAdmitHistorical()
+	if reason == "" {
+		return c.releaseFunc(c.releaseHistorical), nil
+	}
+
+	if c.config.QueueTimeout > 0 {
+		fhmetrics.AdmissionQueuedStreams.Inc()
+		defer fhmetrics.AdmissionQueuedStreams.Dec()
+
+		deadline := time.NewTimer(c.config.QueueTimeout)
+		defer deadline.Stop()
+
+	waiting:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-deadline.C:
+				break waiting
+			case <-released:
+			case <-time.After(pollInterval):
+			}
+
+			if reason, released = c.tryAdmitHistorical(); reason == "" {
+				return c.releaseFunc(c.releaseHistorical), nil
+			}
+		}
+	}
+
+	fhmetrics.AdmissionRejectedStreams.Inc()
+	c.logger.Info("historical stream not admitted", zap.String("reason", reason))
+
+	return nil, &RejectedError{Reason: reason, RetryAfter: c.config.RetryAfter}
+}
+
+// tryAdmitHistorical admits a historical stream if the server is not overloaded, returning the reason
+// why it's overloaded otherwise along with the channel closed on the next historical stream release.
+func (c *Controller) tryAdmitHistorical() (reason string, released <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if reason := c.overloadReason(); reason != "" {
+		return reason, c.released
+	}
+
+	c.historicalStreams++
+	c.updateMetricsLocked()
+	return "", nil
+}
+
+func (c *Controller) overloadReason() string {
+	if c.config.MaxHistoricalStreams > 0 && c.historicalStreams >= c.config.MaxHistoricalStreams {
+		return fmt.Sprintf("%d active historical streams", c.historicalStreams)
+	}
+
+	if c.config.MaxInflightPreprocessing > 0 {
+		if inflight := c.inflightPreprocessing(); inflight >= c.config.MaxInflightPreprocessing {
+			return fmt.Sprintf("%d blocks being preprocessed", inflight)
+		}
+	}
+
+	if c.config.MaxHeapBytes > 0 {
+		if heap := c.heapBytes(); heap >= c.config.MaxHeapBytes {
+			return fmt.Sprintf("%d bytes of heap in use", heap)
+		}
+	}
+
+	return ""
+}
+
+func (c *Controller) releaseHistorical() {
+	c.historicalStreams--
+
+	close(c.released)
+	c.released = make(chan struct{})
+}
+
+// releaseFunc returns a func calling [f] under lock once, however many times it's called.
+func (c *Controller) releaseFunc(f func()) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			f()
+			c.updateMetricsLocked()
+			c.mu.Unlock()
+		})
+	}
+}
+
+func (c *Controller) updateMetricsLocked() {
+	fhmetrics.AdmissionHistoricalStreams.SetUint64(uint64(c.historicalStreams))
+	fhmetrics.AdmissionLiveStreams.SetUint64(uint64(c.liveStreams))
+}
+
+func readHeapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
@@ -0,0 +1,90 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func TestController_Admit(t *testing.T) {
+	inflight := atomic.NewInt64(0)
+	controller := NewController(Config{
+		MaxHistoricalStreams:     1,
+		MaxInflightPreprocessing: 10,
+		MaxHeapBytes:             1000,
+		RetryAfter:               5 * time.Second,
+	}, inflight.Load, zap.NewNop())
+
+	heap := atomic.NewUint64(0)
+	controller.heapBytes = heap.Load
+
+	ctx := context.Background()
+
+	release, err := controller.Admit(ctx, false)
+	require.NoError(t, err)
+
+	_, err = controller.Admit(ctx, false)
+	assert.Equal(t, &RejectedError{Reason: "1 active historical streams", RetryAfter: 5 * time.Second}, err)
+
+	// Live-only streams are admitted whatever the load
+	releaseLive, err := controller.Admit(ctx, true)
+	require.NoError(t, err)
+	releaseLive()
+
+	release()
+	release() // releasing twice is a no-op
+
+	inflight.Store(10)
+	_, err = controller.Admit(ctx, false)
+	assert.Equal(t, &RejectedError{Reason: "10 blocks being preprocessed", RetryAfter: 5 * time.Second}, err)
+
+	inflight.Store(0)
+	heap.Store(1000)
+	_, err = controller.Admit(ctx, false)
+	assert.Equal(t, &RejectedError{Reason: "1000 bytes of heap in use", RetryAfter: 5 * time.Second}, err)
+
+	heap.Store(0)
+	release, err = controller.Admit(ctx, false)
+	require.NoError(t, err)
+	release()
+}
+
+func TestController_AdmitQueued(t *testing.T) {
+	controller := NewController(Config{MaxHistoricalStreams: 1, QueueTimeout: time.Minute}, nil, zap.NewNop())
+	ctx := context.Background()
+
+	release, err := controller.Admit(ctx, false)
+	require.NoError(t, err)
+
+	admitted := make(chan error)
+	go func() {
+		_, err := controller.Admit(ctx, false)
+		admitted <- err
+	}()
+
+	select {
+	case <-admitted:
+		t.Fatal("second stream admitted while the first one is active")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+
+	select {
+	case err := <-admitted:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("queued stream not admitted after release")
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = controller.Admit(cancelledCtx, false)
+	assert.ErrorIs(t, err, context.Canceled)
+}
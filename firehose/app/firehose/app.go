@@ -30,6 +30,8 @@ import (
 	"github.com/streamingfast/dstore"
 	firecore "github.com/streamingfast/firehose-core"
 	"github.com/streamingfast/firehose-core/firehose"
+	"github.com/streamingfast/firehose-core/firehose/accesslog"
+	"github.com/streamingfast/firehose-core/firehose/blockhash"
 	"github.com/streamingfast/firehose-core/firehose/info"
 	"github.com/streamingfast/firehose-core/firehose/metrics"
 	"github.com/streamingfast/firehose-core/firehose/server"
@@ -43,11 +45,56 @@ type Config struct {
 	MergedBlocksStoreURL    string
 	OneBlocksStoreURL       string
 	ForkedBlocksStoreURL    string
+	BlockHashIndexStoreURL  string        // Store where the merger writes block hash index files, can be "" in which case merged blocks cannot be fetched by hash alone
+	BlockCacheSizeBytes     uint64        // Maximum size of the decoded merged bundles cached to serve single block requests, 0 disables the cache
+	LiveInfoTTL             time.Duration // How long the live info (head, LIB and merged blocks range) is cached, 0 disables the live info
 	BlockStreamAddr         string        // gRPC endpoint to get real-time blocks, can be "" in which live streams is disabled
 	GRPCListenAddr          string        // gRPC address where this app will listen to
 	GRPCShutdownGracePeriod time.Duration // The duration we allow for gRPC connections to terminate gracefully prior forcing shutdown
+	DrainTimeout            time.Duration // The duration we allow for active streams to be ended at a block boundary (with their last cursor) when draining, prior the gRPC shutdown
 	ServiceDiscoveryURL     *url.URL
 	ServerOptions           []server.Option `json:"-"`
+
+	ChainName         string          // Value of the 'x-firehose-chain' routing header selecting the main chain, requests without the header are also served the main chain
+	Chains            []*ChainConfig  // Other chains served by this app, see [ChainConfig]
+	MainServerOptions []server.Option `json:"-"` // Options applied only to the server of the main chain on top of [ServerOptions], like those listening on extra addresses
+	AccessLogSink     accesslog.Sink  `json:"-"` // Sink shared by the servers of all chains, closed once they are all shut down
+}
+
+// ChainConfig configures a chain served by the app next to the main one. Its requests are those
+// carrying its [Name] in the 'x-firehose-chain' header on the main listen address(es), or all of
+// those received on its own [GRPCListenAddr] if set.
+//
+// `bstream` keeps the first streamable block in a process wide variable
+// ([bstream.GetProtocolFirstStreamableBlock], the main chain's one), the chain's own is applied by
+// its stream factory and advertised by its info server. The maximum normal LIB distance
+// ([bstream.GetMaxNormalLIBDistance]) and the block ID normalization ([bstream.NormalizeBlockID]) are
+// properties of the chain type, shared by all the chains of the process.
+type ChainConfig struct {
+	Name                   string
+	MergedBlocksStoreURL   string
+	OneBlocksStoreURL      string
+	ForkedBlocksStoreURL   string
+	BlockHashIndexStoreURL string // Store where the chain's merger writes block hash index files, can be "" in which case merged blocks cannot be fetched by hash alone
+	BlockStreamAddr        string // gRPC endpoint to get real-time blocks, can be "" in which live streams is disabled
+	GRPCListenAddr         string // Address(es) serving only this chain, can be ""
+
+	// FirstStreamableBlock is the first streamable block of the chain, nil when it's the one of the
+	// main chain, see [ChainConfig.FirstStreamableBlockNum].
+	FirstStreamableBlock *uint64
+
+	TransformRegistry *transform.Registry `json:"-"`
+	InfoServer        *info.InfoServer    `json:"-"`
+}
+
+// FirstStreamableBlockNum returns the first streamable block of the chain. `bstream` never streams
+// blocks below the process wide [bstream.GetProtocolFirstStreamableBlock], a lower one is raised to it.
+func (c *ChainConfig) FirstStreamableBlockNum() uint64 {
+	if c.FirstStreamableBlock == nil || *c.FirstStreamableBlock < bstream.GetProtocolFirstStreamableBlock {
+		return bstream.GetProtocolFirstStreamableBlock
+	}
+
+	return *c.FirstStreamableBlock
 }
 
 type Modules struct {
@@ -89,39 +136,154 @@ func (a *App) Run() error {
 		return fmt.Errorf("invalid app config: %w", err)
 	}
 
-	mergedBlocksStore, err := dstore.NewDBinStore(a.config.MergedBlocksStoreURL)
+	mainChain, err := a.newChainBackend(&ChainConfig{
+		MergedBlocksStoreURL:   a.config.MergedBlocksStoreURL,
+		OneBlocksStoreURL:      a.config.OneBlocksStoreURL,
+		ForkedBlocksStoreURL:   a.config.ForkedBlocksStoreURL,
+		BlockHashIndexStoreURL: a.config.BlockHashIndexStoreURL,
+		BlockStreamAddr:        a.config.BlockStreamAddr,
+		TransformRegistry:      a.modules.TransformRegistry,
+		InfoServer:             a.modules.InfoServer,
+	}, a.modules.HeadBlockNumberMetric, a.modules.HeadTimeDriftMetric)
+	if err != nil {
+		return err
+	}
+
+	serverOptions := a.config.ServerOptions
+	if a.config.AccessLogSink != nil {
+		serverOptions = append(serverOptions, server.WithAccessLogSink(a.config.AccessLogSink))
+	}
+
+	chainRoutes := map[string]*server.Server{}
+	var chainBackends []*chainBackend
+	for _, chainConfig := range a.config.Chains {
+		if chainConfig.FirstStreamableBlock != nil && *chainConfig.FirstStreamableBlock < bstream.GetProtocolFirstStreamableBlock {
+			a.logger.Warn("chain first streamable block is below the process wide one, its blocks below it cannot be streamed",
+				zap.String("chain", chainConfig.Name),
+				zap.Uint64("first_streamable_block", *chainConfig.FirstStreamableBlock),
+				zap.Uint64("process_first_streamable_block", bstream.GetProtocolFirstStreamableBlock),
+			)
+		}
+
+		backend, err := a.newChainBackend(
+			chainConfig,
+			metrics.Metricset.NewHeadBlockNumber("firehose-"+chainConfig.Name),
+			metrics.Metricset.NewHeadTimeDrift("firehose-"+chainConfig.Name),
+		)
+		if err != nil {
+			return fmt.Errorf("chain %q: %w", chainConfig.Name, err)
+		}
+
+		backend.server = server.New(
+			chainConfig.TransformRegistry,
+			backend.streamFactory,
+			backend.blockGetter,
+			a.logger.With(zap.String("chain", chainConfig.Name)),
+			a.modules.Authenticator,
+			a.IsReady,
+			chainConfig.GRPCListenAddr,
+			nil,
+			chainConfig.InfoServer,
+			append(serverOptions, server.WithForkableHub(backend.forkableHub), server.WithLiveInfo(backend.liveInfo))...,
+		)
+
+		chainRoutes[chainConfig.Name] = backend.server
+		chainBackends = append(chainBackends, backend)
+	}
+
+	mainChain.server = server.New(
+		a.modules.TransformRegistry,
+		mainChain.streamFactory,
+		mainChain.blockGetter,
+		a.logger,
+		a.modules.Authenticator,
+		a.IsReady,
+		a.config.GRPCListenAddr,
+		a.config.ServiceDiscoveryURL,
+		a.modules.InfoServer,
+		append(append(serverOptions, a.config.MainServerOptions...), server.WithForkableHub(mainChain.forkableHub), server.WithLiveInfo(mainChain.liveInfo), server.WithChainRoutes(a.config.ChainName, chainRoutes))...,
+	)
+
+	servers := []*server.Server{mainChain.server}
+	for _, backend := range chainBackends {
+		servers = append(servers, backend.server)
+	}
+
+	a.OnTerminating(func(_ error) {
+		// The servers of the other chains share the drain of the main chain's one
+		mainChain.server.Drain(a.config.DrainTimeout)
+		for _, firehoseServer := range servers {
+			firehoseServer.Shutdown(a.config.GRPCShutdownGracePeriod)
+		}
+
+		if a.config.AccessLogSink != nil {
+			if err := a.config.AccessLogSink.Close(); err != nil {
+				a.logger.Warn("unable to close access log sink", zap.Error(err))
+			}
+		}
+	})
+	for _, firehoseServer := range servers {
+		firehoseServer.OnTerminated(a.Shutdown)
+	}
+
+	if a.modules.CheckPendingShutdown != nil {
+		go a.drainOnPendingShutdown(mainChain.server)
+	}
+
+	for _, backend := range chainBackends {
+		go a.launchChain(backend, false)
+	}
+	go a.launchChain(mainChain, true)
+
+	return nil
+}
+
+// chainBackend holds everything needed to serve a single chain.
+type chainBackend struct {
+	mergedBlocksStore dstore.Store
+	oneBlocksStore    dstore.Store
+	forkableHub       *hub.ForkableHub
+	streamFactory     *firecore.StreamFactory
+	blockGetter       *firehose.BlockGetter
+	infoServer        *info.InfoServer
+	liveInfo          *info.LiveInfoProvider
+	server            *server.Server
+}
+
+// newChainBackend sets up the stores, the hub and the block sources of a chain, the head metrics are
+// updated with each block received from the live source.
+func (a *App) newChainBackend(config *ChainConfig, headBlockNumber *dmetrics.HeadBlockNum, headTimeDrift *dmetrics.HeadTimeDrift) (*chainBackend, error) {
+	mergedBlocksStore, err := dstore.NewDBinStore(config.MergedBlocksStoreURL)
 	if err != nil {
-		return fmt.Errorf("failed setting up block store from url %q: %w", a.config.MergedBlocksStoreURL, err)
+		return nil, fmt.Errorf("failed setting up block store from url %q: %w", config.MergedBlocksStoreURL, err)
 	}
 
-	oneBlocksStore, err := dstore.NewDBinStore(a.config.OneBlocksStoreURL)
+	oneBlocksStore, err := dstore.NewDBinStore(config.OneBlocksStoreURL)
 	if err != nil {
-		return fmt.Errorf("failed setting up block store from url %q: %w", a.config.OneBlocksStoreURL, err)
+		return nil, fmt.Errorf("failed setting up block store from url %q: %w", config.OneBlocksStoreURL, err)
 	}
 
 	// set to empty store interface if URL is ""
 	var forkedBlocksStore dstore.Store
-	if a.config.ForkedBlocksStoreURL != "" {
-		forkedBlocksStore, err = dstore.NewDBinStore(a.config.ForkedBlocksStoreURL)
+	if config.ForkedBlocksStoreURL != "" {
+		forkedBlocksStore, err = dstore.NewDBinStore(config.ForkedBlocksStoreURL)
 		if err != nil {
-			return fmt.Errorf("failed setting up block store from url %q: %w", a.config.ForkedBlocksStoreURL, err)
+			return nil, fmt.Errorf("failed setting up block store from url %q: %w", config.ForkedBlocksStoreURL, err)
 		}
 	}
 
-	withLive := a.config.BlockStreamAddr != ""
-
 	var forkableHub *hub.ForkableHub
 
-	if withLive {
+	if config.BlockStreamAddr != "" {
 		liveSourceFactory := bstream.SourceFactory(func(h bstream.Handler) bstream.Source {
 
 			return blockstream.NewSource(
 				context.Background(),
-				a.config.BlockStreamAddr,
+				config.BlockStreamAddr,
 				2,
 				bstream.HandlerFunc(func(blk *pbbstream.Block, obj interface{}) error {
-					a.modules.HeadBlockNumberMetric.SetUint64(blk.Number)
-					a.modules.HeadTimeDriftMetric.SetBlockTime(blk.Time())
+					headBlockNumber.SetUint64(blk.Number)
+					headTimeDrift.SetBlockTime(blk.Time())
 					return h.ProcessBlock(blk, obj)
 				}),
 				blockstream.WithRequester("firehose"),
@@ -138,52 +300,87 @@ func (a *App) Run() error {
 		mergedBlocksStore,
 		forkedBlocksStore,
 		forkableHub,
-		a.modules.TransformRegistry,
+		config.TransformRegistry,
+		firecore.WithFirstStreamableBlock(config.FirstStreamableBlockNum()),
 	)
 
-	blockGetter := firehose.NewBlockGetter(mergedBlocksStore, forkedBlocksStore, forkableHub)
+	var blockHashIndex *blockhash.Index
+	if config.BlockHashIndexStoreURL != "" {
+		blockHashIndexStore, err := dstore.NewStore(config.BlockHashIndexStoreURL, "", "", false)
+		if err != nil {
+			return nil, fmt.Errorf("failed setting up block hash index store from url %q: %w", config.BlockHashIndexStoreURL, err)
+		}
 
-	firehoseServer := server.New(
-		a.modules.TransformRegistry,
-		streamFactory,
-		blockGetter,
-		a.logger,
-		a.modules.Authenticator,
-		a.IsReady,
-		a.config.GRPCListenAddr,
-		a.config.ServiceDiscoveryURL,
-		a.modules.InfoServer,
-		a.config.ServerOptions...,
-	)
+		blockHashIndex = blockhash.NewIndex(blockHashIndexStore, a.logger)
 
-	a.OnTerminating(func(_ error) {
-		firehoseServer.Shutdown(a.config.GRPCShutdownGracePeriod)
-	})
-	firehoseServer.OnTerminated(a.Shutdown)
+		ctx, cancel := context.WithCancel(context.Background())
+		a.OnTerminating(func(_ error) { cancel() })
+		go blockHashIndex.Run(ctx, 30*time.Second)
+	}
+
+	var liveInfo *info.LiveInfoProvider
+	if a.config.LiveInfoTTL > 0 {
+		liveInfo = info.NewLiveInfoProvider(forkableHub, mergedBlocksStore, a.config.LiveInfoTTL, a.logger)
+	}
+
+	return &chainBackend{
+		mergedBlocksStore: mergedBlocksStore,
+		oneBlocksStore:    oneBlocksStore,
+		forkableHub:       forkableHub,
+		streamFactory:     streamFactory,
+		blockGetter:       firehose.NewBlockGetter(mergedBlocksStore, forkedBlocksStore, forkableHub, blockHashIndex, firehose.WithBlockCache(a.config.BlockCacheSizeBytes)),
+		infoServer:        config.InfoServer,
+		liveInfo:          liveInfo,
+	}, nil
+}
 
-	go func() {
-		if withLive {
-			a.logger.Info("waiting until hub is real-time synced")
-			select {
-			case <-forkableHub.Ready:
+// launchChain waits for the chain's hub to be real-time and its info server to be initialized then
+// launches its server. The app is ready once the main chain is launched.
+func (a *App) launchChain(backend *chainBackend, mainChain bool) {
+	withLive := backend.forkableHub != nil
+	if withLive {
+		a.logger.Info("waiting until hub is real-time synced")
+		select {
+		case <-backend.forkableHub.Ready:
+			if mainChain {
 				metrics.AppReadiness.SetReady()
-			case <-a.Terminating():
-				return
 			}
+		case <-a.Terminating():
+			return
 		}
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
-		defer cancel()
-		if err := a.modules.InfoServer.Init(ctx, forkableHub, mergedBlocksStore, oneBlocksStore, a.logger); err != nil {
-			a.Shutdown(fmt.Errorf("cannot initialize info server: %w", err))
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	if err := backend.infoServer.Init(ctx, backend.forkableHub, backend.mergedBlocksStore, backend.oneBlocksStore, a.logger); err != nil {
+		a.Shutdown(fmt.Errorf("cannot initialize info server: %w", err))
+	}
 
-		a.logger.Info("launching gRPC firehoseServer", zap.Bool("live_support", withLive))
+	a.logger.Info("launching gRPC firehoseServer", zap.Bool("live_support", withLive), zap.Bool("main_chain", mainChain))
+	if mainChain {
 		a.isReady.CAS(false, true)
-		firehoseServer.Launch()
-	}()
+	}
+	backend.server.Launch()
+}
 
-	return nil
+// drainOnPendingShutdown starts draining the server as soon as a shutdown is pending, that is during the
+// 'common-system-shutdown-signal-delay' following the termination signal.
+func (a *App) drainOnPendingShutdown(firehoseServer *server.Server) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.Terminating():
+			return
+		case <-ticker.C:
+			if a.modules.CheckPendingShutdown() {
+				a.logger.Info("shutdown pending, draining firehose server")
+				firehoseServer.Drain(a.config.DrainTimeout)
+				return
+			}
+		}
+	}
 }
 
 // IsReady return `true` if the apps is ready to accept requests, `false` is returned
@@ -205,5 +402,20 @@ func (a *App) IsReady(ctx context.Context) bool {
 // Validate inspects itself to determine if the current config is valid according to
 // Firehose rules.
 func (config *Config) Validate() error {
+	seen := map[string]bool{config.ChainName: true}
+	for _, chain := range config.Chains {
+		switch {
+		case chain.Name == "":
+			return fmt.Errorf("chain name is required")
+		case seen[chain.Name]:
+			return fmt.Errorf("chain %q is configured more than once", chain.Name)
+		case chain.MergedBlocksStoreURL == "" || chain.OneBlocksStoreURL == "":
+			return fmt.Errorf("chain %q: merged blocks and one blocks store urls are required", chain.Name)
+		case chain.TransformRegistry == nil || chain.InfoServer == nil:
+			return fmt.Errorf("chain %q: transform registry and info server are required", chain.Name)
+		}
+		seen[chain.Name] = true
+	}
+
 	return nil
 }
@@ -0,0 +1,21 @@
+package firehose
+
+import (
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainConfig_FirstStreamableBlockNum(t *testing.T) {
+	processWide := bstream.GetProtocolFirstStreamableBlock
+	defer func() { bstream.GetProtocolFirstStreamableBlock = processWide }()
+	bstream.GetProtocolFirstStreamableBlock = 10
+
+	ptr := func(num uint64) *uint64 { return &num }
+
+	assert.Equal(t, uint64(10), (&ChainConfig{}).FirstStreamableBlockNum())
+	assert.Equal(t, uint64(10), (&ChainConfig{FirstStreamableBlock: ptr(10)}).FirstStreamableBlockNum())
+	assert.Equal(t, uint64(250), (&ChainConfig{FirstStreamableBlock: ptr(250)}).FirstStreamableBlockNum())
+	assert.Equal(t, uint64(10), (&ChainConfig{FirstStreamableBlock: ptr(1)}).FirstStreamableBlockNum(), "bstream never streams below the process wide one")
+}
@@ -0,0 +1,193 @@
+package firehose
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/firehose/metrics"
+	"go.uber.org/atomic"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+)
+
+const mergedBundleSize = 100
+
+// bundleLoadTimeout bounds a bundle load, which is detached from the cancellation of the request
+// that triggered it since concurrent requests for the same bundle are waiting on it.
+const bundleLoadTimeout = 2 * time.Minute
+
+// blockCache is a size bounded LRU cache of decoded merged bundles, loads of the same bundle
+// happening concurrently are de-duplicated.
+//
+// Blocks held by the cache are shared between requests and must never be mutated.
+type blockCache struct {
+	maxSizeBytes uint64
+
+	mu        sync.Mutex
+	sizeBytes uint64
+	lru       *list.List // of *cachedBundle, most recently used first
+	bundles   map[uint64]*list.Element
+
+	loads singleflight.Group
+}
+
+type cachedBundle struct {
+	baseNum   uint64
+	blocks    map[uint64]*cachedBlock
+	sizeBytes uint64
+
+	// compressedBytes is the amount of bytes read from the store to load the bundle
+	compressedBytes uint64
+}
+
+type cachedBlock struct {
+	block *pbbstream.Block
+
+	// compressedBytes is the share of the bundle's compressed bytes attributed to this block,
+	// proportional to its size, that is metered each time the block is served so that cached
+	// and uncached reads are metered the same way.
+	compressedBytes uint64
+}
+
+func newBlockCache(maxSizeBytes uint64) *blockCache {
+	return &blockCache{
+		maxSizeBytes: maxSizeBytes,
+		lru:          list.New(),
+		bundles:      make(map[uint64]*list.Element),
+	}
+}
+
+// Get returns the block [num] of the merged bundle containing it, loading the bundle from [store]
+// if it's not cached yet. Returns `nil, nil` if the bundle exists but does not contain [num].
+func (c *blockCache) Get(ctx context.Context, num uint64, store dstore.Store) (*cachedBlock, error) {
+	baseNum := num - num%mergedBundleSize
+
+	if bundle := c.cachedBundle(baseNum); bundle != nil {
+		metrics.BlockCacheHits.Inc()
+		metrics.BlockCacheBytesSaved.AddUint64(bundle.compressedBytes)
+
+		return bundle.blocks[num], nil
+	}
+
+	// Keyed by base block so that all concurrent requests for blocks of the same bundle share the load
+	out, err, _ := c.loads.Do(strconv.FormatUint(baseNum, 10), func() (interface{}, error) {
+		// The bundle might have been loaded between our lookup and becoming the leader
+		if bundle := c.cachedBundle(baseNum); bundle != nil {
+			return bundle, nil
+		}
+
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), bundleLoadTimeout)
+		defer cancel()
+
+		bundle, err := loadBundle(loadCtx, baseNum, store)
+		if err != nil {
+			return nil, err
+		}
+
+		c.add(bundle)
+		return bundle, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.BlockCacheMisses.Inc()
+	return out.(*cachedBundle).blocks[num], nil
+}
+
+func (c *blockCache) cachedBundle(baseNum uint64) *cachedBundle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, found := c.bundles[baseNum]
+	if !found {
+		return nil
+	}
+
+	c.lru.MoveToFront(element)
+	return element.Value.(*cachedBundle)
+}
+
+func (c *blockCache) add(bundle *cachedBundle) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if bundle.sizeBytes > c.maxSizeBytes {
+		// Would evict everything else and still not fit, serve it without caching it
+		return
+	}
+
+	c.bundles[bundle.baseNum] = c.lru.PushFront(bundle)
+	c.sizeBytes += bundle.sizeBytes
+
+	for c.sizeBytes > c.maxSizeBytes {
+		oldest := c.lru.Back()
+		evicted := c.lru.Remove(oldest).(*cachedBundle)
+		delete(c.bundles, evicted.baseNum)
+		c.sizeBytes -= evicted.sizeBytes
+	}
+
+	metrics.BlockCacheSizeBytes.SetUint64(c.sizeBytes)
+}
+
+func loadBundle(ctx context.Context, baseNum uint64, store dstore.Store) (*cachedBundle, error) {
+	compressedBytes := atomic.NewUint64(0)
+	if clonable, ok := store.(dstore.Clonable); ok {
+		var err error
+		store, err = clonable.Clone(ctx, dstore.WithCompressedReadCallback(func(_ context.Context, n int) {
+			compressedBytes.Add(uint64(n))
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reader, err := store.OpenObject(ctx, fmt.Sprintf("%010d", baseNum))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	blockReader, err := bstream.NewDBinBlockReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create block reader: %w", err)
+	}
+
+	bundle := &cachedBundle{
+		baseNum: baseNum,
+		blocks:  make(map[uint64]*cachedBlock, mergedBundleSize),
+	}
+
+	for {
+		blk, err := blockReader.Read()
+		if blk != nil {
+			bundle.blocks[blk.Number] = &cachedBlock{block: blk}
+			bundle.sizeBytes += uint64(proto.Size(blk))
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("reading merged bundle %d: %w", baseNum, err)
+		}
+	}
+
+	bundle.compressedBytes = compressedBytes.Load()
+	if bundle.sizeBytes > 0 {
+		for _, block := range bundle.blocks {
+			block.compressedBytes = bundle.compressedBytes * uint64(proto.Size(block.block)) / bundle.sizeBytes
+		}
+	}
+
+	return bundle, nil
+}
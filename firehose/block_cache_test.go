@@ -0,0 +1,132 @@
+package firehose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/metering"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func TestBlockGetter_GetThroughCache(t *testing.T) {
+	mergedBlocksStore, err := dstore.NewDBinStore("file://" + t.TempDir())
+	require.NoError(t, err)
+
+	bundle := encodeBlocks(t,
+		bstream.TestBlock("00000001a", ""),
+		bstream.TestBlock("00000002a", "00000001a"),
+		bstream.TestBlock("00000003a", "00000002a"),
+	)
+	require.NoError(t, mergedBlocksStore.WriteObject(context.Background(), "0000000000", bytes.NewReader(bundle)))
+	require.NoError(t, mergedBlocksStore.WriteObject(context.Background(), "0000000100", bytes.NewReader(encodeBlocks(t,
+		bstream.TestBlockWithNumbers("00000101a", "00000003a", 101, 3),
+	))))
+
+	getter := NewBlockGetter(mergedBlocksStore, nil, nil, nil, WithBlockCache(10*1024*1024))
+
+	get := func(num uint64, id string) (blockID string, compressedReadBytes uint64) {
+		t.Helper()
+
+		ctx := dmetering.WithBytesMeter(context.Background())
+		blk, err := getter.Get(ctx, num, id, zap.NewNop())
+		require.NoError(t, err)
+
+		return blk.Id, uint64(dmetering.GetBytesMeter(ctx).GetCount(metering.MeterFileCompressedReadBytes))
+	}
+
+	// Miss loading the bundle, then hits
+	id2, read2 := get(2, "")
+	id3, read3 := get(3, "00000003a")
+	id2Again, read2Again := get(2, "00000002a")
+
+	assert.Equal(t, "00000002a", id2)
+	assert.Equal(t, "00000003a", id3)
+	assert.Equal(t, "00000002a", id2Again)
+
+	// Cached reads are metered the same way as the read that loaded the bundle
+	assert.NotZero(t, read2)
+	assert.Equal(t, read2, read2Again)
+	assert.NotZero(t, read3)
+
+	// Wrong ID is not found
+	_, err = getter.Get(context.Background(), 3, "00000003b", zap.NewNop())
+	require.Error(t, err)
+
+	// Sum of the blocks share is at most the bundle's compressed size
+	bundleAttrs, err := mergedBlocksStore.ObjectAttributes(context.Background(), "0000000000")
+	require.NoError(t, err)
+	id1, read1 := get(1, "")
+	assert.Equal(t, "00000001a", id1)
+	assert.LessOrEqual(t, read1+read2+read3, uint64(bundleAttrs.Size))
+
+	id101, _ := get(101, "")
+	assert.Equal(t, "00000101a", id101)
+}
+
+func TestBlockCache_SingleflightAndEviction(t *testing.T) {
+	store := &countingOpenStore{MockStore: dstore.NewMockStore(nil)}
+	for base := uint64(0); base < 300; base += 100 {
+		store.SetFile(fmt.Sprintf("%010d", base), encodeBlocks(t, bstream.TestBlockWithNumbers(fmt.Sprintf("%08da", base+1), "", base+1, base)))
+	}
+
+	bundleSize := func(base uint64) uint64 {
+		bundle, err := loadBundle(context.Background(), base, store.MockStore)
+		require.NoError(t, err)
+		return bundle.sizeBytes
+	}
+
+	// Room for exactly two bundles
+	cache := newBlockCache(bundleSize(0) + bundleSize(100))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			cached, err := cache.Get(context.Background(), 1, store)
+			require.NoError(t, err)
+			require.Equal(t, "00000001a", cached.block.Id)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), store.opened.Load())
+
+	_, err := cache.Get(context.Background(), 101, store)
+	require.NoError(t, err)
+	_, err = cache.Get(context.Background(), 201, store)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), store.opened.Load())
+
+	// Bundle 0 was the least recently used, it was evicted
+	_, err = cache.Get(context.Background(), 201, store)
+	require.NoError(t, err)
+	_, err = cache.Get(context.Background(), 1, store)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), store.opened.Load())
+
+	// Missing block within an existing bundle
+	cached, err := cache.Get(context.Background(), 2, store)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+}
+
+type countingOpenStore struct {
+	*dstore.MockStore
+	opened atomic.Int64
+}
+
+func (s *countingOpenStore) OpenObject(ctx context.Context, name string) (out io.ReadCloser, err error) {
+	s.opened.Inc()
+	return s.MockStore.OpenObject(ctx, name)
+}
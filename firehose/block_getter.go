@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/streamingfast/bstream"
 	"github.com/streamingfast/bstream/hub"
@@ -11,28 +12,67 @@ import (
 	"github.com/streamingfast/derr"
 	"github.com/streamingfast/dmetering"
 	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/firehose/blockhash"
 	"github.com/streamingfast/firehose-core/metering"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// forkedBlocksSearchMaxFiles caps the number of forked block files listed when searching a block
+	// by hash alone, the search gives up past it
+	forkedBlocksSearchMaxFiles = 20_000
+
+	// forkedBlocksSearchWindow is how far below the head forked blocks are searched by hash alone when
+	// the head is known, older forked blocks are deleted by the merger by default
+	// (`--merger-prune-forked-blocks-after`)
+	forkedBlocksSearchWindow = 50_000
+)
+
+var errForkedBlocksSearchLimit = errors.New("forked blocks search limit reached")
+
 type BlockGetter struct {
 	mergedBlocksStore dstore.Store
 	forkedBlocksStore dstore.Store
 	hub               *hub.ForkableHub
+	blockHashIndex    *blockhash.Index
+	cache             *blockCache
+}
+
+type BlockGetterOption func(*BlockGetter)
+
+// WithBlockCache keeps up to [maxSizeBytes] of recently decoded merged bundles in memory so that
+// lookups of blocks close to each other only download and decode a bundle once.
+func WithBlockCache(maxSizeBytes uint64) BlockGetterOption {
+	return func(g *BlockGetter) {
+		if maxSizeBytes > 0 {
+			g.cache = newBlockCache(maxSizeBytes)
+		}
+	}
 }
 
+// NewBlockGetter creates a BlockGetter, [blockHashIndex] is optional and required only to
+// find merged blocks by hash alone.
 func NewBlockGetter(
 	mergedBlocksStore dstore.Store,
 	forkedBlocksStore dstore.Store,
 	hub *hub.ForkableHub,
+	blockHashIndex *blockhash.Index,
+	opts ...BlockGetterOption,
 ) *BlockGetter {
-	return &BlockGetter{
+	g := &BlockGetter{
 		mergedBlocksStore: mergedBlocksStore,
 		forkedBlocksStore: forkedBlocksStore,
 		hub:               hub,
+		blockHashIndex:    blockHashIndex,
+	}
+
+	for _, opt := range opts {
+		opt(g)
 	}
+
+	return g
 }
 
 func (g *BlockGetter) Get(
@@ -57,6 +97,10 @@ func (g *BlockGetter) Get(
 		return nil, status.Error(codes.NotFound, "live block not found in hub")
 	}
 
+	if g.cache != nil {
+		return g.getThroughCache(ctx, num, id, reqLogger, logger)
+	}
+
 	mergedBlocksStore := g.mergedBlocksStore
 	if clonable, ok := mergedBlocksStore.(dstore.Clonable); ok {
 		var err error
@@ -89,18 +133,47 @@ func (g *BlockGetter) Get(
 		return out, nil
 	}
 
-	// check for block in forkedBlocksStore
-	if g.forkedBlocksStore != nil {
-		forkedBlocksStore := g.forkedBlocksStore
-		if clonable, ok := forkedBlocksStore.(dstore.Clonable); ok {
-			var err error
-			forkedBlocksStore, err = clonable.Clone(ctx, metering.WithForkedBlockBytesReadMeteringOptions(dmetering.GetBytesMeter(ctx), logger)...)
-			if err != nil {
-				return nil, err
-			}
+	return g.getFromForkedBlocks(ctx, num, id, reqLogger, logger, err)
+}
+
+// getThroughCache looks for the block in the merged bundles cache, loading the bundle from the merged
+// blocks store on a miss. The block is metered with its share of the bundle's compressed bytes whether
+// it was cached or not.
+func (g *BlockGetter) getThroughCache(ctx context.Context, num uint64, id string, reqLogger, logger *zap.Logger) (*pbbstream.Block, error) {
+	var cached *cachedBlock
+	err := derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		var err error
+		cached, err = g.cache.Get(ctx, num, g.mergedBlocksStore)
+		if err != nil && errors.Is(err, dstore.ErrNotFound) {
+			return derr.NewFatalError(err)
+		}
+		return err
+	})
+
+	if err == nil && cached != nil {
+		if id == "" || cached.block.Id == id {
+			meter := dmetering.GetBytesMeter(ctx)
+			meter.CountInc(metering.MeterFileCompressedReadBytes, int(cached.compressedBytes))
 
 			//todo: (deprecated) remove this
-			forkedBlocksStore.SetMeter(dmetering.GetBytesMeter(ctx))
+			meter.AddBytesRead(int(cached.compressedBytes))
+
+			reqLogger.Info("single block request", zap.String("source", "merged_blocks"), zap.Bool("found", true))
+			return cached.block, nil
+		}
+
+		err = fmt.Errorf("wrong block: found %s, expecting %s", cached.block.Id, id)
+	}
+
+	return g.getFromForkedBlocks(ctx, num, id, reqLogger, logger, err)
+}
+
+func (g *BlockGetter) getFromForkedBlocks(ctx context.Context, num uint64, id string, reqLogger, logger *zap.Logger, mergedErr error) (*pbbstream.Block, error) {
+	// check for block in forkedBlocksStore
+	if g.forkedBlocksStore != nil {
+		forkedBlocksStore, err := g.meteredForkedBlocksStore(ctx, logger)
+		if err != nil {
+			return nil, err
 		}
 
 		if blk, _ := bstream.FetchBlockFromOneBlockStore(ctx, num, id, forkedBlocksStore); blk != nil {
@@ -109,6 +182,137 @@ func (g *BlockGetter) Get(
 		}
 	}
 
-	reqLogger.Info("single block request", zap.Bool("found", false), zap.Error(err))
+	reqLogger.Info("single block request", zap.Bool("found", false), zap.Error(mergedErr))
 	return nil, status.Error(codes.NotFound, "block not found in files")
 }
+
+// GetByHash finds a block from its hash alone, looking in the hub first, then in the merged
+// blocks through the block hash index and finally in the forked blocks store.
+func (g *BlockGetter) GetByHash(
+	ctx context.Context,
+	id string,
+	logger *zap.Logger) (out *pbbstream.Block, err error) {
+
+	id = bstream.NormalizeBlockID(id)
+	reqLogger := logger.With(zap.String("id", id))
+
+	// check for block in live segment: Hub
+	if g.hub != nil && g.hub.IsReady() {
+		if blk := g.hub.GetBlockByHash(id); blk != nil {
+			reqLogger.Info("single block by hash request", zap.String("source", "hub"), zap.Bool("found", true))
+			return blk, nil
+		}
+	}
+
+	// check for block in mergedBlocksStore, through the block hash index
+	if g.blockHashIndex != nil {
+		if num, found := g.blockHashIndex.Lookup(id); found {
+			return g.Get(ctx, num, id, logger)
+		}
+	}
+
+	// check for block in forkedBlocksStore
+	if g.forkedBlocksStore != nil {
+		forkedBlocksStore, err := g.meteredForkedBlocksStore(ctx, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		var startBlockNum uint64
+		if g.hub != nil && g.hub.IsReady() && g.hub.HeadNum() > forkedBlocksSearchWindow {
+			startBlockNum = g.hub.HeadNum() - forkedBlocksSearchWindow
+		}
+
+		blk, err := fetchBlockByHashFromOneBlockStore(ctx, id, forkedBlocksStore, startBlockNum, forkedBlocksSearchMaxFiles)
+		if err != nil {
+			if errors.Is(err, errForkedBlocksSearchLimit) {
+				reqLogger.Warn("single block by hash request gave up searching forked blocks", zap.Uint64("start_block_num", startBlockNum), zap.Int("max_files", forkedBlocksSearchMaxFiles))
+				return nil, status.Error(codes.NotFound, "block not found by hash, too many forked blocks to search")
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, status.FromContextError(ctxErr).Err()
+			}
+			return nil, err
+		}
+
+		if blk != nil {
+			reqLogger.Info("single block by hash request", zap.String("source", "forked_blocks"), zap.Bool("found", true))
+			return blk, nil
+		}
+	}
+
+	reqLogger.Info("single block by hash request", zap.Bool("found", false))
+	return nil, status.Error(codes.NotFound, "block not found by hash")
+}
+
+func (g *BlockGetter) meteredForkedBlocksStore(ctx context.Context, logger *zap.Logger) (dstore.Store, error) {
+	forkedBlocksStore := g.forkedBlocksStore
+	if clonable, ok := forkedBlocksStore.(dstore.Clonable); ok {
+		var err error
+		forkedBlocksStore, err = clonable.Clone(ctx, metering.WithForkedBlockBytesReadMeteringOptions(dmetering.GetBytesMeter(ctx), logger)...)
+		if err != nil {
+			return nil, err
+		}
+
+		//todo: (deprecated) remove this
+		forkedBlocksStore.SetMeter(dmetering.GetBytesMeter(ctx))
+	}
+
+	return forkedBlocksStore, nil
+}
+
+// fetchBlockByHashFromOneBlockStore walks the one block files of [store], from [startBlockNum],
+// looking for the block with the given [id]. One block filenames only hold a truncated block ID, so
+// candidates are decoded to compare the full block ID. Returns `nil, nil` if the block is not found
+// and [errForkedBlocksSearchLimit] once more than [maxFiles] files were listed. The walk stops as
+// soon as [ctx] is done.
+func fetchBlockByHashFromOneBlockStore(ctx context.Context, id string, store dstore.Store, startBlockNum uint64, maxFiles int) (out *pbbstream.Block, err error) {
+	var startingPoint string
+	if startBlockNum > 0 {
+		startingPoint = fmt.Sprintf("%010d", startBlockNum)
+	}
+
+	visited := 0
+	err = store.WalkFrom(ctx, "", startingPoint, func(filename string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		visited++
+		if visited > maxFiles {
+			return errForkedBlocksSearchLimit
+		}
+
+		obf, err := bstream.NewOneBlockFile(filename)
+		if err != nil {
+			// Not a one block file, ignore it
+			return nil
+		}
+
+		if !strings.HasSuffix(id, bstream.NormalizeBlockID(obf.ID)) {
+			return nil
+		}
+
+		data, err := obf.Data(ctx, bstream.OneBlockDownloaderFromStore(store))
+		if err != nil {
+			return fmt.Errorf("download one block file %q: %w", filename, err)
+		}
+
+		blk, err := bstream.DecodeOneblockfileData(data)
+		if err != nil {
+			return fmt.Errorf("decode one block file %q: %w", filename, err)
+		}
+
+		if bstream.NormalizeBlockID(blk.Id) == id {
+			out = blk
+			return dstore.StopIteration
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package firehose
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/firehose/blockhash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBlockGetter_GetByHash(t *testing.T) {
+	ctx := context.Background()
+
+	mergedBlocksStore := dstore.NewMockStore(nil)
+	mergedBlocksStore.SetFile("0000000000", encodeBlocks(t,
+		bstream.TestBlock("00000001a", ""),
+		bstream.TestBlock("00000002a", "00000001a"),
+		bstream.TestBlock("00000003a", "00000002a"),
+	))
+
+	forkedBlocksStore := dstore.NewMockStore(nil)
+	forkedBlocksStore.SetFile("0000000002-00000002b-00000001a-1-suffix", encodeBlocks(t, bstream.TestBlock("00000002b", "00000001a")))
+
+	indexStore := dstore.NewMockStore(nil)
+	require.NoError(t, blockhash.WriteIndex(ctx, indexStore, 0, 100, []bstream.BlockRef{
+		bstream.NewBlockRef("00000001a", 1),
+		bstream.NewBlockRef("00000002a", 2),
+		bstream.NewBlockRef("00000003a", 3),
+	}))
+
+	index := blockhash.NewIndex(indexStore, zap.NewNop())
+	require.NoError(t, index.Refresh(ctx))
+
+	getter := NewBlockGetter(mergedBlocksStore, forkedBlocksStore, nil, index)
+
+	blk, err := getter.GetByHash(ctx, "00000002a", zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, "00000002a", blk.Id)
+	assert.Equal(t, uint64(2), blk.Number)
+
+	blk, err = getter.GetByHash(ctx, "00000002b", zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, "00000002b", blk.Id)
+	assert.Equal(t, uint64(2), blk.Number)
+
+	_, err = getter.GetByHash(ctx, "00000004a", zap.NewNop())
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestFetchBlockByHashFromOneBlockStore_Bounded(t *testing.T) {
+	store := dstore.NewMockStore(nil)
+	store.SetFile("0000000002-00000002b-00000001a-1-suffix", encodeBlocks(t, bstream.TestBlock("00000002b", "00000001a")))
+	store.SetFile("0000000003-00000003b-00000002b-1-suffix", encodeBlocks(t, bstream.TestBlock("00000003b", "00000002b")))
+	store.SetFile("0000000004-00000004b-00000003b-1-suffix", encodeBlocks(t, bstream.TestBlock("00000004b", "00000003b")))
+
+	blk, err := fetchBlockByHashFromOneBlockStore(context.Background(), "00000004b", store, 0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "00000004b", blk.Id)
+
+	_, err = fetchBlockByHashFromOneBlockStore(context.Background(), "00000004b", store, 0, 2)
+	assert.ErrorIs(t, err, errForkedBlocksSearchLimit)
+
+	// The files before the start block are not listed
+	blk, err = fetchBlockByHashFromOneBlockStore(context.Background(), "00000004b", store, 4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "00000004b", blk.Id)
+
+	blk, err = fetchBlockByHashFromOneBlockStore(context.Background(), "00000002b", store, 3, 3)
+	require.NoError(t, err)
+	assert.Nil(t, blk)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = fetchBlockByHashFromOneBlockStore(ctx, "00000004b", store, 0, 3)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func encodeBlocks(t *testing.T, blocks ...*pbbstream.Block) []byte {
+	t.Helper()
+
+	buffer := bytes.NewBuffer(nil)
+	writer, err := bstream.NewDBinBlockWriter(buffer)
+	require.NoError(t, err)
+
+	for _, blk := range blocks {
+		require.NoError(t, writer.Write(blk))
+	}
+
+	return buffer.Bytes()
+}
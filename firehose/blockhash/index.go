@@ -0,0 +1,210 @@
+// Package blockhash maintains a block hash to block number index over the merged blocks.
+//
+// The merger writes one index file per merged bundle in the index store (`common-index-store-url`)
+// named `<base_block_num>.<bundle_size>.blockhash.idx`, using the same `sf.bstream.v1.GenericBlockIndex`
+// format as other block indexes: each key is a normalized block ID and its bitmap holds the single
+// block number of that block.
+//
+// The Firehose loads those files in memory through an [Index] so that blocks can be fetched by hash
+// alone. The in-memory index keeps one entry per merged block, count roughly 100 bytes per block
+// (hash string, number and map overhead), so ~2 GiB for a 20M blocks chain.
+package blockhash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RoaringBitmap/roaring/roaring64"
+	"github.com/streamingfast/bstream"
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const ShortName = "blockhash"
+
+const indexFileSuffix = "." + ShortName + ".idx"
+
+// IndexFilename returns the name of the block hash index file of the bundle starting at [baseBlockNum].
+func IndexFilename(baseBlockNum, bundleSize uint64) string {
+	return fmt.Sprintf("%010d.%d%s", baseBlockNum, bundleSize, indexFileSuffix)
+}
+
+// WriteIndex writes the block hash index file of the bundle starting at [baseBlockNum] containing [blocks].
+func WriteIndex(ctx context.Context, store dstore.Store, baseBlockNum, bundleSize uint64, blocks []bstream.BlockRef) error {
+	index := &pbbstream.GenericBlockIndex{}
+	for _, block := range blocks {
+		bitmap, err := roaring64.BitmapOf(block.Num()).ToBytes()
+		if err != nil {
+			return fmt.Errorf("marshal bitmap of block %s: %w", block, err)
+		}
+
+		index.Kv = append(index.Kv, &pbbstream.KeyToBitmap{
+			Key:    []byte(bstream.NormalizeBlockID(block.ID())),
+			Bitmap: bitmap,
+		})
+	}
+
+	data, err := proto.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("marshal index: %w", err)
+	}
+
+	return store.WriteObject(ctx, IndexFilename(baseBlockNum, bundleSize), bytes.NewReader(data))
+}
+
+// ReadIndex reads a block hash index file content, calling [onBlock] for each block it contains.
+func ReadIndex(r io.Reader, onBlock func(id string, num uint64)) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read index: %w", err)
+	}
+
+	index := &pbbstream.GenericBlockIndex{}
+	if err := proto.Unmarshal(data, index); err != nil {
+		return fmt.Errorf("unmarshal index: %w", err)
+	}
+
+	for _, kv := range index.Kv {
+		bitmap := roaring64.NewBitmap()
+		if err := bitmap.UnmarshalBinary(kv.Bitmap); err != nil {
+			return fmt.Errorf("unmarshal bitmap of key %q: %w", string(kv.Key), err)
+		}
+
+		if bitmap.IsEmpty() {
+			continue
+		}
+
+		onBlock(string(kv.Key), bitmap.Minimum())
+	}
+
+	return nil
+}
+
+// Index is an in-memory block hash to block number lookup table loaded from the block
+// hash index files found in a store.
+type Index struct {
+	store  dstore.Store
+	logger *zap.Logger
+
+	mu sync.RWMutex
+	// nextBaseBlock is the base block of the next bundle to load, index files are written in order
+	// by the merger so the store is walked from there on each refresh.
+	nextBaseBlock uint64
+	blocks        map[string]uint64
+}
+
+func NewIndex(store dstore.Store, logger *zap.Logger) *Index {
+	return &Index{
+		store:  store,
+		logger: logger,
+		blocks: make(map[string]uint64),
+	}
+}
+
+// Lookup returns the block number of the block with the given [id], if it's known.
+func (i *Index) Lookup(id string) (num uint64, found bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	num, found = i.blocks[bstream.NormalizeBlockID(id)]
+	return
+}
+
+// Len returns the number of blocks currently indexed.
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return len(i.blocks)
+}
+
+// Refresh loads the index files written since the last refresh.
+func (i *Index) Refresh(ctx context.Context) error {
+	i.mu.RLock()
+	startingPoint := fmt.Sprintf("%010d", i.nextBaseBlock)
+	i.mu.RUnlock()
+
+	loaded := 0
+	err := i.store.WalkFrom(ctx, "", startingPoint, func(filename string) error {
+		if !strings.HasSuffix(filename, indexFileSuffix) {
+			return nil
+		}
+
+		baseBlockNum, bundleSize, err := parseIndexFilename(filename)
+		if err != nil {
+			i.logger.Warn("skipping invalid block hash index filename", zap.String("filename", filename), zap.Error(err))
+			return nil
+		}
+
+		reader, err := i.store.OpenObject(ctx, filename)
+		if err != nil {
+			return fmt.Errorf("open index %q: %w", filename, err)
+		}
+		defer reader.Close()
+
+		blocks := make(map[string]uint64, bundleSize)
+		if err := ReadIndex(reader, func(id string, num uint64) { blocks[id] = num }); err != nil {
+			return fmt.Errorf("index %q: %w", filename, err)
+		}
+
+		i.mu.Lock()
+		for id, num := range blocks {
+			i.blocks[id] = num
+		}
+		if next := baseBlockNum + bundleSize; next > i.nextBaseBlock {
+			i.nextBaseBlock = next
+		}
+		i.mu.Unlock()
+
+		loaded++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if loaded > 0 {
+		i.logger.Debug("loaded block hash index files", zap.Int("files", loaded), zap.Int("indexed_blocks", i.Len()))
+	}
+	return nil
+}
+
+// Run refreshes the index every [interval] until [ctx] is done.
+func (i *Index) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := i.Refresh(ctx); err != nil && ctx.Err() == nil {
+			i.logger.Warn("unable to refresh block hash index", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func parseIndexFilename(filename string) (baseBlockNum, bundleSize uint64, err error) {
+	parts := strings.Split(strings.TrimSuffix(filename, indexFileSuffix), ".")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected <base_block_num>.<bundle_size>%s", indexFileSuffix)
+	}
+
+	if baseBlockNum, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid base block num: %w", err)
+	}
+
+	if bundleSize, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid bundle size: %w", err)
+	}
+
+	return baseBlockNum, bundleSize, nil
+}
@@ -0,0 +1,47 @@
+package blockhash
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestIndex_Refresh(t *testing.T) {
+	ctx := context.Background()
+	store := dstore.NewMockStore(nil)
+	store.SetFile("0000000100.100.default.idx", []byte{})
+
+	require.NoError(t, WriteIndex(ctx, store, 0, 100, []bstream.BlockRef{
+		bstream.NewBlockRef("00000001a", 1),
+		bstream.NewBlockRef("00000002a", 2),
+	}))
+
+	index := NewIndex(store, zap.NewNop())
+	require.NoError(t, index.Refresh(ctx))
+
+	num, found := index.Lookup("00000001a")
+	assert.True(t, found)
+	assert.Equal(t, uint64(1), num)
+
+	num, found = index.Lookup("00000002a")
+	assert.True(t, found)
+	assert.Equal(t, uint64(2), num)
+
+	_, found = index.Lookup("00000101a")
+	assert.False(t, found)
+
+	require.NoError(t, WriteIndex(ctx, store, 100, 100, []bstream.BlockRef{
+		bstream.NewBlockRef("00000101a", 101),
+	}))
+	require.NoError(t, index.Refresh(ctx))
+
+	num, found = index.Lookup("00000101a")
+	assert.True(t, found)
+	assert.Equal(t, uint64(101), num)
+	assert.Equal(t, 3, index.Len())
+}
@@ -0,0 +1,245 @@
+package fieldmask
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
+	"github.com/streamingfast/bstream/transform"
+	fcproto "github.com/streamingfast/firehose-core/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// MessageName is the fully qualified name of the transform message that activates
+// field masking, clients send a `google.protobuf.FieldMask` as one of the request's
+// transforms.
+const MessageName = protoreflect.FullName("google.protobuf.FieldMask")
+
+// NewFactory returns a chain agnostic [transform.Factory] that prunes the block payload
+// down to the paths found in the received `google.protobuf.FieldMask`. Paths are expressed
+// relative to the chain's block type, e.g. `header.number` or `transactions.hash`.
+//
+// The [registry] is used to resolve the payload's type when the block has not already been
+// decoded by a previous transform. If [blockDescriptor] is non-nil, paths are validated
+// against it when the request is received so that invalid paths are reported immediately
+// instead of on the first block.
+func NewFactory(registry *fcproto.Registry, blockDescriptor protoreflect.MessageDescriptor) *transform.Factory {
+	return &transform.Factory{
+		Obj: &fieldmaskpb.FieldMask{},
+		NewFunc: func(message *anypb.Any) (transform.Transform, error) {
+			mask := &fieldmaskpb.FieldMask{}
+			if err := message.UnmarshalTo(mask); err != nil {
+				return nil, fmt.Errorf("unexpected unmarshal error: %w", err)
+			}
+
+			if len(mask.Paths) == 0 {
+				return nil, fmt.Errorf("field mask must contain at least one path")
+			}
+
+			tree := newMaskTree(mask.Paths)
+			if blockDescriptor != nil {
+				if err := tree.validate(blockDescriptor, ""); err != nil {
+					return nil, fmt.Errorf("invalid field mask for %s: %w", blockDescriptor.FullName(), err)
+				}
+			}
+
+			return &Transform{
+				registry: registry,
+				paths:    mask.Paths,
+				tree:     tree,
+			}, nil
+		},
+	}
+}
+
+type Transform struct {
+	registry *fcproto.Registry
+	paths    []string
+	tree     maskTree
+}
+
+var _ transform.PreprocessTransform = (*Transform)(nil)
+
+func (t *Transform) String() string {
+	return fmt.Sprintf("field mask transform (paths: %s)", strings.Join(t.paths, ","))
+}
+
+// Transform prunes the incoming object. When no transform ran before this one, the raw
+// [pbbstream.Block] payload is decoded through the registry and the pruned message is
+// returned as an [anypb.Any] of the same type URL. The read-only block is never modified.
+func (t *Transform) Transform(readOnlyBlk *pbbstream.Block, in transform.Input) (transform.Output, error) {
+	switch obj := in.Obj().(type) {
+	case nil:
+		return t.pruneAny(readOnlyBlk.Payload)
+	case *anypb.Any:
+		return t.pruneAny(obj)
+	default:
+		out := proto.Clone(obj)
+		if err := t.tree.prune(out.ProtoReflect()); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+}
+
+func (t *Transform) pruneAny(payload *anypb.Any) (*anypb.Any, error) {
+	if payload == nil {
+		return nil, fmt.Errorf("block has no payload")
+	}
+
+	if t.registry == nil {
+		return nil, fmt.Errorf("no proto registry configured, cannot decode %q", payload.TypeUrl)
+	}
+
+	message, err := t.registry.Unmarshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	if err := t.tree.prune(message); err != nil {
+		return nil, err
+	}
+
+	value, err := proto.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("marshal pruned payload: %w", err)
+	}
+
+	return &anypb.Any{TypeUrl: payload.TypeUrl, Value: value}, nil
+}
+
+// maskTree is the hierarchical view of the field mask paths. A node without children
+// means the whole field (and everything under it) is kept.
+type maskTree map[string]maskTree
+
+func newMaskTree(paths []string) maskTree {
+	root := maskTree{}
+	for _, path := range paths {
+		parts := strings.Split(strings.TrimSpace(path), ".")
+
+		node := root
+		for i, part := range parts {
+			child, found := node[part]
+			if found && len(child) == 0 {
+				// A parent path is already fully selected, deeper paths add nothing
+				break
+			}
+
+			if i == len(parts)-1 {
+				// Last element of the path selects the whole sub-tree
+				node[part] = maskTree{}
+				break
+			}
+
+			if !found {
+				child = maskTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+
+	return root
+}
+
+func (t maskTree) validate(descriptor protoreflect.MessageDescriptor, prefix string) error {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		field := descriptor.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return fmt.Errorf("field %q does not exist", path)
+		}
+
+		children := t[name]
+		if len(children) == 0 {
+			continue
+		}
+
+		target := messageDescriptorOf(field)
+		if target == nil {
+			return fmt.Errorf("field %q is not a message, cannot select sub-fields", path)
+		}
+
+		if err := children.validate(target, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t maskTree) prune(message protoreflect.Message) error {
+	var err error
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		children, keep := t[string(field.Name())]
+		if !keep {
+			message.Clear(field)
+			return true
+		}
+
+		if len(children) == 0 {
+			return true
+		}
+
+		switch {
+		case field.IsList():
+			if field.Kind() != protoreflect.MessageKind && field.Kind() != protoreflect.GroupKind {
+				err = fmt.Errorf("field %q is not a message, cannot select sub-fields", field.FullName())
+				return false
+			}
+
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				if err = children.prune(list.Get(i).Message()); err != nil {
+					return false
+				}
+			}
+		case field.IsMap():
+			if field.MapValue().Message() == nil {
+				err = fmt.Errorf("field %q is not a message map, cannot select sub-fields", field.FullName())
+				return false
+			}
+
+			value.Map().Range(func(_ protoreflect.MapKey, entry protoreflect.Value) bool {
+				err = children.prune(entry.Message())
+				return err == nil
+			})
+			if err != nil {
+				return false
+			}
+		case field.Message() != nil:
+			if err = children.prune(value.Message()); err != nil {
+				return false
+			}
+		default:
+			err = fmt.Errorf("field %q is not a message, cannot select sub-fields", field.FullName())
+			return false
+		}
+
+		return true
+	})
+
+	return err
+}
+
+func messageDescriptorOf(field protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	if field.IsMap() {
+		return field.MapValue().Message()
+	}
+
+	return field.Message()
+}
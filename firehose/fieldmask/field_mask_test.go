@@ -0,0 +1,148 @@
+package fieldmask
+
+import (
+	"testing"
+
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
+	"github.com/streamingfast/bstream/transform"
+	fcproto "github.com/streamingfast/firehose-core/proto"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    []string
+		expected *pbfirehose.Response
+	}{
+		{
+			"single top level field",
+			[]string{"cursor"},
+			&pbfirehose.Response{Cursor: "cursor"},
+		},
+		{
+			"nested field",
+			[]string{"metadata.num", "step"},
+			&pbfirehose.Response{Step: pbfirehose.ForkStep_STEP_NEW, Metadata: &pbfirehose.BlockMetadata{Num: 10}},
+		},
+		{
+			"parent path wins over child path",
+			[]string{"metadata.num", "metadata"},
+			&pbfirehose.Response{Metadata: testResponse().Metadata},
+		},
+		{
+			"child path after parent path is ignored",
+			[]string{"metadata", "metadata.num"},
+			&pbfirehose.Response{Metadata: testResponse().Metadata},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trx := newTestTransform(t, tt.paths)
+
+			out, err := trx.Transform(nil, &testInput{obj: testResponse()})
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.expected, out), "got %s", out)
+		})
+	}
+}
+
+func TestTransform_RawPayload(t *testing.T) {
+	registry, err := fcproto.NewRegistry(pbfirehose.File_sf_firehose_v2_firehose_proto)
+	require.NoError(t, err)
+
+	payload, err := anypb.New(testResponse())
+	require.NoError(t, err)
+
+	block := &pbbstream.Block{Number: 10, Payload: payload}
+	originalPayload := proto.Clone(payload)
+
+	trx := newTestTransform(t, []string{"metadata.id"})
+	trx.registry = registry
+
+	out, err := trx.Transform(block, transform.NewNilObj())
+	require.NoError(t, err)
+
+	outAny, ok := out.(*anypb.Any)
+	require.True(t, ok)
+	assert.Equal(t, payload.TypeUrl, outAny.TypeUrl)
+	assert.Less(t, len(outAny.Value), len(payload.Value))
+
+	actual := &pbfirehose.Response{}
+	require.NoError(t, outAny.UnmarshalTo(actual))
+	assert.True(t, proto.Equal(&pbfirehose.Response{Metadata: &pbfirehose.BlockMetadata{Id: "0a"}}, actual), "got %s", actual)
+
+	assert.True(t, proto.Equal(originalPayload, block.Payload), "read-only block payload must not be modified")
+}
+
+func TestNewFactory_Validation(t *testing.T) {
+	descriptor := (&pbfirehose.Response{}).ProtoReflect().Descriptor()
+	factory := NewFactory(nil, descriptor)
+
+	tests := []struct {
+		name        string
+		paths       []string
+		expectedErr string
+	}{
+		{"valid", []string{"metadata.num", "cursor"}, ""},
+		{"empty", nil, "field mask must contain at least one path"},
+		{"unknown field", []string{"metadata.unknown"}, `invalid field mask for sf.firehose.v2.Response: field "metadata.unknown" does not exist`},
+		{"sub-field of scalar", []string{"cursor.value"}, `invalid field mask for sf.firehose.v2.Response: field "cursor" is not a message, cannot select sub-fields`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := anypb.New(&fieldmaskpb.FieldMask{Paths: tt.paths})
+			require.NoError(t, err)
+
+			_, err = factory.NewFunc(message)
+			if tt.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func newTestTransform(t *testing.T, paths []string) *Transform {
+	t.Helper()
+
+	message, err := anypb.New(&fieldmaskpb.FieldMask{Paths: paths})
+	require.NoError(t, err)
+
+	trx, err := NewFactory(nil, nil).NewFunc(message)
+	require.NoError(t, err)
+
+	return trx.(*Transform)
+}
+
+func testResponse() *pbfirehose.Response {
+	return &pbfirehose.Response{
+		Block:  &anypb.Any{TypeUrl: "type.googleapis.com/sf.acme.type.v1.Block", Value: []byte{0x01, 0x02}},
+		Step:   pbfirehose.ForkStep_STEP_NEW,
+		Cursor: "cursor",
+		Metadata: &pbfirehose.BlockMetadata{
+			Id:        "0a",
+			Num:       10,
+			ParentId:  "09",
+			ParentNum: 9,
+			LibNum:    8,
+			Time:      &timestamppb.Timestamp{Seconds: 1700000000},
+		},
+	}
+}
+
+type testInput struct {
+	obj proto.Message
+}
+
+func (i *testInput) Type() string       { return string(proto.MessageName(i.obj)) }
+func (i *testInput) Obj() proto.Message { return i.obj }
@@ -10,6 +10,7 @@ import (
 	"github.com/streamingfast/bstream/hub"
 	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
 	"github.com/streamingfast/dstore"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -64,6 +65,30 @@ func NewInfoServer(
 	}
 }
 
+// ForChain returns a new info server advertising [chainName] (and [chainNameAliases]) and its
+// [firstStreamableBlock] instead of this server's chain, everything else is configured as this
+// server. It must be initialized on its own.
+func (s *InfoServer) ForChain(chainName string, chainNameAliases []string, firstStreamableBlock uint64) *InfoServer {
+	s.Lock()
+	defer s.Unlock()
+
+	resp := &pbfirehose.InfoResponse{
+		ChainName:               chainName,
+		ChainNameAliases:        chainNameAliases,
+		BlockIdEncoding:         s.response.BlockIdEncoding,
+		BlockFeatures:           s.response.BlockFeatures,
+		FirstStreamableBlockNum: firstStreamableBlock,
+	}
+
+	return &InfoServer{
+		responseFiller: s.responseFiller,
+		response:       resp,
+		validate:       s.validate,
+		ready:          make(chan struct{}),
+		logger:         s.logger,
+	}
+}
+
 func validateInfoResponse(resp *pbfirehose.InfoResponse) error {
 	switch {
 	case resp.ChainName == "":
@@ -230,6 +255,46 @@ func (s *InfoServer) init(ctx context.Context, fhub *hub.ForkableHub, mergedBloc
 		return err
 	}
 
+	if err := s.verifyCheckpoints(ctx, mergedBlocksStore, logger); err != nil {
+		return fmt.Errorf("%w -- use --ignore-advertise-validation to skip these checks", err)
+	}
+
 	close(s.ready)
 	return nil
 }
+
+// verifyCheckpoints checks the blocks at the checkpoints of the advertised chain which are already in
+// [mergedBlocksStore], the following ones are checked by the reader and the merger as they pass.
+func (s *InfoServer) verifyCheckpoints(ctx context.Context, mergedBlocksStore dstore.Store, logger *zap.Logger) error {
+	chain := wellknown.WellKnownProtocols.ChainByName(s.response.ChainName)
+	if !chain.HasCheckpoints() {
+		return nil
+	}
+
+	for _, checkpoint := range chain.Checkpoints {
+		if checkpoint.Number < s.response.FirstStreamableBlockNum {
+			continue
+		}
+
+		exists, err := mergedBlocksStore.FileExists(ctx, fmt.Sprintf("%010d", checkpoint.Number/mergedBundleSize*mergedBundleSize))
+		if err != nil {
+			return fmt.Errorf("checking merged bundle of checkpoint #%d: %w", checkpoint.Number, err)
+		}
+		if !exists {
+			logger.Debug("checkpoint not merged yet, skipping", zap.Uint64("checkpoint", checkpoint.Number))
+			continue
+		}
+
+		block, err := bstream.FetchBlockFromMergedBlocksStore(ctx, checkpoint.Number, mergedBlocksStore)
+		if err != nil {
+			return fmt.Errorf("fetching checkpoint #%d of chain %q: %w", checkpoint.Number, chain.Name, err)
+		}
+
+		if err := chain.VerifyBlock(block.Number, block.Id); err != nil {
+			return err
+		}
+		logger.Info("verified chain checkpoint", zap.String("chain", chain.Name), zap.Uint64("checkpoint", checkpoint.Number))
+	}
+
+	return nil
+}
@@ -23,7 +23,7 @@ var DefaultInfoResponseFiller = func(firstStreamableBlock *pbbstream.Block, resp
 	if !validate {
 		if resp.ChainName == "" {
 			// still try to fill the chain name if it is not given
-			if chain := wellknown.WellKnownProtocols.ChainByGenesisBlock(firstStreamableBlock.Number, firstStreamableBlock.Id); chain != nil {
+			if chain := wellknown.WellKnownProtocols.ChainByKnownBlock(firstStreamableBlock.Number, firstStreamableBlock.Id); chain != nil {
 				resp.ChainName = chain.Name
 				resp.ChainNameAliases = chain.Aliases
 			}
@@ -33,16 +33,20 @@ var DefaultInfoResponseFiller = func(firstStreamableBlock *pbbstream.Block, resp
 
 	if resp.ChainName != "" {
 		if chain := wellknown.WellKnownProtocols.ChainByName(resp.ChainName); chain != nil {
-			if firstStreamableBlock.Number == chain.GenesisBlockNumber && chain.GenesisBlockID != firstStreamableBlock.Id { // we don't check if the firstStreamableBlock is something other than our well-known genesis block
+			if firstStreamableBlock.Number == chain.GenesisBlockNumber && chain.GenesisBlockID != firstStreamableBlock.Id {
 				return fmt.Errorf("chain name defined in flag: %q inconsistent with the genesis block ID %q (expected: %q)", resp.ChainName, ox(firstStreamableBlock.Id), ox(chain.GenesisBlockID))
 			}
+			// a first streamable block other than the genesis block can only be checked when it is one of the chain's checkpoints
+			if err := chain.VerifyBlock(firstStreamableBlock.Number, firstStreamableBlock.Id); err != nil {
+				return fmt.Errorf("chain name defined in flag: %q inconsistent with the first streamable block: %w", resp.ChainName, err)
+			}
 			resp.ChainName = chain.Name // ensure we use the canonical name if the user provided one of the aliases
 			resp.ChainNameAliases = chain.Aliases
-		} else if chain := wellknown.WellKnownProtocols.ChainByGenesisBlock(firstStreamableBlock.Number, firstStreamableBlock.Id); chain != nil {
-			return fmt.Errorf("chain name defined in flag: %q inconsistent with the one discovered from genesis block %q", resp.ChainName, chain.Name)
+		} else if chain := wellknown.WellKnownProtocols.ChainByKnownBlock(firstStreamableBlock.Number, firstStreamableBlock.Id); chain != nil {
+			return fmt.Errorf("chain name defined in flag: %q inconsistent with the one discovered from the first streamable block %q", resp.ChainName, chain.Name)
 		}
 	} else {
-		if chain := wellknown.WellKnownProtocols.ChainByGenesisBlock(firstStreamableBlock.Number, firstStreamableBlock.Id); chain != nil {
+		if chain := wellknown.WellKnownProtocols.ChainByKnownBlock(firstStreamableBlock.Number, firstStreamableBlock.Id); chain != nil {
 			resp.ChainName = chain.Name
 			resp.ChainNameAliases = chain.Aliases
 		}
@@ -0,0 +1,61 @@
+package info
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestDefaultInfoResponseFiller_LoadedRegistry(t *testing.T) {
+	original := wellknown.WellKnownProtocols
+	defer func() { wellknown.WellKnownProtocols = original }()
+
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("protocols:\n  - name: acme\n    chains:\n      - name: acme-devnet\n        aliases: [devnet]\n        genesis-block-id: abcdef\n        genesis-block-number: 1\n"), 0644))
+	require.NoError(t, wellknown.LoadRegistry(context.Background(), path))
+
+	genesis := &pbbstream.Block{Number: 1, Id: "abcdef", Payload: &anypb.Any{TypeUrl: "type.googleapis.com/sf.acme.type.v1.Block"}}
+
+	resp := &pbfirehose.InfoResponse{}
+	require.NoError(t, DefaultInfoResponseFiller(genesis, resp, true))
+	assert.Equal(t, "acme-devnet", resp.ChainName)
+	assert.Equal(t, []string{"devnet"}, resp.ChainNameAliases)
+	assert.Equal(t, pbfirehose.InfoResponse_BLOCK_ID_ENCODING_HEX, resp.BlockIdEncoding)
+
+	resp = &pbfirehose.InfoResponse{ChainName: "devnet"}
+	require.NoError(t, DefaultInfoResponseFiller(genesis, resp, true))
+	assert.Equal(t, "acme-devnet", resp.ChainName)
+
+	err := DefaultInfoResponseFiller(&pbbstream.Block{Number: 1, Id: "012345", Payload: genesis.Payload}, &pbfirehose.InfoResponse{ChainName: "devnet"}, true)
+	assert.ErrorContains(t, err, `chain name defined in flag: "devnet" inconsistent with the genesis block ID "0x012345" (expected: "0xabcdef")`)
+}
+
+func TestDefaultInfoResponseFiller_Checkpoints(t *testing.T) {
+	original := wellknown.WellKnownProtocols
+	defer func() { wellknown.WellKnownProtocols = original }()
+
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("protocols:\n  - name: acme\n    chains:\n      - name: acme-devnet\n        genesis-block-id: abcdef\n        checkpoints:\n          - {number: 1000, id: 001000}\n"), 0644))
+	require.NoError(t, wellknown.LoadRegistry(context.Background(), path))
+
+	payload := &anypb.Any{TypeUrl: "type.googleapis.com/sf.acme.type.v1.Block"}
+
+	// The chain is inferred from a first streamable block at one of its checkpoints
+	resp := &pbfirehose.InfoResponse{}
+	require.NoError(t, DefaultInfoResponseFiller(&pbbstream.Block{Number: 1000, Id: "001000", Payload: payload}, resp, true))
+	assert.Equal(t, "acme-devnet", resp.ChainName)
+
+	err := DefaultInfoResponseFiller(&pbbstream.Block{Number: 1000, Id: "00100f", Payload: payload}, &pbfirehose.InfoResponse{ChainName: "acme-devnet"}, true)
+	assert.ErrorIs(t, err, wellknown.ErrChainIdentityMismatch)
+
+	// First streamable blocks other than the genesis and the checkpoints cannot be checked
+	require.NoError(t, DefaultInfoResponseFiller(&pbbstream.Block{Number: 1001, Id: "00100f", Payload: payload}, &pbfirehose.InfoResponse{ChainName: "acme-devnet"}, true))
+}
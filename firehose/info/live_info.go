@@ -0,0 +1,191 @@
+package info
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/bstream/hub"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const mergedBundleSize = 100
+
+// LiveInfo is the current state of the chain as seen by the instance, unlike the [InfoServer]
+// response which is filled once.
+type LiveInfo struct {
+	// LiveCapable is true when the instance serves blocks from a real-time synced hub, the head
+	// fields are only set in this case
+	LiveCapable   bool
+	HeadBlockNum  uint64
+	HeadBlockID   string
+	HeadBlockTime time.Time
+	LIBNum        uint64
+
+	// LowestMergedBlockNum and HighestMergedBlockNum are the bounds of the blocks available in the merged
+	// blocks store, only set when HasMergedBlocks is true
+	HasMergedBlocks       bool
+	LowestMergedBlockNum  uint64
+	HighestMergedBlockNum uint64
+
+	UpdatedAt time.Time
+}
+
+// LiveInfoProvider computes the [LiveInfo] from the hub and the merged blocks store, caching it for
+// a short TTL so that it can be requested at a high rate (e.g. by load balancers health checks).
+type LiveInfoProvider struct {
+	forkableHub       *hub.ForkableHub
+	mergedBlocksStore dstore.Store
+	ttl               time.Duration
+	logger            *zap.Logger
+
+	mu     sync.Mutex
+	cached *LiveInfo
+
+	// computes shares a single computation between the concurrent callers of an expired live info
+	computes singleflight.Group
+	// highestMergedBase is the base block of the highest merged bundle found so far, next lookups
+	// only probe the bundles following it. It's only accessed by computations, which [computes]
+	// runs one at a time.
+	highestMergedBase *uint64
+}
+
+// NewLiveInfoProvider creates a provider for [forkableHub] and [mergedBlocksStore], either can be nil.
+func NewLiveInfoProvider(forkableHub *hub.ForkableHub, mergedBlocksStore dstore.Store, ttl time.Duration, logger *zap.Logger) *LiveInfoProvider {
+	return &LiveInfoProvider{
+		forkableHub:       forkableHub,
+		mergedBlocksStore: mergedBlocksStore,
+		ttl:               ttl,
+		logger:            logger,
+	}
+}
+
+// Get returns the live info, computed at most once per TTL whatever the number of callers. The
+// computation probes the merged blocks store, it's done outside of the lock which is only held to
+// read and swap the cached value.
+func (p *LiveInfoProvider) Get(ctx context.Context) *LiveInfo {
+	if cached := p.cachedLiveInfo(); cached != nil && time.Since(cached.UpdatedAt) < p.ttl {
+		return cached
+	}
+
+	out, _, _ := p.computes.Do("", func() (any, error) {
+		return p.compute(ctx), nil
+	})
+
+	return out.(*LiveInfo)
+}
+
+func (p *LiveInfoProvider) cachedLiveInfo() *LiveInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.cached
+}
+
+func (p *LiveInfoProvider) compute(ctx context.Context) *LiveInfo {
+	out := &LiveInfo{UpdatedAt: time.Now()}
+
+	if p.forkableHub != nil && p.forkableHub.IsReady() {
+		headNum, headID, headTime, libNum, err := p.forkableHub.HeadInfo()
+		if err == nil {
+			out.LiveCapable = true
+			out.HeadBlockNum = headNum
+			out.HeadBlockID = headID
+			out.HeadBlockTime = headTime
+			out.LIBNum = libNum
+		}
+	}
+
+	if p.mergedBlocksStore != nil {
+		if err := p.fillMergedBlocksRange(ctx, out); err != nil {
+			p.logger.Debug("unable to resolve merged blocks range", zap.Error(err))
+
+			// Keep the last known range rather than advertising none on a transient store error
+			if cached := p.cachedLiveInfo(); cached != nil {
+				out.HasMergedBlocks = cached.HasMergedBlocks
+				out.LowestMergedBlockNum = cached.LowestMergedBlockNum
+				out.HighestMergedBlockNum = cached.HighestMergedBlockNum
+			}
+		}
+	}
+
+	p.mu.Lock()
+	p.cached = out
+	p.mu.Unlock()
+
+	return out
+}
+
+func (p *LiveInfoProvider) fillMergedBlocksRange(ctx context.Context, out *LiveInfo) error {
+	lowestBase, found, err := p.lowestMergedBase(ctx)
+	if err != nil || !found {
+		return err
+	}
+
+	// Bundles are contiguous, gallop forward from the highest known (or lowest) bundle then bisect
+	highestBase := lowestBase
+	if p.highestMergedBase != nil && *p.highestMergedBase > lowestBase {
+		highestBase = *p.highestMergedBase
+	}
+
+	missingBase := uint64(0)
+	for step := uint64(mergedBundleSize); ; step *= 2 {
+		exists, err := p.mergedBundleExists(ctx, highestBase+step)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missingBase = highestBase + step
+			break
+		}
+		highestBase += step
+	}
+
+	for missingBase-highestBase > mergedBundleSize {
+		middle := highestBase + (missingBase-highestBase)/mergedBundleSize/2*mergedBundleSize
+		exists, err := p.mergedBundleExists(ctx, middle)
+		if err != nil {
+			return err
+		}
+		if exists {
+			highestBase = middle
+		} else {
+			missingBase = middle
+		}
+	}
+	p.highestMergedBase = &highestBase
+
+	out.HasMergedBlocks = true
+	out.LowestMergedBlockNum = lowestBase
+	out.HighestMergedBlockNum = highestBase + mergedBundleSize - 1
+	return nil
+}
+
+func (p *LiveInfoProvider) lowestMergedBase(ctx context.Context) (base uint64, found bool, err error) {
+	err = p.mergedBlocksStore.WalkFrom(ctx, "", "", func(filename string) error {
+		if _, err := fmt.Sscanf(filename, "%010d", &base); err != nil {
+			// Not a merged bundle, keep looking
+			return nil
+		}
+
+		found = true
+		return dstore.StopIteration
+	})
+	if err != nil && !errors.Is(err, dstore.StopIteration) {
+		return 0, false, fmt.Errorf("walk merged blocks store: %w", err)
+	}
+
+	return base, found, nil
+}
+
+func (p *LiveInfoProvider) mergedBundleExists(ctx context.Context, baseBlockNum uint64) (bool, error) {
+	exists, err := p.mergedBlocksStore.FileExists(ctx, fmt.Sprintf("%010d", baseBlockNum))
+	if err != nil {
+		return false, fmt.Errorf("probe merged bundle %d: %w", baseBlockNum, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,99 @@
+package info
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func TestLiveInfoProvider_MergedBlocksRange(t *testing.T) {
+	store := dstore.NewMockStore(nil)
+	for base := uint64(100); base <= 1200; base += 100 {
+		store.SetFile(fmt.Sprintf("%010d", base), nil)
+	}
+
+	probes := 0
+	store.FileExistsFunc = func(ctx context.Context, base string) (bool, error) {
+		probes++
+		_, found := store.Files[base]
+		return found, nil
+	}
+
+	provider := NewLiveInfoProvider(nil, store, time.Hour, zap.NewNop())
+
+	live := provider.Get(context.Background())
+	assert.False(t, live.LiveCapable)
+	assert.True(t, live.HasMergedBlocks)
+	assert.Equal(t, uint64(100), live.LowestMergedBlockNum)
+	assert.Equal(t, uint64(1299), live.HighestMergedBlockNum)
+
+	// Cached for the TTL, the store is not probed again
+	store.SetFile("0000001300", nil)
+	probes = 0
+	assert.Same(t, live, provider.Get(context.Background()))
+	assert.Equal(t, 0, probes)
+
+	// Once expired, only the bundles following the highest known one are probed (1300, 1500 then 1400)
+	provider.ttl = 0
+	live = provider.Get(context.Background())
+	assert.Equal(t, uint64(1399), live.HighestMergedBlockNum)
+	assert.Equal(t, 3, probes)
+
+	// A failing store keeps the last known range
+	store.FileExistsFunc = func(ctx context.Context, base string) (bool, error) {
+		return false, errors.New("unreachable")
+	}
+	live = provider.Get(context.Background())
+	assert.True(t, live.HasMergedBlocks)
+	assert.Equal(t, uint64(100), live.LowestMergedBlockNum)
+	assert.Equal(t, uint64(1399), live.HighestMergedBlockNum)
+}
+
+func TestLiveInfoProvider_EmptyStore(t *testing.T) {
+	provider := NewLiveInfoProvider(nil, dstore.NewMockStore(nil), 0, zap.NewNop())
+
+	live := provider.Get(context.Background())
+	require.NotNil(t, live)
+	assert.False(t, live.LiveCapable)
+	assert.False(t, live.HasMergedBlocks)
+}
+
+func TestLiveInfoProvider_ConcurrentCallersShareComputation(t *testing.T) {
+	store := dstore.NewMockStore(nil)
+	store.SetFile("0000000100", nil)
+
+	probes := atomic.NewInt64(0)
+	release := make(chan struct{})
+	store.FileExistsFunc = func(ctx context.Context, base string) (bool, error) {
+		probes.Inc()
+		<-release
+		_, found := store.Files[base]
+		return found, nil
+	}
+
+	provider := NewLiveInfoProvider(nil, store, time.Hour, zap.NewNop())
+
+	results := make(chan *LiveInfo, 5)
+	for i := 0; i < cap(results); i++ {
+		go func() { results <- provider.Get(context.Background()) }()
+	}
+
+	require.Eventually(t, func() bool { return probes.Load() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	first := <-results
+	for i := 1; i < cap(results); i++ {
+		assert.Same(t, first, <-results)
+	}
+	assert.Equal(t, uint64(199), first.HighestMergedBlockNum)
+	assert.Equal(t, int64(1), probes.Load())
+}
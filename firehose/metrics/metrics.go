@@ -10,5 +10,19 @@ var AppReadiness = Metricset.NewAppReadiness("firehose")
 var ActiveRequests = Metricset.NewGauge("firehose_active_requests", "Number of active requests")
 var RequestCounter = Metricset.NewCounter("firehose_requests_counter", "Request count")
 
+// Hit ratio of the single block cache is `hits / (hits + misses)`
+var BlockCacheHits = Metricset.NewCounter("firehose_block_cache_hits", "Number of single block lookups served from the merged bundles cache")
+var BlockCacheMisses = Metricset.NewCounter("firehose_block_cache_misses", "Number of single block lookups that loaded a merged bundle from the store")
+var BlockCacheBytesSaved = Metricset.NewCounter("firehose_block_cache_bytes_saved", "Compressed bytes not read from the merged blocks store thanks to the merged bundles cache")
+var BlockCacheSizeBytes = Metricset.NewGauge("firehose_block_cache_size_bytes", "Size in bytes of the decoded blocks held by the merged bundles cache")
+
+var AdmissionHistoricalStreams = Metricset.NewGauge("firehose_admission_historical_streams", "Number of admitted historical streams currently active")
+var AdmissionLiveStreams = Metricset.NewGauge("firehose_admission_live_streams", "Number of admitted live-only streams currently active")
+var AdmissionQueuedStreams = Metricset.NewGauge("firehose_admission_queued_streams", "Number of historical streams waiting to be admitted")
+var AdmissionRejectedStreams = Metricset.NewCounter("firehose_admission_rejected_streams", "Number of historical streams rejected because the server was overloaded")
+var InflightPreprocessing = Metricset.NewGauge("firehose_inflight_preprocessing", "Number of merged blocks currently being preprocessed (transforms) across all streams")
+
+var AccessLogDroppedRecords = Metricset.NewCounter("firehose_access_log_dropped_records", "Number of access log records dropped because the sink could not keep up or failed to write them")
+
 // var CurrentListeners = Metricset.NewGaugeVec("current_listeners", []string{"req_type"}, "...")
 // var TimedOutPushingTrxCount = Metricset.NewCounterVec("something", []string{"guarantee"}, "Number of requests for push_transaction timed out while submitting")
@@ -0,0 +1,138 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/streamingfast/dstore"
+	"gopkg.in/yaml.v2"
+)
+
+type fileContent struct {
+	Default *fileLimits  `yaml:"default"`
+	Users   []*fileEntry `yaml:"users"`
+}
+
+type fileLimits struct {
+	Blocks      uint64 `yaml:"blocks"`
+	EgressBytes string `yaml:"egress-bytes"`
+	Period      string `yaml:"period"`
+}
+
+type fileEntry struct {
+	UserID   string `yaml:"user-id"`
+	APIKeyID string `yaml:"api-key-id"`
+
+	fileLimits `yaml:",inline"`
+}
+
+// File holds the limits read from a quota file.
+type File struct {
+	defaults *Limits
+	entries  map[Key]Limits
+}
+
+// ReadFile reads the quota file at [path], any file URL supported by dstore, see [ParseFile].
+func ReadFile(ctx context.Context, path string) (*File, error) {
+	content, err := dstore.ReadObject(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("read quota file %q: %w", path, err)
+	}
+
+	file, err := ParseFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse quota file %q: %w", path, err)
+	}
+
+	return file, nil
+}
+
+// ParseFile parses a YAML (or JSON) quota file, made of `default` limits applying to every user
+// without an entry of its own and of `users` entries, each with a `user-id` and optionally an
+// `api-key-id`, in which case the limits apply to that API key only. Limits are `blocks`,
+// `egress-bytes` (e.g. `10GiB`) and `period` (default `24h`), unset limits are unlimited:
+//
+//	default: {blocks: 1000000, period: 24h}
+//	users:
+//	  - {user-id: alice, egress-bytes: 50GiB}
+//	  - {user-id: bob, api-key-id: ci, blocks: 1000, period: 1h}
+func ParseFile(content []byte) (*File, error) {
+	var parsed fileContent
+	if err := yaml.UnmarshalStrict(content, &parsed); err != nil {
+		return nil, err
+	}
+
+	file := &File{entries: make(map[Key]Limits)}
+	if parsed.Default != nil {
+		limits, err := parsed.Default.toLimits()
+		if err != nil {
+			return nil, fmt.Errorf("default: %w", err)
+		}
+		file.defaults = &limits
+	}
+
+	for i, entry := range parsed.Users {
+		if entry == nil || entry.UserID == "" {
+			return nil, fmt.Errorf("users entry #%d: user-id is required", i)
+		}
+
+		key := Key{UserID: entry.UserID, APIKeyID: entry.APIKeyID}
+		if _, found := file.entries[key]; found {
+			return nil, fmt.Errorf("users entry #%d: %s is defined more than once", i, key)
+		}
+
+		limits, err := entry.toLimits()
+		if err != nil {
+			return nil, fmt.Errorf("users entry #%d (%s): %w", i, key, err)
+		}
+		file.entries[key] = limits
+	}
+
+	return file, nil
+}
+
+func (l *fileLimits) toLimits() (Limits, error) {
+	limits := Limits{Blocks: l.Blocks, Period: defaultPeriod}
+
+	if l.EgressBytes != "" {
+		egressBytes, err := humanize.ParseBytes(l.EgressBytes)
+		if err != nil {
+			return limits, fmt.Errorf("invalid egress-bytes value %q, must be a size like '10GiB'", l.EgressBytes)
+		}
+		limits.EgressBytes = egressBytes
+	}
+
+	if l.Period != "" {
+		period, err := time.ParseDuration(l.Period)
+		if err != nil || period <= 0 {
+			return limits, fmt.Errorf("invalid period value %q, must be a positive duration like '24h'", l.Period)
+		}
+		limits.Period = period
+	}
+
+	return limits, nil
+}
+
+// limitsOf returns the limits of the API key when it has an entry, then of the user, then the
+// default ones, along with the key its usage is counted under.
+func (f *File) limitsOf(userID, apiKeyID string) (Key, Limits) {
+	if apiKeyID != "" {
+		key := Key{UserID: userID, APIKeyID: apiKeyID}
+		if limits, found := f.entries[key]; found {
+			return key, limits
+		}
+	}
+
+	key := Key{UserID: userID}
+	if limits, found := f.entries[key]; found {
+		return key, limits
+	}
+
+	if f.defaults != nil {
+		return key, *f.defaults
+	}
+
+	return key, Limits{}
+}
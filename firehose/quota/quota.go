@@ -0,0 +1,249 @@
+// Package quota enforces usage limits, in blocks and egress bytes per period, on the callers of
+// the Firehose endpoints.
+//
+// Limits apply to a user (all its API keys together) or to a single API key of a user. They come
+// from the trusted headers set by the auth plugin (see [HeaderBlocks], [HeaderEgressBytes] and
+// [HeaderPeriod]) when present, otherwise from the quota file (see [ParseFile]). Callers without a
+// user ID are never limited.
+//
+// Usage is counted in memory, by this process only, over fixed periods aligned on the Unix epoch
+// (a `24h` period resets at midnight UTC), the usage of a period is dropped once it ended. It is fed with the same values as the metering events
+// (see [metering.WithUsageObserver]), so a stream is terminated at the first block after its quota
+// is exhausted.
+package quota
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/streamingfast/dauth"
+	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/firehose-core/metering"
+	"go.uber.org/zap"
+)
+
+// Trusted headers through which the auth plugin can set the limits of the caller, they apply to
+// its API key when it has one and to the user otherwise.
+const (
+	HeaderBlocks      = "x-sf-quota-blocks"
+	HeaderEgressBytes = "x-sf-quota-egress-bytes"
+	HeaderPeriod      = "x-sf-quota-period"
+)
+
+const (
+	defaultPeriod = 24 * time.Hour
+
+	// sweepInterval is how often the usage of the periods that ended is dropped
+	sweepInterval = time.Minute
+)
+
+// Limits are the usage allowed per period, a zero limit means unlimited.
+type Limits struct {
+	Blocks      uint64
+	EgressBytes uint64
+	Period      time.Duration
+}
+
+func (l Limits) unlimited() bool {
+	return l.Blocks == 0 && l.EgressBytes == 0
+}
+
+// Key identifies whose usage is counted, an empty APIKeyID counts the usage of all the user's keys.
+type Key struct {
+	UserID   string
+	APIKeyID string
+}
+
+func (k Key) String() string {
+	if k.APIKeyID == "" {
+		return fmt.Sprintf("user %q", k.UserID)
+	}
+	return fmt.Sprintf("user %q API key %q", k.UserID, k.APIKeyID)
+}
+
+// ExhaustedError is returned when the quota of a caller is exhausted, until [ExhaustedError.ResetAt].
+type ExhaustedError struct {
+	Key     Key
+	Limit   string
+	Used    uint64
+	Allowed uint64
+	Period  time.Duration
+	ResetAt time.Time
+}
+
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("quota exhausted for %s: %d of %d %s per %s used, resets at %s", e.Key, e.Used, e.Allowed, e.Limit, e.Period, e.ResetAt.UTC().Format(time.RFC3339))
+}
+
+// RetryAfter is the delay until the quota resets.
+func (e *ExhaustedError) RetryAfter(now time.Time) time.Duration {
+	if delay := e.ResetAt.Sub(now); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+type usage struct {
+	periodStart time.Time
+	period      time.Duration
+	blocks      uint64
+	egressBytes uint64
+}
+
+// Manager resolves the limits of the callers and counts their usage.
+type Manager struct {
+	file     *File
+	fromAuth bool
+	logger   *zap.Logger
+	now      func() time.Time
+
+	mu        sync.Mutex
+	usage     map[Key]*usage
+	nextSweep time.Time
+}
+
+// NewManager creates a manager enforcing the limits of [file] (nil for none) and, when [fromAuth]
+// is true, the ones set by the auth plugin trusted headers.
+func NewManager(file *File, fromAuth bool, logger *zap.Logger) *Manager {
+	if file == nil {
+		file = &File{}
+	}
+
+	return &Manager{
+		file:     file,
+		fromAuth: fromAuth,
+		logger:   logger,
+		now:      time.Now,
+		usage:    make(map[Key]*usage),
+	}
+}
+
+// Tracker returns the tracker of the caller identified by [auth], nil when it is not limited.
+func (m *Manager) Tracker(auth dauth.TrustedHeaders) *Tracker {
+	if auth.UserID() == "" {
+		return nil
+	}
+
+	key, limits := m.resolve(auth)
+	if limits.unlimited() {
+		return nil
+	}
+
+	return &Tracker{manager: m, key: key, limits: limits}
+}
+
+func (m *Manager) resolve(auth dauth.TrustedHeaders) (Key, Limits) {
+	if m.fromAuth {
+		if limits, found := m.limitsFromHeaders(auth); found {
+			return Key{UserID: auth.UserID(), APIKeyID: auth.APIKeyID()}, limits
+		}
+	}
+
+	return m.file.limitsOf(auth.UserID(), auth.APIKeyID())
+}
+
+func (m *Manager) limitsFromHeaders(auth dauth.TrustedHeaders) (limits Limits, found bool) {
+	limits.Period = defaultPeriod
+
+	if value := auth.Get(HeaderBlocks); value != "" {
+		blocks, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			m.logger.Warn("ignoring invalid quota header", zap.String("header", HeaderBlocks), zap.String("value", value))
+		} else {
+			limits.Blocks = blocks
+			found = true
+		}
+	}
+
+	if value := auth.Get(HeaderEgressBytes); value != "" {
+		egressBytes, err := humanize.ParseBytes(value)
+		if err != nil {
+			m.logger.Warn("ignoring invalid quota header", zap.String("header", HeaderEgressBytes), zap.String("value", value))
+		} else {
+			limits.EgressBytes = egressBytes
+			found = true
+		}
+	}
+
+	if value := auth.Get(HeaderPeriod); value != "" {
+		period, err := time.ParseDuration(value)
+		if err != nil || period <= 0 {
+			m.logger.Warn("ignoring invalid quota header", zap.String("header", HeaderPeriod), zap.String("value", value))
+		} else {
+			limits.Period = period
+		}
+	}
+
+	return limits, found
+}
+
+// current returns the usage of [key] in the ongoing period, the caller must hold the lock.
+func (m *Manager) current(key Key, period time.Duration) *usage {
+	now := m.now()
+	m.sweep(now)
+
+	periodStart := now.Truncate(period)
+
+	entry, found := m.usage[key]
+	if !found || !entry.periodStart.Equal(periodStart) {
+		entry = &usage{periodStart: periodStart, period: period}
+		m.usage[key] = entry
+	}
+
+	return entry
+}
+
+// sweep drops, at most once per [sweepInterval], the usage of the periods that ended so that the
+// callers not seen anymore are forgotten, the caller must hold the lock.
+func (m *Manager) sweep(now time.Time) {
+	if now.Before(m.nextSweep) {
+		return
+	}
+	m.nextSweep = now.Add(sweepInterval)
+
+	for key, entry := range m.usage {
+		if !now.Before(entry.periodStart.Add(entry.period)) {
+			delete(m.usage, key)
+		}
+	}
+}
+
+// Tracker checks and counts the usage of a single caller.
+type Tracker struct {
+	manager *Manager
+	key     Key
+	limits  Limits
+}
+
+// Check returns an [*ExhaustedError] when the quota of the caller is exhausted.
+func (t *Tracker) Check() error {
+	t.manager.mu.Lock()
+	defer t.manager.mu.Unlock()
+
+	entry := t.manager.current(t.key, t.limits.Period)
+	resetAt := entry.periodStart.Add(t.limits.Period)
+
+	if t.limits.Blocks > 0 && entry.blocks >= t.limits.Blocks {
+		return &ExhaustedError{Key: t.key, Limit: "blocks", Used: entry.blocks, Allowed: t.limits.Blocks, Period: t.limits.Period, ResetAt: resetAt}
+	}
+
+	if t.limits.EgressBytes > 0 && entry.egressBytes >= t.limits.EgressBytes {
+		return &ExhaustedError{Key: t.key, Limit: "egress bytes", Used: entry.egressBytes, Allowed: t.limits.EgressBytes, Period: t.limits.Period, ResetAt: resetAt}
+	}
+
+	return nil
+}
+
+// Record counts the blocks and egress bytes of a metering [event], it is meant to be passed to
+// [metering.WithUsageObserver].
+func (t *Tracker) Record(event dmetering.Event) {
+	t.manager.mu.Lock()
+	defer t.manager.mu.Unlock()
+
+	entry := t.manager.current(t.key, t.limits.Period)
+	entry.blocks += uint64(event.Metrics[metering.MeterBlockCount])
+	entry.egressBytes += uint64(event.Metrics[metering.MeterEgressBytes])
+}
@@ -0,0 +1,147 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/streamingfast/dauth"
+	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/firehose-core/metering"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func auth(userID, apiKeyID string, extra ...string) dauth.TrustedHeaders {
+	headers := dauth.TrustedHeaders{dauth.SFHeaderUserID: userID, dauth.SFHeaderApiKeyID: apiKeyID}
+	for i := 0; i+1 < len(extra); i += 2 {
+		headers[extra[i]] = extra[i+1]
+	}
+	return headers
+}
+
+func blockEvent(egressBytes float64) dmetering.Event {
+	return dmetering.Event{Metrics: map[string]float64{metering.MeterBlockCount: 1, metering.MeterEgressBytes: egressBytes}}
+}
+
+func TestParseFile(t *testing.T) {
+	file, err := ParseFile([]byte(`
+default: {blocks: 100}
+users:
+  - {user-id: alice, egress-bytes: 1KiB, period: 1h}
+  - {user-id: bob, api-key-id: ci, blocks: 10}
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		userID, apiKeyID string
+		expectedKey      Key
+		expectedLimits   Limits
+	}{
+		{"alice", "any", Key{UserID: "alice"}, Limits{EgressBytes: 1024, Period: time.Hour}},
+		{"bob", "ci", Key{UserID: "bob", APIKeyID: "ci"}, Limits{Blocks: 10, Period: 24 * time.Hour}},
+		{"bob", "other", Key{UserID: "bob"}, Limits{Blocks: 100, Period: 24 * time.Hour}},
+		{"carol", "", Key{UserID: "carol"}, Limits{Blocks: 100, Period: 24 * time.Hour}},
+	}
+
+	for _, tt := range tests {
+		key, limits := file.limitsOf(tt.userID, tt.apiKeyID)
+		assert.Equal(t, tt.expectedKey, key, tt.userID+"/"+tt.apiKeyID)
+		assert.Equal(t, tt.expectedLimits, limits, tt.userID+"/"+tt.apiKeyID)
+	}
+
+	for content, expectedError := range map[string]string{
+		"users:\n  - {blocks: 10}\n":                                 "users entry #0: user-id is required",
+		"users:\n  - {user-id: a}\n  - {user-id: a}\n":               `users entry #1: user "a" is defined more than once`,
+		"default: {egress-bytes: lots}\n":                            `default: invalid egress-bytes value "lots"`,
+		"users:\n  - {user-id: a, api-key-id: b, period: forever}\n": `users entry #0 (user "a" API key "b"): invalid period value "forever"`,
+		"default: {block: 10}\n":                                     "field block not found",
+	} {
+		_, err := ParseFile([]byte(content))
+		assert.ErrorContains(t, err, expectedError, content)
+	}
+}
+
+func TestManager_Blocks(t *testing.T) {
+	file, err := ParseFile([]byte("default: {blocks: 3, period: 1h}\n"))
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC)
+	manager := NewManager(file, false, zap.NewNop())
+	manager.now = func() time.Time { return now }
+
+	// Callers without a user ID are not limited
+	assert.Nil(t, manager.Tracker(auth("", "key")))
+
+	tracker := manager.Tracker(auth("alice", "key"))
+	require.NotNil(t, tracker)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, tracker.Check())
+		tracker.Record(blockEvent(10))
+	}
+
+	err = tracker.Check()
+	var exhausted *ExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Equal(t, `quota exhausted for user "alice": 3 of 3 blocks per 1h0m0s used, resets at 2026-01-01T11:00:00Z`, err.Error())
+	assert.Equal(t, 45*time.Minute, exhausted.RetryAfter(now))
+
+	// Usage is per user, all its keys together, and not shared with other users
+	require.Error(t, manager.Tracker(auth("alice", "other")).Check())
+	require.NoError(t, manager.Tracker(auth("bob", "key")).Check())
+
+	// Next period
+	now = now.Add(time.Hour)
+	require.NoError(t, tracker.Check())
+}
+
+func TestManager_EgressBytesFromAuth(t *testing.T) {
+	manager := NewManager(nil, true, zap.NewNop())
+
+	assert.Nil(t, manager.Tracker(auth("alice", "key")))
+
+	tracker := manager.Tracker(auth("alice", "key", HeaderEgressBytes, "100B", HeaderPeriod, "1h"))
+	require.NotNil(t, tracker)
+	assert.Equal(t, Key{UserID: "alice", APIKeyID: "key"}, tracker.key)
+	assert.Equal(t, Limits{EgressBytes: 100, Period: time.Hour}, tracker.limits)
+
+	tracker.Record(blockEvent(60))
+	require.NoError(t, tracker.Check())
+	tracker.Record(blockEvent(60))
+
+	var exhausted *ExhaustedError
+	require.ErrorAs(t, tracker.Check(), &exhausted)
+	assert.Equal(t, "egress bytes", exhausted.Limit)
+	assert.Equal(t, uint64(120), exhausted.Used)
+
+	// Invalid headers are ignored
+	assert.Nil(t, NewManager(nil, true, zap.NewNop()).Tracker(auth("alice", "", HeaderBlocks, "many")))
+
+	// Headers are only trusted when enabled
+	assert.Nil(t, NewManager(nil, false, zap.NewNop()).Tracker(auth("alice", "", HeaderBlocks, "10")))
+}
+
+func TestManager_EvictsEndedPeriods(t *testing.T) {
+	file, err := ParseFile([]byte("default: {blocks: 3, period: 1h}\nusers:\n  - {user-id: bob, blocks: 3, period: 24h}\n"))
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC)
+	manager := NewManager(file, false, zap.NewNop())
+	manager.now = func() time.Time { return now }
+
+	manager.Tracker(auth("alice", "key")).Record(blockEvent(10))
+	manager.Tracker(auth("bob", "key")).Record(blockEvent(10))
+	assert.Len(t, manager.usage, 2)
+
+	// Within the sweep interval, nothing is dropped
+	now = now.Add(30 * time.Second)
+	require.NoError(t, manager.Tracker(auth("carol", "key")).Check())
+	assert.Len(t, manager.usage, 3)
+
+	// Alice's and Carol's hour ended, Bob's day did not
+	now = now.Add(50 * time.Minute)
+	manager.Tracker(auth("bob", "key")).Record(blockEvent(10))
+	assert.Len(t, manager.usage, 1)
+	assert.Equal(t, uint64(2), manager.usage[Key{UserID: "bob"}].blocks)
+}
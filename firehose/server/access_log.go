@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/streamingfast/dauth"
+	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/firehose-core/firehose/accesslog"
+	"github.com/streamingfast/firehose-core/metering"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// WithAccessLogSink writes an access log record to [sink] for each `Blocks` request once it ends,
+// the sink can be shared by several servers and is not closed by them.
+func WithAccessLogSink(sink accesslog.Sink) Option {
+	return func(s *Server) {
+		s.accessLog = sink
+	}
+}
+
+func newBlocksAccessRecord(request *pbfirehose.Request) *accesslog.Record {
+	record := &accesslog.Record{
+		StartTime:       time.Now(),
+		Endpoint:        "sf.firehose.v2.Stream/Blocks",
+		StartBlockNum:   request.StartBlockNum,
+		StopBlockNum:    request.StopBlockNum,
+		Cursor:          request.Cursor,
+		FinalBlocksOnly: request.FinalBlocksOnly,
+	}
+
+	for _, transform := range request.Transforms {
+		record.Transforms = append(record.Transforms, describeTransform(transform))
+	}
+
+	return record
+}
+
+func describeTransform(transform *anypb.Any) accesslog.Transform {
+	description := accesslog.Transform{Type: transform.TypeUrl}
+
+	// Unknown transform types are still described by their type
+	if message, err := transform.UnmarshalNew(); err == nil {
+		if value, err := protojson.Marshal(message); err == nil {
+			description.Value = string(value)
+		}
+	}
+
+	return description
+}
+
+// logAccess completes [record] with the caller, the termination status and the metering totals
+// found in [ctx] then writes it to the access log sink, if any.
+func (s *Server) logAccess(ctx context.Context, record *accesslog.Record, err error) {
+	if s.accessLog == nil {
+		return
+	}
+
+	record.Timestamp = time.Now()
+	record.DurationMs = record.Timestamp.Sub(record.StartTime).Milliseconds()
+
+	if auth := dauth.FromContext(ctx); auth != nil {
+		record.UserID = auth.UserID()
+		record.APIKeyID = auth.APIKeyID()
+		record.RealIP = auth.RealIP()
+		record.Meta = auth.Meta()
+	}
+
+	st := status.Convert(err)
+	record.Status = st.Code().String()
+	record.Reason = "COMPLETED"
+	if err != nil {
+		record.Reason = "UNKNOWN"
+		record.Error = st.Message()
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok {
+				record.Reason = info.Reason
+			}
+		}
+	}
+
+	meter := getRequestMeter(ctx)
+	record.BlocksSent = meter.blocks
+	record.EgressBytes = uint64(meter.egressBytes)
+	record.ReadBytes = metering.GetTotalBytesRead(dmetering.GetBytesMeter(ctx))
+
+	s.accessLog.Write(record)
+}
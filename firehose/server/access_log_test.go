@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/firehose-core/firehose/accesslog"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestServer_LogAccess(t *testing.T) {
+	sink := &testAccessLogSink{}
+	server := &Server{logger: zap.NewNop()}
+	WithAccessLogSink(sink)(server)
+
+	transform, err := anypb.New(&fieldmaskpb.FieldMask{Paths: []string{"header.number"}})
+	require.NoError(t, err)
+
+	record := newBlocksAccessRecord(&pbfirehose.Request{StartBlockNum: -5, StopBlockNum: 100, FinalBlocksOnly: true, Transforms: []*anypb.Any{transform}})
+
+	ctx := withRequestMeter(context.Background())
+	getRequestMeter(ctx).blocks = 3
+	getRequestMeter(ctx).egressBytes = 1024
+
+	server.logAccess(ctx, record, statusError(codes.Unavailable, "DRAINING", "server is draining", "cursor", 0))
+
+	require.Len(t, sink.records, 1)
+	logged := sink.records[0]
+	assert.Equal(t, "sf.firehose.v2.Stream/Blocks", logged.Endpoint)
+	assert.Equal(t, int64(-5), logged.StartBlockNum)
+	assert.Equal(t, uint64(100), logged.StopBlockNum)
+	assert.True(t, logged.FinalBlocksOnly)
+	assert.Equal(t, []accesslog.Transform{{Type: "type.googleapis.com/google.protobuf.FieldMask", Value: `"header.number"`}}, logged.Transforms)
+	assert.Equal(t, "Unavailable", logged.Status)
+	assert.Equal(t, "DRAINING", logged.Reason)
+	assert.Equal(t, "server is draining", logged.Error)
+	assert.Equal(t, uint64(3), logged.BlocksSent)
+	assert.Equal(t, uint64(1024), logged.EgressBytes)
+	assert.False(t, logged.Timestamp.Before(logged.StartTime))
+
+	server.logAccess(ctx, newBlocksAccessRecord(&pbfirehose.Request{}), nil)
+	require.Len(t, sink.records, 2)
+	assert.Equal(t, "OK", sink.records[1].Status)
+	assert.Equal(t, "COMPLETED", sink.records[1].Reason)
+}
+
+type testAccessLogSink struct {
+	records []*accesslog.Record
+}
+
+func (s *testAccessLogSink) Write(record *accesslog.Record) { s.records = append(s.records, record) }
+func (s *testAccessLogSink) Close() error                   { return nil }
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/bstream/hub"
+	"github.com/streamingfast/firehose-core/firehose/admission"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// RetryAfterHeader is the response header holding the number of seconds a client rejected because
+// the server is overloaded should wait before retrying.
+const RetryAfterHeader = "retry-after"
+
+// WithAdmissionControl only admits new historical streams while the server is not overloaded
+// according to [config], live-only streams are always admitted. See [admission.Controller].
+func WithAdmissionControl(config admission.Config) Option {
+	return func(s *Server) {
+		var inflightPreprocessing func() int64
+		if s.streamFactory != nil {
+			inflightPreprocessing = s.streamFactory.InflightPreprocessing
+		}
+
+		s.admission = admission.NewController(config, inflightPreprocessing, s.logger)
+	}
+}
+
+// admit admits the stream of [request], the returned release func must be called once it ends.
+// Rejected streams get a `ResourceExhausted` status along with a `retry-after` response header.
+func (s *Server) admit(ctx context.Context, request *pbfirehose.Request, streamSrv pbfirehose.Stream_BlocksServer) (release func(), err error) {
+	if s.admission == nil {
+		return func() {}, nil
+	}
+
+	liveOnly, err := isLiveOnlyRequest(request, s.hub)
+	if err != nil {
+		return nil, statusError(codes.InvalidArgument, "INVALID_CURSOR", err.Error(), "", 0)
+	}
+
+	release, err = s.admission.Admit(ctx, liveOnly)
+	if err != nil {
+		var rejected *admission.RejectedError
+		if !errors.As(err, &rejected) {
+			return nil, statusError(codes.Canceled, "CANCELED", "source canceled while waiting to be admitted", "", 0)
+		}
+
+		retryAfterSeconds := int(math.Ceil(rejected.RetryAfter.Seconds()))
+		streamSrv.SetHeader(metadata.Pairs(RetryAfterHeader, strconv.Itoa(retryAfterSeconds)))
+
+		return nil, statusError(codes.ResourceExhausted, "SERVER_OVERLOADED", rejected.Error(), "", rejected.RetryAfter)
+	}
+
+	return release, nil
+}
+
+// isLiveOnlyRequest returns true when [request] starts within the blocks held by the hub, such a
+// stream is served from memory without reading nor preprocessing merged blocks. An undecodable
+// cursor is an error, it must not be admitted as a live-only stream.
+func isLiveOnlyRequest(request *pbfirehose.Request, forkableHub *hub.ForkableHub) (bool, error) {
+	var cursor *bstream.Cursor
+	if request.Cursor != "" {
+		var err error
+		if cursor, err = bstream.CursorFromOpaque(request.Cursor); err != nil {
+			return false, fmt.Errorf("invalid start cursor %q: %w", request.Cursor, err)
+		}
+	}
+
+	if forkableHub == nil || !forkableHub.IsReady() {
+		return false, nil
+	}
+
+	var startBlockNum uint64
+	switch {
+	case cursor != nil:
+		startBlockNum = cursor.Block.Num()
+
+	case request.StartBlockNum < 0:
+		headNum := forkableHub.HeadNum()
+		if uint64(-request.StartBlockNum) > headNum {
+			return false, nil
+		}
+		startBlockNum = headNum - uint64(-request.StartBlockNum)
+
+	default:
+		startBlockNum = uint64(request.StartBlockNum)
+	}
+
+	return startBlockNum >= forkableHub.LowestBlockNum(), nil
+}
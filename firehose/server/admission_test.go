@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/firehose-core/firehose/admission"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_Admit(t *testing.T) {
+	server := &Server{logger: zap.NewNop()}
+	WithAdmissionControl(admission.Config{MaxHistoricalStreams: 1, RetryAfter: 1500 * time.Millisecond})(server)
+
+	ctx := context.Background()
+	request := &pbfirehose.Request{StartBlockNum: 10}
+
+	release, err := server.admit(ctx, request, &testHeaderBlocksServer{})
+	require.NoError(t, err)
+	defer release()
+
+	stream := &testHeaderBlocksServer{}
+	_, err = server.admit(ctx, request, stream)
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Equal(t, []string{"2"}, stream.header.Get(RetryAfterHeader))
+
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	require.NotNil(t, retryInfo)
+	assert.Equal(t, 1500*time.Millisecond, retryInfo.RetryDelay.AsDuration())
+}
+
+func TestServer_Admit_InvalidCursor(t *testing.T) {
+	server := &Server{logger: zap.NewNop()}
+	WithAdmissionControl(admission.Config{MaxHistoricalStreams: 1})(server)
+
+	_, err := server.admit(context.Background(), &pbfirehose.Request{Cursor: "not a cursor"}, &testHeaderBlocksServer{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The rejected request did not take the only historical stream slot
+	release, err := server.admit(context.Background(), &pbfirehose.Request{StartBlockNum: 10}, &testHeaderBlocksServer{})
+	require.NoError(t, err)
+	release()
+}
+
+type testHeaderBlocksServer struct {
+	testBlocksServer
+	header metadata.MD
+}
+
+func (s *testHeaderBlocksServer) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
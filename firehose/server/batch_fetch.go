@@ -0,0 +1,256 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/streamingfast/dauth"
+	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/firehose-core/firehose/metrics"
+	"github.com/streamingfast/firehose-core/firehose/quota"
+	"github.com/streamingfast/firehose-core/metering"
+	pbbatchfetch "github.com/streamingfast/firehose-core/pb/sf/firehose/batchfetch/v1"
+	"github.com/streamingfast/logging"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultBatchFetchConcurrency = 8
+
+	// maxBatchFetchRangeSize is the maximum number of blocks a single `block_range` request can reference
+	maxBatchFetchRangeSize = 10_000
+)
+
+// WithBatchFetchConcurrency controls how many blocks are resolved concurrently for a single
+// `sf.firehose.batchfetch.v1.BatchFetch/Blocks` call.
+func WithBatchFetchConcurrency(concurrency int) Option {
+	return func(s *Server) {
+		if concurrency > 0 {
+			s.batchFetchConcurrency = concurrency
+		}
+	}
+}
+
+type batchFetchServer struct {
+	server *Server
+}
+
+var _ pbbatchfetch.BatchFetchServer = (*batchFetchServer)(nil)
+
+type batchFetchResult struct {
+	request blockRequest
+	resp    *pbfirehose.SingleBlockResponse
+	meter   dmetering.Meter
+	err     error
+}
+
+// Blocks resolves every block referenced by the received requests concurrently (up to the server's
+// batch fetch concurrency) from the hub, the merged blocks store and the forked blocks store, and
+// sends responses back in the order requests were received. Each block sent is metered individually.
+func (b *batchFetchServer) Blocks(stream pbbatchfetch.BatchFetch_BlocksServer) error {
+	if target, err := b.server.routeChain(stream.Context()); err != nil || target != b.server {
+		if err != nil {
+			return err
+		}
+		return (&batchFetchServer{server: target}).Blocks(stream)
+	}
+
+	if err := b.server.rejectIfDraining(); err != nil {
+		return err
+	}
+
+	quotaTracker := b.server.quotaTracker(stream.Context())
+	if quotaTracker != nil {
+		var exhausted *quota.ExhaustedError
+		if err := quotaTracker.Check(); errors.As(err, &exhausted) {
+			return quotaExhaustedError(exhausted, "")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	if quotaTracker != nil {
+		ctx = metering.WithUsageObserver(ctx, quotaTracker.Record)
+	}
+
+	metrics.RequestCounter.Inc()
+	metrics.ActiveRequests.Inc()
+	defer metrics.ActiveRequests.Dec()
+
+	logger := logging.Logger(ctx, b.server.logger)
+	concurrency := b.server.batchFetchConcurrency
+
+	// Each pending request gets its own single-element channel, reading those in order
+	// keeps the responses ordered while resolution happens concurrently.
+	pending := make(chan chan batchFetchResult, concurrency)
+	slots := make(chan struct{}, concurrency)
+
+	var recvErr error
+	go func() {
+		defer close(pending)
+
+		for {
+			received, err := stream.Recv()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					recvErr = err
+				}
+				return
+			}
+
+			requests, err := singleBlockRequests(received)
+			if err != nil {
+				recvErr = err
+				return
+			}
+
+			for _, request := range requests {
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+
+				out := make(chan batchFetchResult, 1)
+				select {
+				case pending <- out:
+				case <-ctx.Done():
+					return
+				}
+
+				go func() {
+					defer func() { <-slots }()
+
+					// Each block gets its own meter so that reads are attributed to the block that caused them
+					meter := dmetering.NewBytesMeter()
+					resp, err := b.server.fetchBlock(dmetering.WithExistingBytesMeter(ctx, meter), request)
+					out <- batchFetchResult{request: request, resp: resp, meter: meter, err: err}
+				}()
+			}
+		}
+	}()
+
+	auth := dauth.FromContext(ctx)
+	var sent, notFound int
+	for out := range pending {
+		var result batchFetchResult
+		select {
+		case result = <-out:
+		case <-ctx.Done():
+			return status.Error(codes.Canceled, "source canceled")
+		}
+
+		var resp *pbbatchfetch.Response
+		if result.err != nil {
+			if status.Code(result.err) != codes.NotFound {
+				logger.Info("batch fetch terminated on block error", zap.Int("sent", sent), zap.Error(result.err))
+				return result.err
+			}
+
+			notFound++
+			resp = notFoundResponse(result.request)
+		} else {
+			if quotaTracker != nil {
+				var exhausted *quota.ExhaustedError
+				if err := quotaTracker.Check(); errors.As(err, &exhausted) {
+					logger.Info("batch fetch ended because the caller quota is exhausted", zap.Int("sent", sent), zap.Error(err))
+					return quotaExhaustedError(exhausted, "")
+				}
+			}
+
+			resp = batchFetchResponse(result.resp)
+			metering.Send(ctx, result.meter, auth.UserID(), auth.APIKeyID(), auth.RealIP(), auth.Meta(), "sf.firehose.batchfetch.v1.BatchFetch/Blocks", resp)
+		}
+
+		if err := stream.Send(resp); err != nil {
+			logger.Info("batch fetch send error", zap.Int("sent", sent), zap.Error(err))
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		sent++
+	}
+
+	// Reading recvErr is safe, the receiving goroutine closed pending after setting it
+	if recvErr != nil {
+		logger.Info("batch fetch receive error", zap.Int("sent", sent), zap.Error(recvErr))
+		return recvErr
+	}
+
+	logger.Info("batch fetch completed", zap.Int("sent", sent), zap.Int("not_found", notFound))
+	return nil
+}
+
+// singleBlockRequests returns the single block requests of [request], one per block of its range
+// when it references a range.
+func singleBlockRequests(request *pbbatchfetch.Request) ([]blockRequest, error) {
+	switch ref := request.Reference.(type) {
+	case *pbbatchfetch.Request_BlockNumber_:
+		return []blockRequest{{SingleBlockRequest: blockNumberRequest(ref.BlockNumber.Num)}}, nil
+	case *pbbatchfetch.Request_BlockHashAndNumber_:
+		return []blockRequest{{SingleBlockRequest: blockHashAndNumberRequest(ref.BlockHashAndNumber.Num, ref.BlockHashAndNumber.Hash)}}, nil
+	case *pbbatchfetch.Request_BlockHash_:
+		if ref.BlockHash.Hash == "" {
+			return nil, status.Error(codes.InvalidArgument, "block hash reference has no hash")
+		}
+		return []blockRequest{{SingleBlockRequest: blockHashAndNumberRequest(0, ref.BlockHash.Hash), byHash: true}}, nil
+	case *pbbatchfetch.Request_Cursor_:
+		return []blockRequest{{SingleBlockRequest: &pbfirehose.SingleBlockRequest{Reference: &pbfirehose.SingleBlockRequest_Cursor_{
+			Cursor: &pbfirehose.SingleBlockRequest_Cursor{Cursor: ref.Cursor.Cursor},
+		}}}}, nil
+	case *pbbatchfetch.Request_BlockRange_:
+		start, stop := ref.BlockRange.StartBlockNum, ref.BlockRange.StopBlockNum
+		if stop < start {
+			return nil, status.Errorf(codes.InvalidArgument, "block range stop block %d is lower than its start block %d", stop, start)
+		}
+		if stop-start >= maxBatchFetchRangeSize {
+			return nil, status.Errorf(codes.InvalidArgument, "block range [%d, %d] references more than %d blocks", start, stop, maxBatchFetchRangeSize)
+		}
+
+		requests := make([]blockRequest, 0, stop-start+1)
+		// Iterates over a count, a block number based loop would never end with a stop block of 2^64-1
+		for i := uint64(0); i <= stop-start; i++ {
+			requests = append(requests, blockRequest{SingleBlockRequest: blockNumberRequest(start + i)})
+		}
+		return requests, nil
+	}
+
+	return nil, status.Error(codes.InvalidArgument, "request has no block reference")
+}
+
+func blockNumberRequest(num uint64) *pbfirehose.SingleBlockRequest {
+	return &pbfirehose.SingleBlockRequest{Reference: &pbfirehose.SingleBlockRequest_BlockNumber_{BlockNumber: &pbfirehose.SingleBlockRequest_BlockNumber{Num: num}}}
+}
+
+func blockHashAndNumberRequest(num uint64, hash string) *pbfirehose.SingleBlockRequest {
+	return &pbfirehose.SingleBlockRequest{Reference: &pbfirehose.SingleBlockRequest_BlockHashAndNumber_{BlockHashAndNumber: &pbfirehose.SingleBlockRequest_BlockHashAndNumber{Num: num, Hash: hash}}}
+}
+
+func batchFetchResponse(resp *pbfirehose.SingleBlockResponse) *pbbatchfetch.Response {
+	metadata := resp.Metadata
+	return &pbbatchfetch.Response{
+		Block: resp.Block,
+		Metadata: &pbbatchfetch.BlockMetadata{
+			Num:       metadata.Num,
+			Id:        metadata.Id,
+			ParentNum: metadata.ParentNum,
+			ParentId:  metadata.ParentId,
+			LibNum:    metadata.LibNum,
+			Time:      metadata.Time,
+		},
+	}
+}
+
+func notFoundResponse(request blockRequest) *pbbatchfetch.Response {
+	blockNum, blockHash, _ := singleBlockReference(request.SingleBlockRequest)
+
+	return &pbbatchfetch.Response{
+		Metadata: &pbbatchfetch.BlockMetadata{
+			Id:  blockHash,
+			Num: blockNum,
+		},
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/firehose"
+	pbbatchfetch "github.com/streamingfast/firehose-core/pb/sf/firehose/batchfetch/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBatchFetchServer_Blocks(t *testing.T) {
+	mergedBlocksStore := newTestMergedBlocksStore(t, "00000001a", "00000002a", "00000003a", "00000004a")
+	server := &Server{
+		blockGetter:           firehose.NewBlockGetter(mergedBlocksStore, nil, nil, nil),
+		logger:                zap.NewNop(),
+		batchFetchConcurrency: 2,
+	}
+
+	stream := &testBatchFetchStream{
+		ctx: context.Background(),
+		requests: []*pbbatchfetch.Request{
+			batchBlockNumberRequest(3),
+			batchBlockNumberRequest(1),
+			{Reference: &pbbatchfetch.Request_BlockHashAndNumber_{BlockHashAndNumber: &pbbatchfetch.Request_BlockHashAndNumber{Num: 2, Hash: "00000002b"}}},
+			batchBlockNumberRequest(4),
+			// Hash only, no block hash index nor forked blocks store configured
+			{Reference: &pbbatchfetch.Request_BlockHash_{BlockHash: &pbbatchfetch.Request_BlockHash{Hash: "00000003a"}}},
+			batchBlockRangeRequest(3, 5),
+		},
+	}
+
+	require.NoError(t, (&batchFetchServer{server: server}).Blocks(stream))
+	require.Len(t, stream.sent, 8)
+
+	type ref struct {
+		num     uint64
+		id      string
+		isFound bool
+	}
+
+	var actual []ref
+	for _, resp := range stream.sent {
+		actual = append(actual, ref{resp.Metadata.Num, resp.Metadata.Id, resp.Block != nil})
+	}
+
+	assert.Equal(t, []ref{
+		{3, "00000003a", true},
+		{1, "00000001a", true},
+		{2, "00000002b", false},
+		{4, "00000004a", true},
+		{0, "00000003a", false},
+		{3, "00000003a", true},
+		{4, "00000004a", true},
+		{5, "", false},
+	}, actual)
+}
+
+func TestBatchFetchServer_InvalidRange(t *testing.T) {
+	server := &Server{
+		blockGetter:           firehose.NewBlockGetter(newTestMergedBlocksStore(t, "00000001a"), nil, nil, nil),
+		logger:                zap.NewNop(),
+		batchFetchConcurrency: 2,
+	}
+
+	for _, request := range []*pbbatchfetch.Request{
+		batchBlockRangeRequest(5, 4),
+		batchBlockRangeRequest(0, maxBatchFetchRangeSize),
+		{Reference: &pbbatchfetch.Request_BlockHash_{BlockHash: &pbbatchfetch.Request_BlockHash{}}},
+		{},
+	} {
+		stream := &testBatchFetchStream{ctx: context.Background(), requests: []*pbbatchfetch.Request{request}}
+
+		err := (&batchFetchServer{server: server}).Blocks(stream)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Empty(t, stream.sent)
+	}
+}
+
+func TestSingleBlockRequests_RangeEndingAtMaxUint64(t *testing.T) {
+	requests, err := singleBlockRequests(batchBlockRangeRequest(math.MaxUint64-1, math.MaxUint64))
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.Equal(t, uint64(math.MaxUint64-1), requests[0].GetBlockNumber().Num)
+	assert.Equal(t, uint64(math.MaxUint64), requests[1].GetBlockNumber().Num)
+}
+
+func batchBlockNumberRequest(num uint64) *pbbatchfetch.Request {
+	return &pbbatchfetch.Request{Reference: &pbbatchfetch.Request_BlockNumber_{BlockNumber: &pbbatchfetch.Request_BlockNumber{Num: num}}}
+}
+
+func batchBlockRangeRequest(start, stop uint64) *pbbatchfetch.Request {
+	return &pbbatchfetch.Request{Reference: &pbbatchfetch.Request_BlockRange_{BlockRange: &pbbatchfetch.Request_BlockRange{StartBlockNum: start, StopBlockNum: stop}}}
+}
+
+func newTestMergedBlocksStore(t *testing.T, blockIDs ...string) *dstore.MockStore {
+	t.Helper()
+
+	buffer := bytes.NewBuffer(nil)
+	writer, err := bstream.NewDBinBlockWriter(buffer)
+	require.NoError(t, err)
+
+	previous := ""
+	for _, id := range blockIDs {
+		require.NoError(t, writer.Write(bstream.TestBlock(id, previous)))
+		previous = id
+	}
+
+	store := dstore.NewMockStore(nil)
+	store.SetFile("0000000000", buffer.Bytes())
+
+	return store
+}
+
+type testBatchFetchStream struct {
+	grpc.ServerStream
+
+	ctx      context.Context
+	requests []*pbbatchfetch.Request
+	sent     []*pbbatchfetch.Response
+}
+
+func (s *testBatchFetchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testBatchFetchStream) Recv() (*pbbatchfetch.Request, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+
+	request := s.requests[0]
+	s.requests = s.requests[1:]
+	return request, nil
+}
+
+func (s *testBatchFetchStream) Send(resp *pbbatchfetch.Response) error {
+	s.sent = append(s.sent, resp)
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/streamingfast/bstream"
@@ -14,6 +16,7 @@ import (
 	"github.com/streamingfast/dauth"
 	"github.com/streamingfast/dmetering"
 	"github.com/streamingfast/firehose-core/firehose/metrics"
+	"github.com/streamingfast/firehose-core/firehose/quota"
 	"github.com/streamingfast/firehose-core/metering"
 	"github.com/streamingfast/logging"
 	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
@@ -26,25 +29,92 @@ import (
 )
 
 func (s *Server) Block(ctx context.Context, request *pbfirehose.SingleBlockRequest) (*pbfirehose.SingleBlockResponse, error) {
-	var blockNum uint64
-	var blockHash string
-	switch ref := request.Reference.(type) {
-	case *pbfirehose.SingleBlockRequest_BlockHashAndNumber_:
-		blockNum = ref.BlockHashAndNumber.Num
-		blockHash = ref.BlockHashAndNumber.Hash
-	case *pbfirehose.SingleBlockRequest_Cursor_:
-		cur, err := bstream.CursorFromOpaque(ref.Cursor.Cursor)
+	if target, err := s.routeChain(ctx); err != nil || target != s {
 		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			return nil, err
 		}
-		blockNum = cur.Block.Num()
-		blockHash = cur.Block.ID()
-	case *pbfirehose.SingleBlockRequest_BlockNumber_:
-		blockNum = ref.BlockNumber.Num
+		return target.Block(ctx, request)
+	}
+
+	if err := s.rejectIfDraining(); err != nil {
+		return nil, err
+	}
+
+	if tracker := s.quotaTracker(ctx); tracker != nil {
+		var exhausted *quota.ExhaustedError
+		if err := tracker.Check(); errors.As(err, &exhausted) {
+			return nil, quotaExhaustedError(exhausted, "")
+		}
+		ctx = metering.WithUsageObserver(ctx, tracker.Record)
+	}
+
+	byHash, err := isBlockByHashRequest(ctx, request)
+	if err != nil {
+		return nil, err
 	}
 
 	ctx = dmetering.WithBytesMeter(ctx)
-	blk, err := s.blockGetter.Get(ctx, blockNum, blockHash, s.logger)
+	resp, err := s.fetchBlock(ctx, blockRequest{request, byHash})
+	if err != nil {
+		return nil, err
+	}
+
+	meter := dmetering.GetBytesMeter(ctx)
+	auth := dauth.FromContext(ctx)
+	metering.Send(ctx, meter, auth.UserID(), auth.APIKeyID(), auth.RealIP(), auth.Meta(), "sf.firehose.v2.Firehose/Block", resp)
+
+	return resp, nil
+}
+
+// BlockByHashHeader, set to `true` on a `Block` request, fetches the block having the hash of its
+// `BlockHashAndNumber` reference whatever its number. Without it the `num` of the reference is always
+// used, a `num` of 0 referencing the genesis block.
+const BlockByHashHeader = "x-firehose-block-by-hash"
+
+// blockRequest references a single block, by the hash of its `BlockHashAndNumber` reference alone
+// when [byHash] is set.
+type blockRequest struct {
+	*pbfirehose.SingleBlockRequest
+	byHash bool
+}
+
+// isBlockByHashRequest returns whether [request] asks for a block by hash alone through the
+// [BlockByHashHeader] header.
+func isBlockByHashRequest(ctx context.Context, request *pbfirehose.SingleBlockRequest) (bool, error) {
+	values := metadata.ValueFromIncomingContext(ctx, BlockByHashHeader)
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	byHash, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, status.Errorf(codes.InvalidArgument, "invalid %s header value %q", BlockByHashHeader, values[0])
+	}
+
+	if byHash {
+		ref, ok := request.Reference.(*pbfirehose.SingleBlockRequest_BlockHashAndNumber_)
+		if !ok || ref.BlockHashAndNumber.Hash == "" {
+			return false, status.Errorf(codes.InvalidArgument, "%s header requires a 'block_hash_and_number' reference with a hash", BlockByHashHeader)
+		}
+	}
+
+	return byHash, nil
+}
+
+// fetchBlock resolves the block referenced by [request], store reads are metered against the
+// bytes meter found in [ctx].
+func (s *Server) fetchBlock(ctx context.Context, request blockRequest) (*pbfirehose.SingleBlockResponse, error) {
+	blockNum, blockHash, err := singleBlockReference(request.SingleBlockRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var blk *pbbstream.Block
+	if request.byHash {
+		blk, err = s.blockGetter.GetByHash(ctx, blockHash, s.logger)
+	} else {
+		blk, err = s.blockGetter.Get(ctx, blockNum, blockHash, s.logger)
+	}
 	if err != nil {
 		if _, ok := status.FromError(err); ok {
 			return nil, err
@@ -55,7 +125,7 @@ func (s *Server) Block(ctx context.Context, request *pbfirehose.SingleBlockReque
 		return nil, status.Errorf(codes.NotFound, "block %s not found", bstream.NewBlockRef(blockHash, blockNum))
 	}
 
-	resp := &pbfirehose.SingleBlockResponse{
+	return &pbfirehose.SingleBlockResponse{
 		Block: blk.Payload,
 		Metadata: &pbfirehose.BlockMetadata{
 			Id:        blk.Id,
@@ -65,21 +135,48 @@ func (s *Server) Block(ctx context.Context, request *pbfirehose.SingleBlockReque
 			LibNum:    blk.LibNum,
 			Time:      blk.Timestamp,
 		},
-	}
+	}, nil
+}
 
-	meter := dmetering.GetBytesMeter(ctx)
-	auth := dauth.FromContext(ctx)
-	metering.Send(ctx, meter, auth.UserID(), auth.APIKeyID(), auth.RealIP(), auth.Meta(), "sf.firehose.v2.Firehose/Block", resp)
+func singleBlockReference(request *pbfirehose.SingleBlockRequest) (blockNum uint64, blockHash string, err error) {
+	switch ref := request.Reference.(type) {
+	case *pbfirehose.SingleBlockRequest_BlockHashAndNumber_:
+		blockNum = ref.BlockHashAndNumber.Num
+		blockHash = ref.BlockHashAndNumber.Hash
+	case *pbfirehose.SingleBlockRequest_Cursor_:
+		cur, err := bstream.CursorFromOpaque(ref.Cursor.Cursor)
+		if err != nil {
+			return 0, "", status.Error(codes.InvalidArgument, err.Error())
+		}
+		blockNum = cur.Block.Num()
+		blockHash = cur.Block.ID()
+	case *pbfirehose.SingleBlockRequest_BlockNumber_:
+		blockNum = ref.BlockNumber.Num
+	}
 
-	return resp, nil
+	return blockNum, blockHash, nil
 }
 
-func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream_BlocksServer) error {
+func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream_BlocksServer) (err error) {
 	ctx := streamSrv.Context()
+	if target, err := s.routeChain(ctx); err != nil || target != s {
+		if err != nil {
+			return err
+		}
+		return target.Blocks(request, streamSrv)
+	}
+
 	metrics.RequestCounter.Inc()
 
 	logger := logging.Logger(ctx, s.logger)
 
+	accessRecord := newBlocksAccessRecord(request)
+	defer func() { s.logAccess(ctx, accessRecord, err) }()
+
+	if err := s.rejectIfDraining(); err != nil {
+		return err
+	}
+
 	if s.rateLimiter != nil {
 		rlCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 		defer cancel()
@@ -87,7 +184,7 @@ func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream
 		if allow := s.rateLimiter.Take(rlCtx, "", "Blocks"); !allow {
 			jitterDelay := time.Duration(rand.Intn(3000) + 1000) // force a minimal backoff
 			<-time.After(time.Millisecond * jitterDelay)
-			return status.Error(codes.Unavailable, "rate limit exceeded")
+			return statusError(codes.Unavailable, "RATE_LIMITED", "rate limit exceeded", "", time.Second)
 		} else {
 			defer s.rateLimiter.Return()
 		}
@@ -96,20 +193,95 @@ func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream
 	metrics.ActiveRequests.Inc()
 	defer metrics.ActiveRequests.Dec()
 
+	heartbeatInterval, err := heartbeatIntervalFromContext(ctx)
+	if err != nil {
+		return statusError(codes.InvalidArgument, "INVALID_HEARTBEAT_INTERVAL", err.Error(), "", 0)
+	}
+
+	checkpointInterval, err := checkpointIntervalFromContext(ctx)
+	if err != nil {
+		return statusError(codes.InvalidArgument, "INVALID_CHECKPOINT_INTERVAL", err.Error(), "", 0)
+	}
+
+	resumeToken := resumeTokenFromContext(ctx)
+	resumeUserID := dauth.FromContext(ctx).UserID()
+	if resumeToken != "" && request.Cursor == "" {
+		cursor, found := s.resumeTokens.Cursor(resumeToken, resumeUserID)
+		if !found {
+			return statusError(codes.InvalidArgument, "UNKNOWN_RESUME_TOKEN", fmt.Sprintf("resume token %q is unknown or expired", resumeToken), "", 0)
+		}
+
+		// A token with no cursor yet means no block was sent, we simply restart the original request
+		if cursor != "" {
+			request = proto.Clone(request).(*pbfirehose.Request)
+			request.Cursor = cursor
+		}
+		logger.Info("resuming stream from resume token", zap.String("resume_token", resumeToken), zap.String("cursor", cursor))
+	} else {
+		resumeToken = s.resumeTokens.NewToken(resumeUserID)
+	}
+
+	quotaTracker := s.quotaTracker(ctx)
+	if quotaTracker != nil {
+		var exhausted *quota.ExhaustedError
+		if err := quotaTracker.Check(); errors.As(err, &exhausted) {
+			return quotaExhaustedError(exhausted, "")
+		}
+	}
+
+	release, err := s.admit(ctx, request, streamSrv)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Held while a block is being sent so that draining only ends the stream between two blocks
+	var blockBoundary sync.Mutex
+	ctx, streamDone := s.drainableStream(ctx, &blockBoundary)
+	defer streamDone()
+
+	resumeEntry := s.resumeTokens.Track(resumeToken, request.Cursor)
+	defer s.resumeTokens.Release(resumeEntry)
+
+	header := headInfoMetadata(s.hub)
+	header.Set(ResumeTokenHeader, resumeToken)
 	if os.Getenv("FIREHOSE_SEND_HOSTNAME") != "" {
 		hostname, err := os.Hostname()
 		if err != nil {
 			hostname = "unknown"
 			logger.Warn("cannot determine hostname, using 'unknown'", zap.Error(err))
 		}
-		md := metadata.New(map[string]string{"hostname": hostname})
-		if err := streamSrv.SendHeader(md); err != nil {
-			logger.Warn("cannot send metadata header", zap.Error(err))
-		}
+		header.Set("hostname", hostname)
+	}
+	if err := streamSrv.SendHeader(header); err != nil {
+		logger.Warn("cannot send metadata header", zap.Error(err))
 	}
 
+	sender := newBlocksSender(streamSrv)
+	defer func() {
+		trailer := headInfoMetadata(s.hub)
+		if lastCursor, lastBlockNum := sender.LastBlock(); lastCursor != "" {
+			trailer.Set(LastCursorTrailer, lastCursor)
+			trailer.Set(LastBlockNumTrailer, strconv.FormatUint(lastBlockNum, 10))
+		}
+		streamSrv.SetTrailer(trailer)
+	}()
+
 	var blockCount uint64
 	handlerFunc := bstream.HandlerFunc(func(block *pbbstream.Block, obj interface{}) error {
+		blockBoundary.Lock()
+		defer blockBoundary.Unlock()
+
+		if isDrained(ctx) {
+			return errDraining
+		}
+
+		if quotaTracker != nil {
+			if err := quotaTracker.Check(); err != nil {
+				return err
+			}
+		}
+
 		blockCount++
 		cursorable := obj.(bstream.Cursorable)
 		cursor := cursorable.Cursor()
@@ -159,12 +331,14 @@ func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream
 			s.postHookFunc(ctx, resp)
 		}
 		start := time.Now()
-		err := streamSrv.Send(resp)
+		err := sender.SendBlock(resp)
 		if err != nil {
 			logger.Info("stream send error", zap.Uint64("block_num", block.Number), zap.String("block_id", block.Id), zap.Error(err))
 			return NewErrSendBlock(err)
 		}
 
+		resumeEntry.Record(resp.Cursor)
+
 		level := zap.DebugLevel
 		if block.Number%200 == 0 {
 			level = zap.InfoLevel
@@ -176,7 +350,7 @@ func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream
 	})
 
 	if len(request.Transforms) > 0 && s.transformRegistry == nil {
-		return status.Errorf(codes.Unimplemented, "no transforms registry configured within this instance")
+		return statusError(codes.Unimplemented, "TRANSFORMS_UNSUPPORTED", "no transforms registry configured within this instance", "", 0)
 	}
 
 	liveSourceMiddlewareHandler := func(next bstream.Handler) bstream.Handler {
@@ -210,6 +384,15 @@ func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream
 	}
 
 	ctx = s.initFunc(ctx, request)
+	if quotaTracker != nil {
+		ctx = metering.WithUsageObserver(ctx, quotaTracker.Record)
+	}
+	if s.meteringAggregationEnabled() {
+		aggregator := metering.NewAggregator(s.meteringAggregationWindow, s.meteringAggregationMaxBlocks)
+		ctx = metering.WithAggregator(ctx, aggregator)
+		defer aggregator.Flush()
+	}
+
 	str, err := s.streamFactory.New(
 		ctx,
 		handlerFunc,
@@ -222,8 +405,24 @@ func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream
 		return err
 	}
 
+	if heartbeatInterval > 0 || checkpointInterval > 0 {
+		heartbeatCtx, stopHeartbeats := context.WithCancel(ctx)
+		heartbeatsDone := make(chan struct{})
+		go func() {
+			sender.runHeartbeats(heartbeatCtx, s.hub, heartbeatInterval, checkpointInterval, logger)
+			close(heartbeatsDone)
+		}()
+
+		defer func() {
+			stopHeartbeats()
+			<-heartbeatsDone
+		}()
+	}
+
 	err = str.Run(ctx)
 	meter := getRequestMeter(ctx)
+	lastCursor, _ := sender.LastBlock()
+	accessRecord.LastCursor = lastCursor
 
 	fields := []zap.Field{
 		zap.Uint64("block_sent", meter.blocks),
@@ -246,35 +445,46 @@ func (s *Server) Blocks(request *pbfirehose.Request, streamSrv pbfirehose.Stream
 			return nil
 		}
 
+		if isDrained(ctx) {
+			logger.Info("stream of blocks ended because the server is draining", zap.String("last_cursor", lastCursor))
+			return statusError(codes.Unavailable, "DRAINING", "server is draining, reconnect to another instance from the last cursor", lastCursor, 0)
+		}
+
 		if errors.Is(err, context.Canceled) {
 			if ctx.Err() != context.Canceled {
 				logger.Debug("stream of blocks ended with context canceled, but our own context was not canceled", zap.Error(err))
 			}
-			return status.Error(codes.Canceled, "source canceled")
+			return statusError(codes.Canceled, "CANCELED", "source canceled", lastCursor, 0)
 		}
 
 		if errors.Is(err, context.DeadlineExceeded) {
 			logger.Info("stream of blocks ended with context deadline exceeded", zap.Error(err))
-			return status.Error(codes.DeadlineExceeded, "source deadline exceeded")
+			return statusError(codes.DeadlineExceeded, "DEADLINE_EXCEEDED", "source deadline exceeded", lastCursor, 0)
 		}
 
 		var errInvalidArg *stream.ErrInvalidArg
 		if errors.As(err, &errInvalidArg) {
-			return status.Error(codes.InvalidArgument, errInvalidArg.Error())
+			return statusError(codes.InvalidArgument, "INVALID_ARGUMENT", errInvalidArg.Error(), lastCursor, 0)
+		}
+
+		var errQuotaExhausted *quota.ExhaustedError
+		if errors.As(err, &errQuotaExhausted) {
+			logger.Info("stream of blocks ended because the caller quota is exhausted", zap.Error(err))
+			return quotaExhaustedError(errQuotaExhausted, lastCursor)
 		}
 
 		var errSendBlock *ErrSendBlock
 		if errors.As(err, &errSendBlock) {
 			logger.Info("unable to send block probably due to client disconnecting", zap.Error(errSendBlock.inner))
-			return status.Error(codes.Unavailable, errSendBlock.inner.Error())
+			return statusError(codes.Unavailable, "SEND_FAILED", errSendBlock.inner.Error(), lastCursor, 0)
 		}
 
 		logger.Info("unexpected stream of blocks termination", zap.Error(err))
-		return status.Errorf(codes.Internal, "unexpected stream termination")
+		return statusError(codes.Internal, "UNEXPECTED_TERMINATION", "unexpected stream termination", lastCursor, time.Second)
 	}
 
 	logger.Error("source is not expected to terminate gracefully, should stop at block or continue forever")
-	return status.Error(codes.Internal, "unexpected stream completion")
+	return statusError(codes.Internal, "UNEXPECTED_COMPLETION", "unexpected stream completion", lastCursor, time.Second)
 
 }
 
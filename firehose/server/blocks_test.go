@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/firehose-core/firehose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_Block_GenesisByHashAndNumber(t *testing.T) {
+	server := &Server{
+		blockGetter: firehose.NewBlockGetter(newTestMergedBlocksStore(t, "00000000a", "00000001a"), nil, nil, nil),
+		logger:      zap.NewNop(),
+		drainer:     newDrainer(),
+	}
+
+	resp, err := server.Block(context.Background(), blockHashAndNumberRequest(0, "00000000a"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), resp.Metadata.Num)
+	assert.Equal(t, "00000000a", resp.Metadata.Id)
+
+	_, err = server.Block(context.Background(), blockHashAndNumberRequest(0, "00000000b"))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// By hash alone, there is no block hash index nor forked blocks store to find it
+	byHash := metadata.NewIncomingContext(context.Background(), metadata.Pairs(BlockByHashHeader, "true"))
+	_, err = server.Block(byHash, blockHashAndNumberRequest(0, "00000000a"))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.Block(byHash, blockNumberRequest(0))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	invalid := metadata.NewIncomingContext(context.Background(), metadata.Pairs(BlockByHashHeader, "maybe"))
+	_, err = server.Block(invalid, blockHashAndNumberRequest(0, "00000000a"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
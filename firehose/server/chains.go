@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ChainHeader is the request header selecting which of the chains served by the process handles
+// the request, requests without it are handled by the server they reached.
+const ChainHeader = "x-firehose-chain"
+
+// WithChainRoutes routes the requests carrying the [ChainHeader] header to the server of the chain
+// it names, [name] is the chain handled by this server itself. The servers in [routes] usually have
+// no listen address of their own, but they can, in which case they also serve their chain directly.
+//
+// The servers in [routes] are drained along with this server, see [Server.Drain].
+func WithChainRoutes(name string, routes map[string]*Server) Option {
+	return func(s *Server) {
+		s.chainName = name
+		s.chainRoutes = routes
+
+		for routeName, route := range routes {
+			route.chainName = routeName
+			route.drainer = s.drainer
+		}
+	}
+}
+
+// routeChain returns the server handling the chain requested in [ctx], which is [s] itself when
+// no chain is requested.
+func (s *Server) routeChain(ctx context.Context) (*Server, error) {
+	requested := metadata.ValueFromIncomingContext(ctx, ChainHeader)
+	if len(requested) == 0 || requested[0] == "" || requested[0] == s.chainName {
+		return s, nil
+	}
+
+	if target, found := s.chainRoutes[requested[0]]; found {
+		return target, nil
+	}
+
+	return nil, statusError(codes.NotFound, "UNKNOWN_CHAIN", fmt.Sprintf("chain %q is not served by this endpoint, served chains are %s", requested[0], strings.Join(s.servedChains(), ", ")), "", 0)
+}
+
+func (s *Server) servedChains() []string {
+	var chains []string
+	if s.chainName != "" {
+		chains = append(chains, s.chainName)
+	}
+	for name := range s.chainRoutes {
+		chains = append(chains, name)
+	}
+
+	sort.Strings(chains)
+	return chains
+}
+
+// Info returns the endpoint information of the requested chain, along with its live info in
+// response headers when known (see [WithLiveInfo]).
+func (s *Server) Info(ctx context.Context, request *pbfirehose.InfoRequest) (*pbfirehose.InfoResponse, error) {
+	target, err := s.routeChain(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.infoServer == nil {
+		return nil, status.Error(codes.Unimplemented, "info not available on this endpoint")
+	}
+
+	resp, err := target.infoServer.Info(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fails outside of a gRPC call (Connect and HTTP gateway), those set the headers themselves
+	if md := s.liveInfoMetadata(ctx); md.Len() > 0 {
+		grpc.SetHeader(ctx, md)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_RouteChain(t *testing.T) {
+	other := &Server{logger: zap.NewNop(), drainer: newDrainer()}
+	main := &Server{logger: zap.NewNop(), drainer: newDrainer()}
+	WithChainRoutes("main", map[string]*Server{"other": other})(main)
+
+	withChain := func(chain string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(ChainHeader, chain))
+	}
+
+	target, err := main.routeChain(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, main, target)
+
+	target, err = main.routeChain(withChain("main"))
+	require.NoError(t, err)
+	assert.Same(t, main, target)
+
+	target, err = main.routeChain(withChain("other"))
+	require.NoError(t, err)
+	assert.Same(t, other, target)
+
+	_, err = main.routeChain(withChain("unknown"))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, `chain "unknown" is not served by this endpoint, served chains are main, other`, status.Convert(err).Message())
+
+	// Requests routed to a chain are handled by its server, which has no info server here
+	_, err = main.Info(withChain("other"), &pbfirehose.InfoRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// Routed chains are drained along with the main one
+	main.Drain(time.Second)
+	assert.True(t, other.IsDraining())
+
+	_, err = main.Block(withChain("other"), &pbfirehose.SingleBlockRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
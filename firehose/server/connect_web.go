@@ -0,0 +1,372 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
+	"connectrpc.com/grpcreflect"
+	"connectrpc.com/otelconnect"
+	dgrpcserver "github.com/streamingfast/dgrpc/server"
+	connectweb "github.com/streamingfast/dgrpc/server/connectrpc"
+	"github.com/streamingfast/dgrpc/server/tracelog"
+	pbbatchfetch "github.com/streamingfast/firehose-core/pb/sf/firehose/batchfetch/v1"
+	"github.com/streamingfast/firehose-core/pb/sf/firehose/batchfetch/v1/pbbatchfetchconnect"
+	pbfirehoseV1 "github.com/streamingfast/pbgo/sf/firehose/v1"
+	pbfirehoseV2 "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/streamingfast/shutter"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// WithConnectWeb serves the Firehose services through a Connect server instead of a native gRPC one,
+// which speaks gRPC, gRPC-Web and Connect on the same listen address(es) so that web clients can
+// consume Firehose directly, without a proxy translating gRPC-Web. Service discovery is not supported
+// by the Connect server.
+func WithConnectWeb() Option {
+	return func(s *Server) {
+		s.connectWeb = true
+	}
+}
+
+// connectWebServer serves the Connect handlers with the lifecycle of the native gRPC servers. It's
+// configured through the same dgrpc options as dgrpc's own Connect server but owns its HTTP server so
+// that shutting down lets in-flight calls complete for the grace period instead of cutting them.
+type connectWebServer struct {
+	*shutter.Shutter
+	logger    *zap.Logger
+	handler   http.Handler
+	tlsConfig *tls.Config
+
+	mu           sync.Mutex
+	httpServer   *http.Server
+	shuttingDown bool
+}
+
+func newConnectWebServer(handlerGetters []connectweb.HandlerGetter, tracerProvider trace.TracerProvider, opts ...dgrpcserver.Option) (*connectWebServer, error) {
+	options := dgrpcserver.NewOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	otelInterceptor, err := otelconnect.NewInterceptor(otelconnect.WithTracerProvider(tracerProvider))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create otel interceptor: %w", err)
+	}
+
+	interceptors := append([]connect.Interceptor{
+		otelInterceptor,
+		tracelog.NewConnectLoggingInterceptor(options.Logger),
+	}, options.ConnectExtraInterceptors...)
+	if options.ConnectWebStrictContentType {
+		interceptors = append(interceptors, connectweb.ContentTypeInterceptor{})
+	}
+
+	handlerOptions := []connect.HandlerOption{
+		connect.WithInterceptors(interceptors...),
+		connect.WithReadMaxBytes(maxRecvMsgSize),
+	}
+
+	mux := http.NewServeMux()
+	for _, getter := range handlerGetters {
+		mux.Handle(getter(handlerOptions...))
+	}
+
+	if len(options.ConnectWebReflectionServices) != 0 {
+		reflector := grpcreflect.NewStaticReflector(options.ConnectWebReflectionServices...)
+		mux.Handle(grpcreflect.NewHandlerV1(reflector))
+		mux.Handle(grpcreflect.NewHandlerV1Alpha(reflector))
+	}
+
+	if options.HealthCheck != nil {
+		healthCheck := connectHealthCheckHandler(options.HealthCheck)
+		mux.Handle("/{$}", healthCheck)
+		mux.Handle("/healthz", healthCheck)
+		mux.Handle(grpchealth.NewHandler(connectHealthChecker{check: options.HealthCheck}))
+	}
+
+	var handler http.Handler = mux
+	if options.ConnectWebCORS != nil {
+		handler = options.ConnectWebCORS.Handler(mux)
+	}
+
+	return &connectWebServer{
+		Shutter:   shutter.New(),
+		logger:    options.Logger,
+		handler:   h2c.NewHandler(handler, &http2.Server{MaxConcurrentStreams: 1000}),
+		tlsConfig: options.SecureTLSConfig,
+	}, nil
+}
+
+// Launch serves until the server is shut down, it should be run in a goroutine.
+func (s *connectWebServer) Launch(serverListenerAddress string) {
+	s.logger.Info("launching connect server", zap.String("listen_addr", serverListenerAddress))
+	listener, err := net.Listen("tcp", serverListenerAddress)
+	if err != nil {
+		s.Shutter.Shutdown(fmt.Errorf("tcp listening to %q: %w", serverListenerAddress, err))
+		return
+	}
+
+	s.serve(listener)
+}
+
+func (s *connectWebServer) serve(listener net.Listener) {
+	errorLogger, err := zap.NewStdLogAt(s.logger, zap.ErrorLevel)
+	if err != nil {
+		s.Shutter.Shutdown(fmt.Errorf("unable to create logger: %w", err))
+		return
+	}
+
+	httpServer := &http.Server{
+		Handler:   s.handler,
+		ErrorLog:  errorLogger,
+		TLSConfig: s.tlsConfig,
+	}
+
+	s.mu.Lock()
+	if s.shuttingDown {
+		s.mu.Unlock()
+		listener.Close()
+		<-s.Terminated()
+		return
+	}
+	s.httpServer = httpServer
+	s.mu.Unlock()
+
+	if s.tlsConfig != nil {
+		err = httpServer.ServeTLS(listener, "", "")
+	} else {
+		err = httpServer.Serve(listener)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		// Serve returns as soon as the shutdown starts, in-flight calls are still being drained
+		<-s.Terminated()
+		return
+	}
+
+	s.Shutter.Shutdown(fmt.Errorf("connect server serve failed: %w", err))
+}
+
+// Shutdown stops accepting new calls and waits up to [timeout] for in-flight ones to complete
+// before closing the remaining connections. Only the first call has an effect.
+func (s *connectWebServer) Shutdown(timeout time.Duration) {
+	s.mu.Lock()
+	if s.shuttingDown {
+		s.mu.Unlock()
+		return
+	}
+	s.shuttingDown = true
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	if httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := httpServer.Shutdown(ctx); err != nil {
+			s.logger.Info("connect server in-flight calls did not complete within the grace period, closing them", zap.Duration("grace_period", timeout))
+			httpServer.Close()
+		}
+	}
+
+	s.Shutter.Shutdown(nil)
+}
+
+func connectHealthCheckHandler(check dgrpcserver.HealthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isReady, _, err := check(r.Context())
+		if err != nil {
+			isReady = false
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !isReady {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(map[string]bool{"is_ready": isReady})
+	})
+}
+
+// connectHandlerGetters returns the Connect handlers of the services registered on the native gRPC servers.
+func (s *Server) connectHandlerGetters() []connectweb.HandlerGetter {
+	getters := []connectweb.HandlerGetter{
+		func(opts ...connect.HandlerOption) (string, http.Handler) {
+			return pbfirehoseV2.Stream_Blocks_FullMethodName, connect.NewServerStreamHandler(pbfirehoseV2.Stream_Blocks_FullMethodName, s.connectBlocks, opts...)
+		},
+		func(opts ...connect.HandlerOption) (string, http.Handler) {
+			return pbfirehoseV1.Stream_Blocks_FullMethodName, connect.NewServerStreamHandler(pbfirehoseV1.Stream_Blocks_FullMethodName, s.connectBlocksV1, opts...)
+		},
+	}
+
+	if s.blockGetter != nil {
+		getters = append(getters,
+			func(opts ...connect.HandlerOption) (string, http.Handler) {
+				return pbfirehoseV2.Fetch_Block_FullMethodName, connect.NewUnaryHandler(pbfirehoseV2.Fetch_Block_FullMethodName, s.connectBlock, opts...)
+			},
+			func(opts ...connect.HandlerOption) (string, http.Handler) {
+				return pbbatchfetchconnect.BatchFetchBlocksProcedure, connect.NewBidiStreamHandler(pbbatchfetchconnect.BatchFetchBlocksProcedure, s.connectBatchFetch, opts...)
+			},
+		)
+	}
+
+	if s.infoServer != nil {
+		getters = append(getters, func(opts ...connect.HandlerOption) (string, http.Handler) {
+			return pbfirehoseV2.EndpointInfo_Info_FullMethodName, connect.NewUnaryHandler(pbfirehoseV2.EndpointInfo_Info_FullMethodName, s.connectInfo, opts...)
+		})
+	}
+
+	return getters
+}
+
+func (s *Server) connectBlocks(ctx context.Context, req *connect.Request[pbfirehoseV2.Request], stream *connect.ServerStream[pbfirehoseV2.Response]) error {
+	return toConnectError(s.Blocks(req.Msg, newConnectServerStream(ctx, req.Header(), stream)))
+}
+
+func (s *Server) connectBlocksV1(ctx context.Context, req *connect.Request[pbfirehoseV1.Request], stream *connect.ServerStream[pbfirehoseV1.Response]) error {
+	return toConnectError(NewFirehoseProxyV1ToV2(s).Blocks(req.Msg, newConnectServerStream(ctx, req.Header(), stream)))
+}
+
+func (s *Server) connectBlock(ctx context.Context, req *connect.Request[pbfirehoseV2.SingleBlockRequest]) (*connect.Response[pbfirehoseV2.SingleBlockResponse], error) {
+	resp, err := s.Block(incomingContext(ctx, req.Header()), req.Msg)
+	if err != nil {
+		return nil, toConnectError(err)
+	}
+
+	return connect.NewResponse(resp), nil
+}
+
+func (s *Server) connectBatchFetch(ctx context.Context, stream *connect.BidiStream[pbbatchfetch.Request, pbbatchfetch.Response]) error {
+	return toConnectError((&batchFetchServer{server: s}).Blocks(&connectBatchFetchStream{
+		connectServerStream: newConnectServerStream(ctx, stream.RequestHeader(), stream),
+		stream:              stream,
+	}))
+}
+
+func (s *Server) connectInfo(ctx context.Context, req *connect.Request[pbfirehoseV2.InfoRequest]) (*connect.Response[pbfirehoseV2.InfoResponse], error) {
+	ctx = incomingContext(ctx, req.Header())
+	resp, err := s.Info(ctx, req.Msg)
+	if err != nil {
+		return nil, toConnectError(err)
+	}
+
+	out := connect.NewResponse(resp)
+	appendMetadata(out.Header(), s.liveInfoMetadata(ctx))
+	return out, nil
+}
+
+// connectStream is the subset of Connect's server and bidi streams needed to serve a gRPC server stream.
+type connectStream[Res any] interface {
+	ResponseHeader() http.Header
+	ResponseTrailer() http.Header
+	Send(*Res) error
+}
+
+// connectServerStream adapts a Connect stream into a gRPC server stream, request headers are
+// forwarded as incoming gRPC metadata and gRPC headers and trailers are written to the Connect
+// response headers and trailers.
+type connectServerStream[Res any] struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	stream connectStream[Res]
+}
+
+func newConnectServerStream[Res any](ctx context.Context, requestHeader http.Header, stream connectStream[Res]) *connectServerStream[Res] {
+	return &connectServerStream[Res]{
+		ctx:    incomingContext(ctx, requestHeader),
+		stream: stream,
+	}
+}
+
+func (s *connectServerStream[Res]) Context() context.Context {
+	return s.ctx
+}
+
+func (s *connectServerStream[Res]) SetHeader(md metadata.MD) error {
+	appendMetadata(s.stream.ResponseHeader(), md)
+	return nil
+}
+
+func (s *connectServerStream[Res]) SendHeader(md metadata.MD) error {
+	appendMetadata(s.stream.ResponseHeader(), md)
+
+	// Sending a nil message only flushes the headers
+	return s.stream.Send(nil)
+}
+
+func (s *connectServerStream[Res]) SetTrailer(md metadata.MD) {
+	appendMetadata(s.stream.ResponseTrailer(), md)
+}
+
+func (s *connectServerStream[Res]) Send(resp *Res) error {
+	return s.stream.Send(resp)
+}
+
+type connectBatchFetchStream struct {
+	*connectServerStream[pbbatchfetch.Response]
+
+	stream *connect.BidiStream[pbbatchfetch.Request, pbbatchfetch.Response]
+}
+
+func (s *connectBatchFetchStream) Recv() (*pbbatchfetch.Request, error) {
+	return s.stream.Receive()
+}
+
+func appendMetadata(headers http.Header, md metadata.MD) {
+	for key, values := range md {
+		for _, value := range values {
+			headers.Add(key, value)
+		}
+	}
+}
+
+// toConnectError converts the gRPC status errors returned by the server into Connect errors, keeping
+// their details. Other errors are returned as-is and handled by Connect.
+func toConnectError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	connectErr := connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+	for _, detail := range st.Proto().Details {
+		if errorDetail, err := connect.NewErrorDetail(detail); err == nil {
+			connectErr.AddDetail(errorDetail)
+		}
+	}
+
+	return connectErr
+}
+
+// connectHealthChecker answers gRPC health checks from the same readiness check as `/healthz` and
+// the native gRPC servers, whatever the service asked for.
+type connectHealthChecker struct {
+	check dgrpcserver.HealthCheck
+}
+
+func (c connectHealthChecker) Check(ctx context.Context, _ *grpchealth.CheckRequest) (*grpchealth.CheckResponse, error) {
+	isReady, _, err := c.check(ctx)
+	if err != nil || !isReady {
+		return &grpchealth.CheckResponse{Status: grpchealth.StatusNotServing}, nil
+	}
+
+	return &grpchealth.CheckResponse{Status: grpchealth.StatusServing}, nil
+}
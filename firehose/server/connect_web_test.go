@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
+	dgrpcserver "github.com/streamingfast/dgrpc/server"
+	connectweb "github.com/streamingfast/dgrpc/server/connectrpc"
+	"github.com/streamingfast/firehose-core/firehose"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+func TestConnectWeb_Block(t *testing.T) {
+	server := &Server{
+		blockGetter: firehose.NewBlockGetter(newTestMergedBlocksStore(t, "00000001a", "00000002a"), nil, nil, nil),
+		logger:      zap.NewNop(),
+	}
+
+	mux := http.NewServeMux()
+	for _, getter := range server.connectHandlerGetters() {
+		mux.Handle(getter())
+	}
+
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+
+	for name, protocol := range map[string][]connect.ClientOption{"grpc-web": {connect.WithGRPCWeb()}, "connect": nil} {
+		t.Run(name, func(t *testing.T) {
+			client := connect.NewClient[pbfirehose.SingleBlockRequest, pbfirehose.SingleBlockResponse](httpServer.Client(), httpServer.URL+pbfirehose.Fetch_Block_FullMethodName, protocol...)
+
+			resp, err := client.CallUnary(context.Background(), connect.NewRequest(blockNumberRequest(2)))
+			require.NoError(t, err)
+			assert.Equal(t, uint64(2), resp.Msg.Metadata.Num)
+			assert.Equal(t, "00000002a", resp.Msg.Metadata.Id)
+
+			_, err = client.CallUnary(context.Background(), connect.NewRequest(blockNumberRequest(3)))
+			assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+		})
+	}
+}
+
+func TestConnectWebServer_ShutdownDrainsInFlightCalls(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	getter := func(opts ...connect.HandlerOption) (string, http.Handler) {
+		return "/test.Service/Info", connect.NewUnaryHandler("/test.Service/Info", func(ctx context.Context, req *connect.Request[pbfirehose.InfoRequest]) (*connect.Response[pbfirehose.InfoResponse], error) {
+			close(started)
+			<-release
+			return connect.NewResponse(&pbfirehose.InfoResponse{ChainName: "test"}), nil
+		}, opts...)
+	}
+
+	srv, err := newConnectWebServer([]connectweb.HandlerGetter{getter}, noop.NewTracerProvider(), dgrpcserver.WithLogger(zap.NewNop()), dgrpcserver.WithPlainTextServer())
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.serve(listener)
+
+	client := connect.NewClient[pbfirehose.InfoRequest, pbfirehose.InfoResponse](http.DefaultClient, "http://"+listener.Addr().String()+"/test.Service/Info")
+	type result struct {
+		resp *connect.Response[pbfirehose.InfoResponse]
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := client.CallUnary(context.Background(), connect.NewRequest(&pbfirehose.InfoRequest{}))
+		done <- result{resp, err}
+	}()
+	<-started
+
+	go srv.Shutdown(5 * time.Second)
+	select {
+	case <-srv.Terminated():
+		t.Fatal("server terminated while a call was in-flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	res := <-done
+	require.NoError(t, res.err)
+	assert.Equal(t, "test", res.resp.Msg.ChainName)
+
+	select {
+	case <-srv.Terminated():
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not terminate once in-flight calls completed")
+	}
+}
+
+func TestConnectHealthChecker(t *testing.T) {
+	ready := atomic.NewBool(false)
+	checker := connectHealthChecker{check: createHealthCheck(func(context.Context) bool { return ready.Load() })}
+
+	resp, err := checker.Check(context.Background(), &grpchealth.CheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpchealth.StatusNotServing, resp.Status)
+
+	ready.Store(true)
+	resp, err = checker.Check(context.Background(), &grpchealth.CheckRequest{Service: pbfirehose.Stream_ServiceDesc.ServiceName})
+	require.NoError(t, err)
+	assert.Equal(t, grpchealth.StatusServing, resp.Status)
+}
+
+func TestToConnectError(t *testing.T) {
+	assert.NoError(t, toConnectError(nil))
+
+	plain := errors.New("plain")
+	assert.Equal(t, plain, toConnectError(plain))
+
+	err := toConnectError(statusError(codes.Unavailable, "rate_limited", "rate limit exceeded", "cursor", time.Second))
+
+	var connectErr *connect.Error
+	require.True(t, errors.As(err, &connectErr))
+	assert.Equal(t, connect.CodeUnavailable, connectErr.Code())
+	assert.Equal(t, "rate limit exceeded", connectErr.Message())
+	require.Len(t, connectErr.Details(), 2)
+
+	detail, err := connectErr.Details()[0].Value()
+	require.NoError(t, err)
+	require.IsType(t, &errdetails.ErrorInfo{}, detail)
+	assert.Equal(t, "rate_limited", detail.(*errdetails.ErrorInfo).Reason)
+	assert.Equal(t, "cursor", detail.(*errdetails.ErrorInfo).Metadata["last_cursor"])
+}
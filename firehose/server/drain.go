@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+// errDraining is the cause of the cancellation of the streams ended because the server is draining.
+var errDraining = errors.New("server is draining")
+
+// drainer tracks the active streams and ends them, between two blocks, once draining starts.
+type drainer struct {
+	once     sync.Once
+	draining chan struct{}
+
+	activeStreams atomic.Int64
+}
+
+func newDrainer() *drainer {
+	return &drainer{draining: make(chan struct{})}
+}
+
+func (d *drainer) isDraining() bool {
+	if d == nil {
+		return false
+	}
+
+	select {
+	case <-d.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// Drain stops accepting new requests and ends active streams at their next block boundary with an
+// `Unavailable` status carrying their last cursor (in the `x-firehose-last-cursor` trailer and the
+// `google.rpc.ErrorInfo` detail), so that clients reconnect to another instance without processing
+// any block twice. It then waits up to [timeout] for active streams to end.
+//
+// The health check reports the server as not ready as soon as draining starts.
+func (s *Server) Drain(timeout time.Duration) {
+	s.startDrain()
+
+	deadline := time.Now().Add(timeout)
+	for s.drainer.activeStreams.Load() > 0 {
+		if time.Now().After(deadline) {
+			s.logger.Warn("drain timeout reached with streams still active", zap.Int64("active_streams", s.drainer.activeStreams.Load()))
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	s.logger.Info("firehose server drained")
+}
+
+func (s *Server) startDrain() {
+	s.drainer.once.Do(func() {
+		s.logger.Info("draining firehose server", zap.Int64("active_streams", s.drainer.activeStreams.Load()))
+		close(s.drainer.draining)
+	})
+}
+
+// IsDraining returns true once [Server.Drain] has been called.
+func (s *Server) IsDraining() bool {
+	return s.drainer.isDraining()
+}
+
+// rejectIfDraining returns the status sent to new requests received while draining.
+func (s *Server) rejectIfDraining() error {
+	if !s.drainer.isDraining() {
+		return nil
+	}
+
+	return statusError(codes.Unavailable, "DRAINING", "server is draining, connect to another instance", "", 0)
+}
+
+// drainableStream returns a context canceled with [errDraining] as cause when draining starts, the
+// cancellation waits for [blockBoundary] so that it never happens while a block is being sent. The
+// returned func must be called when the stream ends.
+func (s *Server) drainableStream(ctx context.Context, blockBoundary *sync.Mutex) (context.Context, func()) {
+	s.drainer.activeStreams.Inc()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-s.drainer.draining:
+			blockBoundary.Lock()
+			cancel(errDraining)
+			blockBoundary.Unlock()
+		case <-ctx.Done():
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		close(done)
+		cancel(nil)
+		s.drainer.activeStreams.Dec()
+	}
+}
+
+func isDrained(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errDraining)
+}
+
+// WithAdminListenAddr serves the admin HTTP endpoints on [listenAddr]:
+//
+//   - `POST /drain`: starts draining the server (see [Server.Drain]) and returns right away
+//   - `GET /drain`: returns whether the server is draining and its number of active streams
+//
+// Those endpoints are only served on [listenAddr], never on the public gRPC or HTTP gateway listeners.
+// When [authToken] is set, they require an `Authorization: Bearer <authToken>` header, without it
+// they are not authenticated and [listenAddr] must not be publicly reachable.
+func WithAdminListenAddr(listenAddr string, authToken string) Option {
+	return func(s *Server) {
+		if listenAddr != "" {
+			s.httpServers = append(s.httpServers, newManagedHTTPServer("firehose admin", listenAddr, adminAuth(authToken, s.adminHandler()), s.logger))
+		}
+	}
+}
+
+// adminAuth requires [token] as bearer token on every request to [next], it's a no-op when [token]
+// is empty.
+func adminAuth(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	expected := sha256.Sum256([]byte(token))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		candidate, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		hash := sha256.Sum256([]byte(candidate))
+		if !found || subtle.ConstantTimeCompare(hash[:], expected[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="firehose admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /drain", func(w http.ResponseWriter, r *http.Request) {
+		s.startDrain()
+		s.writeDrainStatus(w, http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /drain", func(w http.ResponseWriter, r *http.Request) {
+		s.writeDrainStatus(w, http.StatusOK)
+	})
+
+	return mux
+}
+
+type drainStatus struct {
+	Draining      bool  `json:"draining"`
+	ActiveStreams int64 `json:"active_streams"`
+}
+
+func (s *Server) writeDrainStatus(w http.ResponseWriter, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(&drainStatus{
+		Draining:      s.drainer.isDraining(),
+		ActiveStreams: s.drainer.activeStreams.Load(),
+	})
+}
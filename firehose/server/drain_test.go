@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_Drain(t *testing.T) {
+	server := &Server{logger: zap.NewNop(), drainer: newDrainer()}
+	require.NoError(t, server.rejectIfDraining())
+
+	var blockBoundary sync.Mutex
+	ctx, streamDone := server.drainableStream(context.Background(), &blockBoundary)
+
+	// A block is being sent, the stream must not be ended before it's done
+	blockBoundary.Lock()
+
+	drained := make(chan struct{})
+	go func() {
+		server.Drain(time.Second)
+		close(drained)
+	}()
+
+	require.Eventually(t, server.IsDraining, time.Second, 5*time.Millisecond)
+	assert.Equal(t, codes.Unavailable, status.Code(server.rejectIfDraining()))
+	assert.NoError(t, ctx.Err(), "stream ended while sending a block")
+
+	blockBoundary.Unlock()
+	<-ctx.Done()
+	assert.True(t, isDrained(ctx))
+
+	select {
+	case <-drained:
+		t.Fatal("drain returned while a stream is still active")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	streamDone()
+
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("drain did not return once streams ended")
+	}
+}
+
+func TestServer_AdminHandler(t *testing.T) {
+	server := &Server{logger: zap.NewNop(), drainer: newDrainer()}
+	handler := server.adminHandler()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/drain", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"draining":false,"active_streams":0}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/drain", nil))
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.JSONEq(t, `{"draining":true,"active_streams":0}`, recorder.Body.String())
+	assert.True(t, server.IsDraining())
+}
+
+func TestAdminAuth(t *testing.T) {
+	handler := adminAuth("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for header, expected := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer other":  http.StatusUnauthorized,
+		"Basic secret":  http.StatusUnauthorized,
+		"Bearer secret": http.StatusOK,
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/drain", nil)
+		if header != "" {
+			request.Header.Set("Authorization", header)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, expected, recorder.Code, header)
+	}
+}
@@ -2,6 +2,14 @@ package server
 
 import (
 	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type ErrSendBlock struct {
@@ -17,3 +25,56 @@ func NewErrSendBlock(inner error) ErrSendBlock {
 func (e ErrSendBlock) Error() string {
 	return fmt.Sprintf("send error: %s", e.inner)
 }
+
+// ErrorInfoDomain is the `domain` set on the `google.rpc.ErrorInfo` detail attached to every
+// status returned by [Server.Blocks] and [Server.Block].
+const ErrorInfoDomain = "firehose.streamingfast.io"
+
+// statusError builds a gRPC status error carrying a `google.rpc.ErrorInfo` detail with [reason] and,
+// when known, the last cursor sent to the client. Retryable codes additionally carry a
+// `google.rpc.RetryInfo` detail with the suggested [retryDelay].
+//
+// Retryable codes are:
+//   - Unavailable: send error (client probably disconnected) or rate limit exceeded, reconnect from the last cursor
+//   - Internal: unexpected stream termination, reconnect from the last cursor
+//   - DeadlineExceeded: the request's deadline was reached, reconnect from the last cursor
+//   - Aborted: the server is going away, reconnect (possibly to another instance) from the last cursor
+//   - ResourceExhausted: the server is overloaded, retry (possibly on another instance) after the suggested delay,
+//     or the caller's usage quota is exhausted (`QUOTA_EXHAUSTED` reason), retry once it resets after the suggested delay
+//
+// Non retryable codes are: InvalidArgument, NotFound, Unimplemented, Canceled, PermissionDenied and
+// Unauthenticated. Retrying those without changing the request yields the same result.
+func statusError(code codes.Code, reason string, message string, lastCursor string, retryDelay time.Duration) error {
+	st := status.New(code, message)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   ErrorInfoDomain,
+		Metadata: map[string]string{"retryable": strconv.FormatBool(isRetryableCode(code))},
+	}
+	if lastCursor != "" {
+		info.Metadata["last_cursor"] = lastCursor
+	}
+
+	details := []protoadapt.MessageV1{info}
+	if isRetryableCode(code) {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)})
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		// Only happens if details cannot be marshalled, still return the bare status in this case
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+func isRetryableCode(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.Internal, codes.DeadlineExceeded, codes.Aborted, codes.ResourceExhausted:
+		return true
+	}
+
+	return false
+}
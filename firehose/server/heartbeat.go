@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/bstream/hub"
+	pbheartbeat "github.com/streamingfast/firehose-core/pb/sf/firehose/heartbeat/v1"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// HeartbeatIntervalHeader is the request header a client sends to opt-in receiving heartbeat
+// messages when the stream has been idle for the given interval (parsed as a Go duration, e.g. `30s`).
+//
+// An heartbeat is a [pbfirehose.Response] with step `STEP_UNSET`, the last cursor sent on the stream
+// (empty if no block was sent yet), no metadata and a [pbheartbeat.Heartbeat] packed in its `block`
+// field carrying the current head information (when live is enabled). Clients not handling `STEP_UNSET`
+// should simply skip it like any unknown step.
+const HeartbeatIntervalHeader = "x-firehose-heartbeat-interval"
+
+// CheckpointIntervalHeader is the request header a client sends to opt-in receiving an heartbeat
+// every given interval (parsed as a Go duration, e.g. `1m`), whether or not blocks are flowing, to
+// keep track of the chain's head and of its last cursor while the stream runs. It can be combined
+// with [HeartbeatIntervalHeader], a checkpoint also counts as activity for the idle interval.
+const CheckpointIntervalHeader = "x-firehose-checkpoint-interval"
+
+const minHeartbeatInterval = 5 * time.Second
+
+func heartbeatIntervalFromContext(ctx context.Context) (time.Duration, error) {
+	return intervalFromContext(ctx, HeartbeatIntervalHeader)
+}
+
+func checkpointIntervalFromContext(ctx context.Context) (time.Duration, error) {
+	return intervalFromContext(ctx, CheckpointIntervalHeader)
+}
+
+func intervalFromContext(ctx context.Context, header string) (time.Duration, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, nil
+	}
+
+	values := md.Get(header)
+	if len(values) == 0 || values[0] == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(values[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s header value %q: %w", header, values[0], err)
+	}
+
+	if interval < minHeartbeatInterval {
+		return 0, fmt.Errorf("invalid %s header value %q: must be at least %s", header, values[0], minHeartbeatInterval)
+	}
+
+	return interval, nil
+}
+
+// blocksSender serializes sends on the underlying stream, which is not safe for concurrent use,
+// so that heartbeats can be emitted while blocks are flowing. It also keeps track of the last
+// block sent.
+type blocksSender struct {
+	sync.Mutex
+
+	stream          pbfirehose.Stream_BlocksServer
+	lastSentAt      time.Time
+	lastHeartbeatAt time.Time
+	lastCursor      string
+	lastBlockNum    uint64
+}
+
+func newBlocksSender(stream pbfirehose.Stream_BlocksServer) *blocksSender {
+	now := time.Now()
+	return &blocksSender{
+		stream:          stream,
+		lastSentAt:      now,
+		lastHeartbeatAt: now,
+	}
+}
+
+func (s *blocksSender) SendBlock(resp *pbfirehose.Response) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.stream.Send(resp); err != nil {
+		return err
+	}
+
+	s.lastSentAt = time.Now()
+	s.lastCursor = resp.Cursor
+	s.lastBlockNum = resp.Metadata.GetNum()
+
+	return nil
+}
+
+func (s *blocksSender) LastBlock() (cursor string, blockNum uint64) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.lastCursor, s.lastBlockNum
+}
+
+// sendHeartbeatIfDue sends an heartbeat if nothing was sent for at least [idleInterval] or if no
+// heartbeat was sent for at least [checkpointInterval], a zero interval disables its condition.
+func (s *blocksSender) sendHeartbeatIfDue(forkableHub *hub.ForkableHub, idleInterval, checkpointInterval time.Duration) (sent bool, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	idle := idleInterval > 0 && time.Since(s.lastSentAt) >= idleInterval
+	checkpoint := checkpointInterval > 0 && time.Since(s.lastHeartbeatAt) >= checkpointInterval
+	if !idle && !checkpoint {
+		return false, nil
+	}
+
+	heartbeat, err := newHeartbeat(forkableHub, s.lastCursor)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.stream.Send(heartbeat); err != nil {
+		return false, err
+	}
+
+	s.lastSentAt = time.Now()
+	s.lastHeartbeatAt = s.lastSentAt
+	return true, nil
+}
+
+// newHeartbeat leaves the response's metadata empty, it describes the block of the response and an
+// heartbeat has none, the head information goes in the packed [pbheartbeat.Heartbeat].
+func newHeartbeat(forkableHub *hub.ForkableHub, lastCursor string) (*pbfirehose.Response, error) {
+	heartbeat := &pbheartbeat.Heartbeat{}
+	if forkableHub != nil {
+		if headNum, headID, headTime, libNum, err := forkableHub.HeadInfo(); err == nil {
+			heartbeat.Head = &pbheartbeat.Head{
+				BlockNum:  headNum,
+				BlockId:   headID,
+				BlockTime: timestamppb.New(headTime),
+				LibNum:    libNum,
+			}
+		}
+	}
+
+	payload, err := anypb.New(heartbeat)
+	if err != nil {
+		return nil, fmt.Errorf("packing heartbeat: %w", err)
+	}
+
+	return &pbfirehose.Response{
+		Step:   pbfirehose.ForkStep_STEP_UNSET,
+		Cursor: lastCursor,
+		Block:  payload,
+	}, nil
+}
+
+// runHeartbeats blocks until [ctx] is done, emitting an heartbeat each time the stream has been idle
+// for [idleInterval] and every [checkpointInterval], a zero interval disables its heartbeats.
+func (s *blocksSender) runHeartbeats(ctx context.Context, forkableHub *hub.ForkableHub, idleInterval, checkpointInterval time.Duration, logger *zap.Logger) {
+	// Checking at a fraction of the interval keeps the period close to the requested interval
+	checkEvery := idleInterval
+	if checkEvery == 0 || (checkpointInterval > 0 && checkpointInterval < checkEvery) {
+		checkEvery = checkpointInterval
+	}
+
+	ticker := time.NewTicker(checkEvery / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := s.sendHeartbeatIfDue(forkableHub, idleInterval, checkpointInterval)
+			if err != nil {
+				// The stream's next block send fails the same way and terminates the stream
+				logger.Debug("unable to send heartbeat", zap.Error(err))
+				return
+			}
+
+			if sent {
+				logger.Debug("stream sent heartbeat")
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pbheartbeat "github.com/streamingfast/firehose-core/pb/sf/firehose/heartbeat/v1"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHeartbeatIntervalFromContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		expected    time.Duration
+		expectedErr string
+	}{
+		{"no header", "", 0, ""},
+		{"valid", "30s", 30 * time.Second, ""},
+		{"invalid", "abc", 0, `invalid x-firehose-heartbeat-interval header value "abc": time: invalid duration "abc"`},
+		{"too small", "1s", 0, `invalid x-firehose-heartbeat-interval header value "1s": must be at least 5s`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(HeartbeatIntervalHeader, tt.header))
+			}
+
+			interval, err := heartbeatIntervalFromContext(ctx)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, interval)
+		})
+	}
+}
+
+func TestBlocksSender_Heartbeat(t *testing.T) {
+	stream := &testBlocksServer{}
+	sender := newBlocksSender(stream)
+
+	sent, err := sender.sendHeartbeatIfDue(nil, time.Hour, 0)
+	require.NoError(t, err)
+	assert.False(t, sent, "stream is not idle yet")
+
+	require.NoError(t, sender.SendBlock(&pbfirehose.Response{Cursor: "c1", Step: pbfirehose.ForkStep_STEP_NEW, Metadata: &pbfirehose.BlockMetadata{Num: 10}}))
+
+	sent, err = sender.sendHeartbeatIfDue(nil, time.Nanosecond, 0)
+	require.NoError(t, err)
+	assert.True(t, sent)
+
+	require.Len(t, stream.sent, 2)
+	heartbeat := stream.sent[1]
+	assert.Equal(t, pbfirehose.ForkStep_STEP_UNSET, heartbeat.Step)
+	assert.Equal(t, "c1", heartbeat.Cursor)
+	assert.Nil(t, heartbeat.Metadata, "metadata describes the block of a response, heartbeats have none")
+
+	payload := &pbheartbeat.Heartbeat{}
+	require.NoError(t, heartbeat.Block.UnmarshalTo(payload))
+	assert.Nil(t, payload.Head, "no hub, head is unknown")
+
+	cursor, blockNum := sender.LastBlock()
+	assert.Equal(t, "c1", cursor)
+	assert.Equal(t, uint64(10), blockNum)
+}
+
+func TestBlocksSender_Checkpoint(t *testing.T) {
+	stream := &testBlocksServer{}
+	sender := newBlocksSender(stream)
+
+	require.NoError(t, sender.SendBlock(&pbfirehose.Response{Cursor: "c1", Step: pbfirehose.ForkStep_STEP_NEW, Metadata: &pbfirehose.BlockMetadata{Num: 10}}))
+
+	sent, err := sender.sendHeartbeatIfDue(nil, time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, sent, "no checkpoint due yet")
+
+	// Blocks are flowing, the checkpoint is sent anyway
+	time.Sleep(time.Millisecond)
+	sent, err = sender.sendHeartbeatIfDue(nil, time.Hour, time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, sent)
+
+	require.Len(t, stream.sent, 2)
+	assert.Equal(t, pbfirehose.ForkStep_STEP_UNSET, stream.sent[1].Step)
+	assert.Equal(t, "c1", stream.sent[1].Cursor)
+}
+
+type testBlocksServer struct {
+	grpc.ServerStream
+	sent []*pbfirehose.Response
+}
+
+func (s *testBlocksServer) Send(resp *pbfirehose.Response) error {
+	s.sent = append(s.sent, resp)
+	return nil
+}
@@ -0,0 +1,488 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	dauthhttp "github.com/streamingfast/dauth/middleware/http"
+	fcjson "github.com/streamingfast/firehose-core/json"
+	pbheartbeat "github.com/streamingfast/firehose-core/pb/sf/firehose/heartbeat/v1"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WithHTTPGateway serves `Blocks` (as Server-Sent Events or NDJSON), `Block` and `Info` over plain HTTP
+// on [listenAddr], rendering messages as JSON through [marshaller]. Requests go through the same
+// authentication, rate limiting and metering as their gRPC counterpart, see [Server.HTTPHandler].
+func WithHTTPGateway(listenAddr string, marshaller *fcjson.Marshaller) Option {
+	return func(s *Server) {
+		if listenAddr != "" {
+			s.httpGateway = &httpGateway{
+				server:     s,
+				marshaller: marshaller,
+			}
+			s.httpServers = append(s.httpServers, newManagedHTTPServer("firehose gateway", listenAddr, s.httpGateway.handler(), s.logger))
+		}
+	}
+}
+
+type httpGateway struct {
+	server     *Server
+	marshaller *fcjson.Marshaller
+}
+
+// HTTPHandler returns the handler of the HTTP gateway, it serves:
+//
+//   - `GET /v2/blocks?start_block_num=&stop_block_num=&cursor=&final_blocks_only=&field_mask=`: streams `Blocks`
+//     responses as Server-Sent Events when `format=sse` or when the `Accept` header contains `text/event-stream`,
+//     as newline delimited JSON otherwise. With SSE, each block's `id` is its cursor so that the `Last-Event-ID`
+//     header sent by reconnecting clients resumes the stream.
+//   - `GET /v2/block?num=&hash=&cursor=`: returns a single block, `hash` alone fetches the block by hash.
+//   - `GET /v2/info`: returns the endpoint information.
+//   - `GET /v2/live`: returns the live info (head, LIB and merged blocks range), see [WithLiveInfo], with a
+//     `503 Service Unavailable` status when the instance is not live capable.
+//
+// Request headers are forwarded as gRPC metadata, so `x-firehose-heartbeat-interval`, `x-firehose-checkpoint-interval`
+// and `x-firehose-resume-token` work the same way, and response headers mirror the gRPC response headers.
+func (s *Server) HTTPHandler() http.Handler {
+	if s.httpGateway == nil {
+		return http.NotFoundHandler()
+	}
+
+	return s.httpGateway.handler()
+}
+
+func (g *httpGateway) handler() http.Handler {
+	authenticated := http.NewServeMux()
+	authenticated.HandleFunc("GET /v2/blocks", g.blocks)
+	authenticated.HandleFunc("GET /v2/block", g.block)
+	authenticated.HandleFunc("GET /v2/info", g.info)
+
+	authMiddleware := dauthhttp.NewAuthMiddleware(g.server.authenticator, func(w http.ResponseWriter, _ context.Context, err error) {
+		g.writeError(w, err)
+	})
+
+	mux := http.NewServeMux()
+	// The live info is polled by load balancers health checks which cannot authenticate, it's served
+	// outside of the auth middleware
+	mux.HandleFunc("GET /v2/live", g.live)
+	mux.Handle("/", authMiddleware.Handler(authenticated))
+
+	return mux
+}
+
+func (g *httpGateway) blocks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := &pbfirehose.Request{
+		Cursor: query.Get("cursor"),
+	}
+
+	var err error
+	if v := query.Get("start_block_num"); v != "" {
+		if request.StartBlockNum, err = strconv.ParseInt(v, 10, 64); err != nil {
+			g.writeError(w, status.Errorf(codes.InvalidArgument, "invalid start_block_num %q: %s", v, err))
+			return
+		}
+	}
+
+	if v := query.Get("stop_block_num"); v != "" {
+		if request.StopBlockNum, err = strconv.ParseUint(v, 10, 64); err != nil {
+			g.writeError(w, status.Errorf(codes.InvalidArgument, "invalid stop_block_num %q: %s", v, err))
+			return
+		}
+	}
+
+	if v := query.Get("final_blocks_only"); v != "" {
+		if request.FinalBlocksOnly, err = strconv.ParseBool(v); err != nil {
+			g.writeError(w, status.Errorf(codes.InvalidArgument, "invalid final_blocks_only %q: %s", v, err))
+			return
+		}
+	}
+
+	if v := query.Get("field_mask"); v != "" {
+		mask, err := anypb.New(&fieldmaskpb.FieldMask{Paths: strings.Split(v, ",")})
+		if err != nil {
+			g.writeError(w, err)
+			return
+		}
+		request.Transforms = append(request.Transforms, mask)
+	}
+
+	sse := query.Get("format") == "sse" || (query.Get("format") == "" && strings.Contains(r.Header.Get("Accept"), "text/event-stream"))
+	if sse && request.Cursor == "" {
+		request.Cursor = r.Header.Get("Last-Event-ID")
+	}
+
+	stream := &httpBlocksStream{
+		ctx:     incomingContext(r.Context(), r.Header),
+		w:       w,
+		gateway: g,
+		sse:     sse,
+	}
+
+	err = g.server.Blocks(request, stream)
+	if !stream.headerSent {
+		if err == nil {
+			stream.SendHeader(nil)
+		} else {
+			g.writeError(w, err)
+			return
+		}
+	}
+
+	stream.end(err)
+}
+
+func (g *httpGateway) block(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	headers := r.Header
+	request := &pbfirehose.SingleBlockRequest{}
+	switch {
+	case query.Get("cursor") != "":
+		request.Reference = &pbfirehose.SingleBlockRequest_Cursor_{Cursor: &pbfirehose.SingleBlockRequest_Cursor{Cursor: query.Get("cursor")}}
+
+	case query.Get("hash") != "" || query.Get("num") != "":
+		var num uint64
+		if v := query.Get("num"); v != "" {
+			var err error
+			if num, err = strconv.ParseUint(v, 10, 64); err != nil {
+				g.writeError(w, status.Errorf(codes.InvalidArgument, "invalid num %q: %s", v, err))
+				return
+			}
+		}
+
+		if hash := query.Get("hash"); hash != "" {
+			request.Reference = &pbfirehose.SingleBlockRequest_BlockHashAndNumber_{BlockHashAndNumber: &pbfirehose.SingleBlockRequest_BlockHashAndNumber{Num: num, Hash: hash}}
+			if !query.Has("num") {
+				headers = headers.Clone()
+				headers.Set(BlockByHashHeader, "true")
+			}
+		} else {
+			request.Reference = &pbfirehose.SingleBlockRequest_BlockNumber_{BlockNumber: &pbfirehose.SingleBlockRequest_BlockNumber{Num: num}}
+		}
+
+	default:
+		g.writeError(w, status.Error(codes.InvalidArgument, "one of 'num', 'hash' or 'cursor' query parameter is required"))
+		return
+	}
+
+	resp, err := g.server.Block(incomingContext(r.Context(), headers), request)
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+
+	g.writeJSON(w, http.StatusOK, &httpBlockResponse{
+		Block:    resp.Block,
+		Metadata: newHTTPBlockMetadata(resp.Metadata),
+	})
+}
+
+func (g *httpGateway) info(w http.ResponseWriter, r *http.Request) {
+	ctx := incomingContext(r.Context(), r.Header)
+	resp, err := g.server.Info(ctx, &pbfirehose.InfoRequest{})
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+
+	appendMetadata(w.Header(), g.server.liveInfoMetadata(ctx))
+
+	g.writeJSON(w, http.StatusOK, &httpInfoResponse{
+		ChainName:               resp.ChainName,
+		ChainNameAliases:        resp.ChainNameAliases,
+		FirstStreamableBlockNum: resp.FirstStreamableBlockNum,
+		FirstStreamableBlockID:  resp.FirstStreamableBlockId,
+		BlockIDEncoding:         resp.BlockIdEncoding.String(),
+		BlockFeatures:           resp.BlockFeatures,
+	})
+}
+
+func (g *httpGateway) writeJSON(w http.ResponseWriter, statusCode int, in any) {
+	out, err := g.marshaller.MarshalToString(in)
+	if err != nil {
+		g.writeError(w, status.Errorf(codes.Internal, "marshalling response to json: %s", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	io.WriteString(w, out)
+}
+
+func (g *httpGateway) writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+
+	out, marshalErr := json.Marshal(newHTTPError(st))
+	if marshalErr != nil {
+		out = []byte(`{"error":{"code":"Internal","message":"unable to marshal error"}}`)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatusFromCode(st.Code()))
+	w.Write(out)
+}
+
+// incomingContext forwards the HTTP request [headers] as incoming gRPC metadata.
+func incomingContext(ctx context.Context, headers http.Header) context.Context {
+	md := metadata.MD{}
+	for key, values := range headers {
+		md.Append(strings.ToLower(key), values...)
+	}
+
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// httpBlocksStream adapts an HTTP response into a `Blocks` server stream.
+type httpBlocksStream struct {
+	grpc.ServerStream
+
+	ctx     context.Context
+	w       http.ResponseWriter
+	gateway *httpGateway
+	sse     bool
+
+	headerSent bool
+	trailer    metadata.MD
+}
+
+func (s *httpBlocksStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *httpBlocksStream) SetHeader(md metadata.MD) error {
+	if s.headerSent {
+		return fmt.Errorf("header already sent")
+	}
+
+	for key, values := range md {
+		for _, value := range values {
+			s.w.Header().Add(key, value)
+		}
+	}
+	return nil
+}
+
+func (s *httpBlocksStream) SendHeader(md metadata.MD) error {
+	if err := s.SetHeader(md); err != nil {
+		return err
+	}
+
+	if s.sse {
+		s.w.Header().Set("Content-Type", "text/event-stream")
+		s.w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+
+	s.w.WriteHeader(http.StatusOK)
+	s.headerSent = true
+	return s.flush()
+}
+
+func (s *httpBlocksStream) SetTrailer(md metadata.MD) {
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+func (s *httpBlocksStream) Send(resp *pbfirehose.Response) error {
+	if !s.headerSent {
+		if err := s.SendHeader(nil); err != nil {
+			return err
+		}
+	}
+
+	if resp.Step == pbfirehose.ForkStep_STEP_UNSET {
+		return s.sendHeartbeat(resp)
+	}
+
+	return s.write("block", resp.Cursor, &httpStreamResponse{
+		Block:    resp.Block,
+		Step:     resp.Step.String(),
+		Cursor:   resp.Cursor,
+		Metadata: newHTTPBlockMetadata(resp.Metadata),
+	})
+}
+
+// sendHeartbeat renders the head of the packed [pbheartbeat.Heartbeat] in its own `head` field, the
+// marshaller's registry only knows the chain's block types.
+func (s *httpBlocksStream) sendHeartbeat(resp *pbfirehose.Response) error {
+	heartbeat := &pbheartbeat.Heartbeat{}
+	if err := resp.Block.UnmarshalTo(heartbeat); err != nil {
+		return status.Errorf(codes.Internal, "unpacking heartbeat: %s", err)
+	}
+
+	out := &httpStreamResponse{
+		Step:   resp.Step.String(),
+		Cursor: resp.Cursor,
+	}
+	if head := heartbeat.Head; head != nil {
+		out.Head = &httpBlockMetadata{
+			Num:    head.BlockNum,
+			ID:     head.BlockId,
+			LibNum: head.LibNum,
+			Time:   asTime(head.BlockTime),
+		}
+	}
+
+	return s.write("heartbeat", resp.Cursor, out)
+}
+
+// end writes the final message of the stream: the error that terminated it if any, the trailer otherwise.
+func (s *httpBlocksStream) end(err error) {
+	if err != nil {
+		s.write("error", "", newHTTPError(status.Convert(err)))
+		return
+	}
+
+	trailer := map[string]string{}
+	for key, values := range s.trailer {
+		if len(values) > 0 {
+			trailer[key] = values[0]
+		}
+	}
+	s.write("end", "", &httpStreamEnd{Trailer: trailer})
+}
+
+func (s *httpBlocksStream) write(event string, id string, in any) error {
+	out, err := s.gateway.marshaller.MarshalToString(in)
+	if err != nil {
+		return status.Errorf(codes.Internal, "marshalling response to json: %s", err)
+	}
+	out = strings.TrimSuffix(out, "\n")
+
+	if s.sse {
+		if id != "" {
+			out = fmt.Sprintf("event: %s\nid: %s\ndata: %s\n\n", event, id, out)
+		} else {
+			out = fmt.Sprintf("event: %s\ndata: %s\n\n", event, out)
+		}
+	} else if event != "block" {
+		out = fmt.Sprintf("{%q:%s}\n", event, out)
+	} else {
+		out += "\n"
+	}
+
+	if _, err := io.WriteString(s.w, out); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+func (s *httpBlocksStream) flush() error {
+	return http.NewResponseController(s.w).Flush()
+}
+
+type httpStreamResponse struct {
+	Block    *anypb.Any         `json:"block,omitempty"`
+	Step     string             `json:"step"`
+	Cursor   string             `json:"cursor"`
+	Metadata *httpBlockMetadata `json:"metadata,omitempty"`
+	// Head is only set on heartbeats, see [HeartbeatIntervalHeader]
+	Head *httpBlockMetadata `json:"head,omitempty"`
+}
+
+type httpStreamEnd struct {
+	Trailer map[string]string `json:"trailer"`
+}
+
+type httpBlockResponse struct {
+	Block    *anypb.Any         `json:"block,omitempty"`
+	Metadata *httpBlockMetadata `json:"metadata,omitempty"`
+}
+
+type httpBlockMetadata struct {
+	Num       uint64     `json:"num"`
+	ID        string     `json:"id"`
+	ParentNum uint64     `json:"parent_num"`
+	ParentID  string     `json:"parent_id"`
+	LibNum    uint64     `json:"lib_num"`
+	Time      *time.Time `json:"time,omitempty"`
+}
+
+func newHTTPBlockMetadata(in *pbfirehose.BlockMetadata) *httpBlockMetadata {
+	if in == nil {
+		return nil
+	}
+
+	return &httpBlockMetadata{
+		Num:       in.Num,
+		ID:        in.Id,
+		ParentNum: in.ParentNum,
+		ParentID:  in.ParentId,
+		LibNum:    in.LibNum,
+		Time:      asTime(in.Time),
+	}
+}
+
+func asTime(in *timestamppb.Timestamp) *time.Time {
+	if in == nil {
+		return nil
+	}
+
+	t := in.AsTime()
+	return &t
+}
+
+type httpInfoResponse struct {
+	ChainName               string   `json:"chain_name"`
+	ChainNameAliases        []string `json:"chain_name_aliases"`
+	FirstStreamableBlockNum uint64   `json:"first_streamable_block_num"`
+	FirstStreamableBlockID  string   `json:"first_streamable_block_id"`
+	BlockIDEncoding         string   `json:"block_id_encoding"`
+	BlockFeatures           []string `json:"block_features"`
+}
+
+type httpError struct {
+	Error httpErrorDetails `json:"error"`
+}
+
+type httpErrorDetails struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newHTTPError(st *status.Status) *httpError {
+	return &httpError{Error: httpErrorDetails{Code: st.Code().String(), Message: st.Message()}}
+}
+
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusInternalServerError
+}
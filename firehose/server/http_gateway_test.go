@@ -0,0 +1,194 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/firehose"
+	"github.com/streamingfast/firehose-core/firehose/info"
+	fcjson "github.com/streamingfast/firehose-core/json"
+	pbheartbeat "github.com/streamingfast/firehose-core/pb/sf/firehose/heartbeat/v1"
+	fcproto "github.com/streamingfast/firehose-core/proto"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestHTTPGateway_Block(t *testing.T) {
+	registry, err := fcproto.NewRegistry(wrapperspb.File_google_protobuf_wrappers_proto)
+	require.NoError(t, err)
+
+	server := &Server{
+		blockGetter:   firehose.NewBlockGetter(newTestBytesPayloadMergedBlocksStore(t, "00000001a", "00000002a"), nil, nil, nil),
+		authenticator: testAuthenticator{},
+		logger:        zap.NewNop(),
+	}
+	WithHTTPGateway(":0", fcjson.NewMarshaller(registry, fcjson.WithBytesEncoding("hex")))(server)
+
+	tests := []struct {
+		name           string
+		target         string
+		authorized     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			"by number", "/v2/block?num=2", true, http.StatusOK,
+			`{"block":{"value":"deadbeef02"},"metadata":{"num":2,"id":"00000002a","parent_num":1,"parent_id":"00000001a","lib_num":0,"time":"0001-01-01T00:00:00Z"}}`,
+		},
+		{
+			"not found", "/v2/block?num=2&hash=00000002b", true, http.StatusNotFound,
+			`{"error":{"code":"NotFound","message":"block not found in files"}}`,
+		},
+		{
+			"by hash alone", "/v2/block?hash=00000002a", true, http.StatusNotFound,
+			`{"error":{"code":"NotFound","message":"block not found by hash"}}`,
+		},
+		{
+			"invalid number", "/v2/block?num=abc", true, http.StatusBadRequest,
+			`{"error":{"code":"InvalidArgument","message":"invalid num \"abc\": strconv.ParseUint: parsing \"abc\": invalid syntax"}}`,
+		},
+		{
+			"missing reference", "/v2/block", true, http.StatusBadRequest,
+			`{"error":{"code":"InvalidArgument","message":"one of 'num', 'hash' or 'cursor' query parameter is required"}}`,
+		},
+		{
+			"unauthenticated", "/v2/block?num=2", false, http.StatusUnauthorized,
+			`{"error":{"code":"Unauthenticated","message":"authenticate : missing authorization header"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.authorized {
+				request.Header.Set("Authorization", "Bearer test")
+			}
+
+			recorder := httptest.NewRecorder()
+			server.HTTPHandler().ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestHTTPBlocksStream_Heartbeat(t *testing.T) {
+	registry, err := fcproto.NewRegistry(wrapperspb.File_google_protobuf_wrappers_proto)
+	require.NoError(t, err)
+
+	payload, err := anypb.New(&pbheartbeat.Heartbeat{Head: &pbheartbeat.Head{
+		BlockNum:  10,
+		BlockId:   "0000000aa",
+		BlockTime: timestamppb.New(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+		LibNum:    8,
+	}})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	stream := &httpBlocksStream{
+		ctx:     context.Background(),
+		w:       recorder,
+		gateway: &httpGateway{marshaller: fcjson.NewMarshaller(registry)},
+	}
+	require.NoError(t, stream.Send(&pbfirehose.Response{Step: pbfirehose.ForkStep_STEP_UNSET, Cursor: "c1", Block: payload}))
+
+	assert.JSONEq(t, `{"heartbeat":{"step":"STEP_UNSET","cursor":"c1","head":{"num":10,"id":"0000000aa","parent_num":0,"parent_id":"","lib_num":8,"time":"2026-01-01T00:00:00Z"}}}`, recorder.Body.String())
+}
+
+func TestHTTPHandler_GatewayNotConfigured(t *testing.T) {
+	server := &Server{authenticator: testAuthenticator{}, logger: zap.NewNop()}
+
+	recorder := httptest.NewRecorder()
+	server.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v2/block?num=1", nil))
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// newTestBytesPayloadMergedBlocksStore is like [newTestMergedBlocksStore] but with payloads that
+// can be rendered to JSON, a `google.protobuf.BytesValue` of `0xdeadbeef` followed by the block number.
+func newTestBytesPayloadMergedBlocksStore(t *testing.T, blockIDs ...string) *dstore.MockStore {
+	t.Helper()
+
+	buffer := bytes.NewBuffer(nil)
+	writer, err := bstream.NewDBinBlockWriter(buffer)
+	require.NoError(t, err)
+
+	previous := ""
+	for _, id := range blockIDs {
+		block := bstream.TestBlock(id, previous)
+		block.Payload, err = anypb.New(wrapperspb.Bytes([]byte{0xde, 0xad, 0xbe, 0xef, byte(block.Number)}))
+		require.NoError(t, err)
+
+		require.NoError(t, writer.Write(block))
+		previous = id
+	}
+
+	store := dstore.NewMockStore(nil)
+	store.SetFile("0000000000", buffer.Bytes())
+
+	return store
+}
+
+type testAuthenticator struct{}
+
+func (testAuthenticator) Authenticate(ctx context.Context, _ string, headers map[string][]string, _ string) (context.Context, error) {
+	if len(headers["Authorization"]) == 0 {
+		return nil, fmt.Errorf("missing authorization header")
+	}
+	return ctx, nil
+}
+
+func (testAuthenticator) Ready(context.Context) bool {
+	return true
+}
+
+func TestHTTPGateway_Live(t *testing.T) {
+	registry, err := fcproto.NewRegistry(wrapperspb.File_google_protobuf_wrappers_proto)
+	require.NoError(t, err)
+
+	store := dstore.NewMockStore(nil)
+	store.SetFile("0000000100", nil)
+	store.SetFile("0000000200", nil)
+
+	server := &Server{authenticator: testAuthenticator{}, logger: zap.NewNop()}
+	WithHTTPGateway(":0", fcjson.NewMarshaller(registry))(server)
+	WithLiveInfo(info.NewLiveInfoProvider(nil, store, time.Second, zap.NewNop()))(server)
+
+	// Load balancers health checks are not authenticated
+	request := httptest.NewRequest(http.MethodGet, "/v2/live", nil)
+
+	recorder := httptest.NewRecorder()
+	server.HTTPHandler().ServeHTTP(recorder, request)
+
+	// Without a hub the instance is not live capable, the merged blocks range is still reported
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	var resp httpLiveResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.False(t, resp.LiveCapable)
+	assert.Nil(t, resp.HeadBlockNum)
+	require.NotNil(t, resp.LowestMergedBlockNum)
+	assert.Equal(t, uint64(100), *resp.LowestMergedBlockNum)
+	require.NotNil(t, resp.HighestMergedBlockNum)
+	assert.Equal(t, uint64(299), *resp.HighestMergedBlockNum)
+
+	md := server.liveInfoMetadata(context.Background())
+	assert.Equal(t, []string{"false"}, md.Get(LiveCapableHeader))
+	assert.Equal(t, []string{"299"}, md.Get(HighestMergedBlockNumHeader))
+	assert.Empty(t, md.Get(HeadBlockNumHeader))
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// managedHTTPServer is a plain HTTP server launched and shut down along the gRPC servers.
+type managedHTTPServer struct {
+	name       string
+	listenAddr string
+	handler    http.Handler
+	logger     *zap.Logger
+
+	mu         sync.Mutex
+	httpServer *http.Server
+}
+
+func newManagedHTTPServer(name string, listenAddr string, handler http.Handler, logger *zap.Logger) *managedHTTPServer {
+	return &managedHTTPServer{
+		name:       name,
+		listenAddr: listenAddr,
+		handler:    handler,
+		logger:     logger,
+	}
+}
+
+func (m *managedHTTPServer) launch() error {
+	m.mu.Lock()
+	m.httpServer = &http.Server{
+		Addr:    m.listenAddr,
+		Handler: m.handler,
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("launching "+m.name+" http server", zap.String("listen_addr", m.listenAddr))
+	if err := m.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (m *managedHTTPServer) shutdown(timeout time.Duration) {
+	m.mu.Lock()
+	httpServer := m.httpServer
+	m.mu.Unlock()
+
+	if httpServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		httpServer.Close()
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/streamingfast/firehose-core/firehose/info"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	LiveCapableHeader           = "x-firehose-live-capable"
+	LowestMergedBlockNumHeader  = "x-firehose-lowest-merged-block-num"
+	HighestMergedBlockNumHeader = "x-firehose-highest-merged-block-num"
+)
+
+// WithLiveInfo reports the live info from [provider] along `Info` responses, in the head information
+// headers (see [HeadBlockNumHeader]) plus [LiveCapableHeader], [LowestMergedBlockNumHeader] and
+// [HighestMergedBlockNumHeader], and on the HTTP gateway `GET /v2/live` endpoint.
+func WithLiveInfo(provider *info.LiveInfoProvider) Option {
+	return func(s *Server) {
+		s.liveInfo = provider
+	}
+}
+
+// liveInfoMetadata returns the live info headers of the chain requested in [ctx], empty when unknown.
+func (s *Server) liveInfoMetadata(ctx context.Context) metadata.MD {
+	md := metadata.MD{}
+
+	target, err := s.routeChain(ctx)
+	if err != nil || target.liveInfo == nil {
+		return md
+	}
+
+	live := target.liveInfo.Get(ctx)
+	md.Set(LiveCapableHeader, strconv.FormatBool(live.LiveCapable))
+	if live.LiveCapable {
+		md.Set(HeadBlockNumHeader, strconv.FormatUint(live.HeadBlockNum, 10))
+		md.Set(HeadBlockIDHeader, live.HeadBlockID)
+		md.Set(HeadBlockTimeHeader, live.HeadBlockTime.UTC().Format(time.RFC3339Nano))
+		md.Set(LIBNumHeader, strconv.FormatUint(live.LIBNum, 10))
+	}
+	if live.HasMergedBlocks {
+		md.Set(LowestMergedBlockNumHeader, strconv.FormatUint(live.LowestMergedBlockNum, 10))
+		md.Set(HighestMergedBlockNumHeader, strconv.FormatUint(live.HighestMergedBlockNum, 10))
+	}
+
+	return md
+}
+
+type httpLiveResponse struct {
+	LiveCapable           bool    `json:"live_capable"`
+	HeadBlockNum          *uint64 `json:"head_block_num,omitempty"`
+	HeadBlockID           string  `json:"head_block_id,omitempty"`
+	HeadBlockTime         string  `json:"head_block_time,omitempty"`
+	LIBNum                *uint64 `json:"lib_num,omitempty"`
+	LowestMergedBlockNum  *uint64 `json:"lowest_merged_block_num,omitempty"`
+	HighestMergedBlockNum *uint64 `json:"highest_merged_block_num,omitempty"`
+	UpdatedAt             string  `json:"updated_at"`
+}
+
+// live serves the live info, with a `503 Service Unavailable` status when the instance is not live
+// capable so that load balancers can route on it without parsing the body.
+func (g *httpGateway) live(w http.ResponseWriter, r *http.Request) {
+	target, err := g.server.routeChain(incomingContext(r.Context(), r.Header))
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+
+	if target.liveInfo == nil {
+		g.writeJSON(w, http.StatusServiceUnavailable, &httpLiveResponse{UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano)})
+		return
+	}
+
+	live := target.liveInfo.Get(r.Context())
+	resp := &httpLiveResponse{
+		LiveCapable: live.LiveCapable,
+		UpdatedAt:   live.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if live.LiveCapable {
+		resp.HeadBlockNum = &live.HeadBlockNum
+		resp.HeadBlockID = live.HeadBlockID
+		resp.HeadBlockTime = live.HeadBlockTime.UTC().Format(time.RFC3339Nano)
+		resp.LIBNum = &live.LIBNum
+	}
+	if live.HasMergedBlocks {
+		resp.LowestMergedBlockNum = &live.LowestMergedBlockNum
+		resp.HighestMergedBlockNum = &live.HighestMergedBlockNum
+	}
+
+	statusCode := http.StatusOK
+	if !live.LiveCapable {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	g.writeJSON(w, statusCode, resp)
+}
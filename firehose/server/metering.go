@@ -0,0 +1,20 @@
+package server
+
+import (
+	"time"
+)
+
+// WithMeteringAggregation makes `Blocks` requests emit one metering event per [window] or per
+// [maxBlocks] blocks, whichever comes first, instead of one per block, the remaining usage being
+// emitted when the stream ends. Totals are the same as per-block emission. Zero values disable the
+// corresponding limit, both being zero (the default) keeps per-block emission.
+func WithMeteringAggregation(window time.Duration, maxBlocks uint64) Option {
+	return func(s *Server) {
+		s.meteringAggregationWindow = window
+		s.meteringAggregationMaxBlocks = maxBlocks
+	}
+}
+
+func (s *Server) meteringAggregationEnabled() bool {
+	return s.meteringAggregationWindow > 0 || s.meteringAggregationMaxBlocks > 0
+}
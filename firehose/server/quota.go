@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/streamingfast/dauth"
+	"github.com/streamingfast/firehose-core/firehose/quota"
+	"google.golang.org/grpc/codes"
+)
+
+// WithQuotas enforces the usage quotas of [manager] on `Blocks`, `Block` and `BatchFetch/Blocks`
+// requests, requests of callers whose quota is exhausted are rejected and streams are terminated at
+// the first block after it got exhausted, both with `ResourceExhausted` and the `QUOTA_EXHAUSTED` reason.
+func WithQuotas(manager *quota.Manager) Option {
+	return func(s *Server) {
+		s.quotas = manager
+	}
+}
+
+// quotaTracker returns the tracker of the caller of [ctx], nil when quotas are disabled or the
+// caller is not limited.
+func (s *Server) quotaTracker(ctx context.Context) *quota.Tracker {
+	if s.quotas == nil {
+		return nil
+	}
+
+	return s.quotas.Tracker(dauth.FromContext(ctx))
+}
+
+func quotaExhaustedError(err *quota.ExhaustedError, lastCursor string) error {
+	return statusError(codes.ResourceExhausted, "QUOTA_EXHAUSTED", err.Error(), lastCursor, err.RetryAfter(time.Now()))
+}
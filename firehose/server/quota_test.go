@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/dauth"
+	"github.com/streamingfast/firehose-core/firehose"
+	"github.com/streamingfast/firehose-core/firehose/quota"
+	pbbatchfetch "github.com/streamingfast/firehose-core/pb/sf/firehose/batchfetch/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_BlockQuota(t *testing.T) {
+	file, err := quota.ParseFile([]byte("users:\n  - {user-id: alice, blocks: 2}\n"))
+	require.NoError(t, err)
+
+	server := &Server{
+		blockGetter: firehose.NewBlockGetter(newTestMergedBlocksStore(t, "00000001a", "00000002a"), nil, nil, nil),
+		logger:      zap.NewNop(),
+		drainer:     newDrainer(),
+	}
+	WithQuotas(quota.NewManager(file, false, zap.NewNop()))(server)
+
+	alice := dauth.WithTrustedHeaders(context.Background(), dauth.TrustedHeaders{dauth.SFHeaderUserID: "alice"})
+	bob := dauth.WithTrustedHeaders(context.Background(), dauth.TrustedHeaders{dauth.SFHeaderUserID: "bob"})
+
+	for i := 0; i < 2; i++ {
+		_, err := server.Block(alice, blockNumberRequest(1))
+		require.NoError(t, err)
+	}
+
+	_, err = server.Block(alice, blockNumberRequest(2))
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Contains(t, st.Message(), `quota exhausted for user "alice": 2 of 2 blocks per 24h0m0s used`)
+
+	var info *errdetails.ErrorInfo
+	for _, detail := range st.Details() {
+		if candidate, ok := detail.(*errdetails.ErrorInfo); ok {
+			info = candidate
+		}
+	}
+	require.NotNil(t, info)
+	assert.Equal(t, "QUOTA_EXHAUSTED", info.Reason)
+
+	// Users without limits are not affected
+	_, err = server.Block(bob, blockNumberRequest(2))
+	require.NoError(t, err)
+}
+
+func TestBatchFetchServer_Quota(t *testing.T) {
+	file, err := quota.ParseFile([]byte("users:\n  - {user-id: alice, blocks: 2}\n"))
+	require.NoError(t, err)
+
+	server := &Server{
+		blockGetter:           firehose.NewBlockGetter(newTestMergedBlocksStore(t, "00000001a", "00000002a", "00000003a"), nil, nil, nil),
+		logger:                zap.NewNop(),
+		drainer:               newDrainer(),
+		batchFetchConcurrency: 1,
+	}
+	WithQuotas(quota.NewManager(file, false, zap.NewNop()))(server)
+
+	alice := dauth.WithTrustedHeaders(context.Background(), dauth.TrustedHeaders{dauth.SFHeaderUserID: "alice"})
+
+	// The batch is cut at the first block over the quota
+	stream := &testBatchFetchStream{ctx: alice, requests: []*pbbatchfetch.Request{batchBlockRangeRequest(1, 3)}}
+	err = (&batchFetchServer{server: server}).Blocks(stream)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Len(t, stream.sent, 2)
+
+	// Next batches are refused
+	stream = &testBatchFetchStream{ctx: alice, requests: []*pbbatchfetch.Request{batchBlockNumberRequest(1)}}
+	err = (&batchFetchServer{server: server}).Blocks(stream)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), `quota exhausted for user "alice": 2 of 2 blocks per 24h0m0s used`)
+	assert.Empty(t, stream.sent)
+}
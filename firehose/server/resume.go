@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/bstream/hub"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// ResumeTokenHeader is both the request header a client can send to resume a previous stream and the
+	// response header in which the server returns the token identifying the current stream.
+	ResumeTokenHeader = "x-firehose-resume-token"
+
+	// Head information is sent in the response headers when the stream starts and in the response
+	// trailers when it ends, clients opt-in to receive it periodically while the stream runs with
+	// [CheckpointIntervalHeader].
+	HeadBlockNumHeader  = "x-firehose-head-block-num"
+	HeadBlockIDHeader   = "x-firehose-head-block-id"
+	HeadBlockTimeHeader = "x-firehose-head-block-time"
+	LIBNumHeader        = "x-firehose-lib-num"
+
+	LastCursorTrailer   = "x-firehose-last-cursor"
+	LastBlockNumTrailer = "x-firehose-last-block-num"
+)
+
+const defaultResumeTokenTTL = 15 * time.Minute
+
+func WithResumeTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.resumeTokens = newResumeTokenStore(ttl)
+	}
+}
+
+// resumeTokenStore keeps track of the last cursor handed to the transport for every stream served
+// by this instance. A client that lost track of its last received message (or never got it because
+// the connection dropped) can send back the token it received in the response headers and the stream
+// restarts right after the last block the server sent.
+//
+// Tokens are kept in memory, so resuming only works against the instance that served the original
+// stream and for [ttl] after the stream ended. Tokens are signed with a per-process secret along the
+// authenticated user that started the stream, a token sent back by any other user is unknown.
+//
+// The store is only locked when a stream starts ([Track]) or ends ([Release]), the cursor of every
+// sent block is recorded on the stream's own entry.
+type resumeTokenStore struct {
+	sync.Mutex
+
+	ttl     time.Duration
+	secret  []byte
+	entries map[string]*resumeEntry
+}
+
+type resumeEntry struct {
+	cursor atomic.String
+
+	// Guarded by the store's lock, an entry never expires while a stream uses it
+	streams   int
+	expiresAt time.Time
+}
+
+// Record updates the last sent cursor of the entry's stream.
+func (e *resumeEntry) Record(cursor string) {
+	e.cursor.Store(cursor)
+}
+
+func (e *resumeEntry) expired(now time.Time) bool {
+	return e.streams == 0 && now.After(e.expiresAt)
+}
+
+func newResumeTokenStore(ttl time.Duration) *resumeTokenStore {
+	return &resumeTokenStore{
+		ttl:     ttl,
+		secret:  randomBytes(32),
+		entries: make(map[string]*resumeEntry),
+	}
+}
+
+// NewToken returns a new token, in the form `<id>.<signature>`, bound to [userID].
+func (s *resumeTokenStore) NewToken(userID string) string {
+	id := hex.EncodeToString(randomBytes(16))
+
+	return id + "." + s.sign(id, userID)
+}
+
+func (s *resumeTokenStore) sign(id string, userID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userID))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomBytes(length int) []byte {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		panic("unable to generate random resume token: " + err.Error())
+	}
+
+	return buf
+}
+
+// Cursor returns the last cursor recorded for [token], if it was issued to [userID] and is known and
+// not expired.
+func (s *resumeTokenStore) Cursor(token string, userID string) (cursor string, found bool) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(id, userID))) {
+		return "", false
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	entry, found := s.entries[token]
+	if !found {
+		return "", false
+	}
+
+	if entry.expired(time.Now()) {
+		delete(s.entries, token)
+		return "", false
+	}
+
+	return entry.cursor.Load(), true
+}
+
+// Track returns the entry of [token] with [cursor] recorded, the stream records its sent cursors
+// on it and must [Release] it when it ends.
+func (s *resumeTokenStore) Track(token string, cursor string) *resumeEntry {
+	s.Lock()
+	defer s.Unlock()
+
+	entry, found := s.entries[token]
+	if !found {
+		s.evictExpired(time.Now())
+
+		entry = &resumeEntry{}
+		s.entries[token] = entry
+	}
+
+	entry.streams++
+	entry.cursor.Store(cursor)
+
+	return entry
+}
+
+// Release marks the end of a stream tracked with [Track], the entry expires [ttl] after the last
+// stream using it ended.
+func (s *resumeTokenStore) Release(entry *resumeEntry) {
+	s.Lock()
+	defer s.Unlock()
+
+	entry.streams--
+	entry.expiresAt = time.Now().Add(s.ttl)
+}
+
+// evictExpired must be called while the store is locked, it's done on token creation only
+// which is much less frequent than recording cursors.
+func (s *resumeTokenStore) evictExpired(now time.Time) {
+	for token, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, token)
+		}
+	}
+}
+
+func resumeTokenFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(ResumeTokenHeader); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func headInfoMetadata(forkableHub *hub.ForkableHub) metadata.MD {
+	md := metadata.MD{}
+	if forkableHub == nil {
+		return md
+	}
+
+	headNum, headID, headTime, libNum, err := forkableHub.HeadInfo()
+	if err != nil {
+		return md
+	}
+
+	md.Set(HeadBlockNumHeader, strconv.FormatUint(headNum, 10))
+	md.Set(HeadBlockIDHeader, headID)
+	md.Set(HeadBlockTimeHeader, headTime.UTC().Format(time.RFC3339Nano))
+	md.Set(LIBNumHeader, strconv.FormatUint(libNum, 10))
+
+	return md
+}
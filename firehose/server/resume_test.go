@@ -0,0 +1,114 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResumeTokenStore(t *testing.T) {
+	store := newResumeTokenStore(50 * time.Millisecond)
+
+	_, found := store.Cursor("unknown", "alice")
+	assert.False(t, found)
+
+	token := store.NewToken("alice")
+	entry := store.Track(token, "")
+	cursor, found := store.Cursor(token, "alice")
+	require.True(t, found)
+	assert.Equal(t, "", cursor)
+
+	entry.Record("c1")
+	entry.Record("c2")
+	cursor, found = store.Cursor(token, "alice")
+	require.True(t, found)
+	assert.Equal(t, "c2", cursor)
+
+	// Never expires while the stream runs
+	time.Sleep(60 * time.Millisecond)
+	_, found = store.Cursor(token, "alice")
+	require.True(t, found)
+
+	store.Release(entry)
+	cursor, found = store.Cursor(token, "alice")
+	require.True(t, found)
+	assert.Equal(t, "c2", cursor)
+
+	time.Sleep(60 * time.Millisecond)
+	_, found = store.Cursor(token, "alice")
+	assert.False(t, found)
+}
+
+func TestResumeTokenStore_BoundToUser(t *testing.T) {
+	store := newResumeTokenStore(time.Minute)
+
+	token := store.NewToken("alice")
+	store.Track(token, "c1")
+
+	_, found := store.Cursor(token, "bob")
+	assert.False(t, found)
+
+	_, found = store.Cursor(token, "")
+	assert.False(t, found)
+
+	id, _, _ := strings.Cut(token, ".")
+	_, found = store.Cursor(id+"."+store.sign(id, "bob"), "alice")
+	assert.False(t, found)
+
+	cursor, found := store.Cursor(token, "alice")
+	require.True(t, found)
+	assert.Equal(t, "c1", cursor)
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name              string
+		code              codes.Code
+		lastCursor        string
+		expectedRetryable string
+		expectRetryInfo   bool
+	}{
+		{"retryable with cursor", codes.Unavailable, "cursor", "true", true},
+		{"retryable without cursor", codes.Internal, "", "true", true},
+		{"non retryable", codes.InvalidArgument, "cursor", "false", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := status.FromError(statusError(tt.code, "REASON", "message", tt.lastCursor, time.Second))
+			require.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, "message", st.Message())
+
+			var info *errdetails.ErrorInfo
+			var retryInfo *errdetails.RetryInfo
+			for _, detail := range st.Details() {
+				switch v := detail.(type) {
+				case *errdetails.ErrorInfo:
+					info = v
+				case *errdetails.RetryInfo:
+					retryInfo = v
+				}
+			}
+
+			require.NotNil(t, info)
+			assert.Equal(t, "REASON", info.Reason)
+			assert.Equal(t, ErrorInfoDomain, info.Domain)
+			assert.Equal(t, tt.expectedRetryable, info.Metadata["retryable"])
+			assert.Equal(t, tt.lastCursor, info.Metadata["last_cursor"])
+
+			if tt.expectRetryInfo {
+				require.NotNil(t, retryInfo)
+				assert.Equal(t, time.Second, retryInfo.RetryDelay.AsDuration())
+			} else {
+				assert.Nil(t, retryInfo)
+			}
+		})
+	}
+}
@@ -2,23 +2,30 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"net/url"
 	"strings"
 	"sync"
 	"time"
 
 	_ "github.com/mostynb/go-grpc-compression/zstd"
+	"github.com/streamingfast/bstream/hub"
 	"github.com/streamingfast/bstream/transform"
 	"github.com/streamingfast/dauth"
+	dauthconnect "github.com/streamingfast/dauth/middleware/connect"
 	dauthgrpc "github.com/streamingfast/dauth/middleware/grpc"
 	dgrpcserver "github.com/streamingfast/dgrpc/server"
 	"github.com/streamingfast/dgrpc/server/factory"
 	"github.com/streamingfast/dmetering"
 	firecore "github.com/streamingfast/firehose-core"
 	"github.com/streamingfast/firehose-core/firehose"
+	"github.com/streamingfast/firehose-core/firehose/accesslog"
+	"github.com/streamingfast/firehose-core/firehose/admission"
 	"github.com/streamingfast/firehose-core/firehose/info"
+	"github.com/streamingfast/firehose-core/firehose/quota"
 	"github.com/streamingfast/firehose-core/firehose/rate"
 	"github.com/streamingfast/firehose-core/metering"
+	pbbatchfetch "github.com/streamingfast/firehose-core/pb/sf/firehose/batchfetch/v1"
 	pbfirehoseV1 "github.com/streamingfast/pbgo/sf/firehose/v1"
 	pbfirehoseV2 "github.com/streamingfast/pbgo/sf/firehose/v2"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -37,19 +44,57 @@ type Server struct {
 	initFunc     func(context.Context, *pbfirehoseV2.Request) context.Context
 	postHookFunc func(context.Context, *pbfirehoseV2.Response)
 
-	servers []*wrappedServer
-	logger  *zap.Logger
+	servers       []*wrappedServer
+	httpGateway   *httpGateway
+	httpServers   []*managedHTTPServer
+	authenticator dauth.Authenticator
+	infoServer    *info.InfoServer
+	logger        *zap.Logger
 
-	rateLimiter rate.Limiter
+	rateLimiter  rate.Limiter
+	admission    *admission.Controller
+	accessLog    accesslog.Sink
+	liveInfo     *info.LiveInfoProvider
+	drainer      *drainer
+	resumeTokens *resumeTokenStore
+	quotas       *quota.Manager
+	hub          *hub.ForkableHub
+
+	chainName   string
+	chainRoutes map[string]*Server
+
+	batchFetchConcurrency int
+	connectWeb            bool
+
+	meteringAggregationWindow    time.Duration
+	meteringAggregationMaxBlocks uint64
 }
 
 type wrappedServer struct {
-	dgrpcserver.Server
+	launchableServer
 	listenAddr string
 }
 
+// maxRecvMsgSize is the maximum size of a received message, on the native gRPC and Connect servers alike
+const maxRecvMsgSize = 25 * 1024 * 1024
+
+// launchableServer is the lifecycle shared by the native gRPC servers and the Connect ones.
+type launchableServer interface {
+	Launch(serverListenerAddress string)
+	OnTerminated(f func(err error))
+	Shutdown(timeout time.Duration)
+}
+
 type Option func(*Server)
 
+// WithForkableHub gives access to the live segment of the chain so that head information can be
+// reported to clients through response headers and trailers.
+func WithForkableHub(forkableHub *hub.ForkableHub) Option {
+	return func(s *Server) {
+		s.hub = forkableHub
+	}
+}
+
 func WithLeakyBucketLimiter(size int, dripRate time.Duration) Option {
 	return func(s *Server) {
 		s.rateLimiter = rate.NewLeakyBucketLimiter(size, dripRate)
@@ -84,22 +129,36 @@ func New(
 		metering.Send(ctx, meter, auth.UserID(), auth.APIKeyID(), auth.RealIP(), auth.Meta(), "sf.firehose.v2.Firehose/Blocks", response)
 	}
 
+	s := &Server{
+		transformRegistry: transformRegistry,
+		blockGetter:       blockGetter,
+		streamFactory:     streamFactory,
+		initFunc:          initFunc,
+		postHookFunc:      postHookFunc,
+		authenticator:     authenticator,
+		infoServer:        infoServer,
+		logger:            logger,
+		resumeTokens:      newResumeTokenStore(defaultResumeTokenTTL),
+		drainer:           newDrainer(),
+
+		batchFetchConcurrency: defaultBatchFetchConcurrency,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
 	tracerProvider := otel.GetTracerProvider()
 
-	var servers []*wrappedServer
 	for _, addr := range strings.Split(listenAddr, ",") {
-		options := []dgrpcserver.Option{
-			dgrpcserver.WithLogger(logger),
-			dgrpcserver.WithHealthCheck(dgrpcserver.HealthCheckOverGRPC|dgrpcserver.HealthCheckOverHTTP, createHealthCheck(isReady)),
-			dgrpcserver.WithPostUnaryInterceptor(otelgrpc.UnaryServerInterceptor(otelgrpc.WithTracerProvider(tracerProvider))),
-			dgrpcserver.WithPostStreamInterceptor(otelgrpc.StreamServerInterceptor(otelgrpc.WithTracerProvider(tracerProvider))),
-			dgrpcserver.WithGRPCServerOptions(grpc.MaxRecvMsgSize(25 * 1024 * 1024)),
-			dgrpcserver.WithPostUnaryInterceptor(dauthgrpc.UnaryAuthChecker(authenticator, logger)),
-			dgrpcserver.WithPostStreamInterceptor(dauthgrpc.StreamAuthChecker(authenticator, logger)),
+		if addr == "" {
+			// Servers of chains only reachable through the routing header have no listen address
+			continue
 		}
 
-		if serviceDiscoveryURL != nil {
-			options = append(options, dgrpcserver.WithServiceDiscoveryURL(serviceDiscoveryURL))
+		options := []dgrpcserver.Option{
+			dgrpcserver.WithLogger(logger),
+			dgrpcserver.WithHealthCheck(dgrpcserver.HealthCheckOverGRPC|dgrpcserver.HealthCheckOverHTTP, createHealthCheck(func(ctx context.Context) bool { return !s.IsDraining() && isReady(ctx) })),
 		}
 
 		if strings.Contains(addr, "*") {
@@ -109,39 +168,58 @@ func New(
 			options = append(options, dgrpcserver.WithPlainTextServer())
 		}
 
-		srv := factory.ServerFromOptions(options...)
+		if s.connectWeb {
+			options = append(options,
+				dgrpcserver.WithConnectInterceptor(dauthconnect.NewAuthInterceptor(authenticator, logger)),
+				dgrpcserver.WithConnectStrictContentType(false),
+				dgrpcserver.WithReflection(pbfirehoseV2.Stream_ServiceDesc.ServiceName),
+				dgrpcserver.WithReflection(pbfirehoseV2.Fetch_ServiceDesc.ServiceName),
+				dgrpcserver.WithReflection(pbbatchfetch.BatchFetch_ServiceDesc.ServiceName),
+				dgrpcserver.WithReflection(pbfirehoseV2.EndpointInfo_ServiceDesc.ServiceName),
+				dgrpcserver.WithPermissiveCORS(),
+			)
 
-		servers = append(servers, &wrappedServer{
-			Server:     srv,
-			listenAddr: addr,
-		})
+			srv, err := newConnectWebServer(s.connectHandlerGetters(), tracerProvider, options...)
+			if err != nil {
+				panic(fmt.Errorf("unable to create connect server: %w", err))
+			}
 
-	}
+			s.servers = append(s.servers, &wrappedServer{
+				launchableServer: srv,
+				listenAddr:       addr,
+			})
+			continue
+		}
 
-	s := &Server{
-		servers:           servers,
-		transformRegistry: transformRegistry,
-		blockGetter:       blockGetter,
-		streamFactory:     streamFactory,
-		initFunc:          initFunc,
-		postHookFunc:      postHookFunc,
-		logger:            logger,
-	}
+		options = append(options,
+			dgrpcserver.WithPostUnaryInterceptor(otelgrpc.UnaryServerInterceptor(otelgrpc.WithTracerProvider(tracerProvider))),
+			dgrpcserver.WithPostStreamInterceptor(otelgrpc.StreamServerInterceptor(otelgrpc.WithTracerProvider(tracerProvider))),
+			dgrpcserver.WithGRPCServerOptions(grpc.MaxRecvMsgSize(maxRecvMsgSize)),
+			dgrpcserver.WithPostUnaryInterceptor(dauthgrpc.UnaryAuthChecker(authenticator, logger)),
+			dgrpcserver.WithPostStreamInterceptor(dauthgrpc.StreamAuthChecker(authenticator, logger)),
+		)
+
+		if serviceDiscoveryURL != nil {
+			options = append(options, dgrpcserver.WithServiceDiscoveryURL(serviceDiscoveryURL))
+		}
+
+		srv := factory.ServerFromOptions(options...)
 
-	logger.Info("registering grpc services")
-	for _, srv := range servers {
+		logger.Info("registering grpc services", zap.String("listen_addr", addr))
 		srv.RegisterService(func(gs grpc.ServiceRegistrar) {
 			if blockGetter != nil {
 				pbfirehoseV2.RegisterFetchServer(gs, s)
+				pbbatchfetch.RegisterBatchFetchServer(gs, &batchFetchServer{server: s})
 			}
-			pbfirehoseV2.RegisterEndpointInfoServer(gs, infoServer)
+			pbfirehoseV2.RegisterEndpointInfoServer(gs, s)
 			pbfirehoseV2.RegisterStreamServer(gs, s)
 			pbfirehoseV1.RegisterStreamServer(gs, NewFirehoseProxyV1ToV2(s)) // compatibility with firehose
 		})
-	}
 
-	for _, opt := range opts {
-		opt(s)
+		s.servers = append(s.servers, &wrappedServer{
+			launchableServer: srv,
+			listenAddr:       addr,
+		})
 	}
 
 	return s
@@ -157,6 +235,10 @@ func (s *Server) Shutdown(timeout time.Duration) {
 	for _, server := range s.servers {
 		server.Shutdown(timeout)
 	}
+
+	for _, httpServer := range s.httpServers {
+		httpServer.shutdown(timeout)
+	}
 }
 
 func (s *Server) Launch() {
@@ -171,6 +253,18 @@ func (s *Server) Launch() {
 			wg.Done()
 		}()
 	}
+
+	for _, httpServer := range s.httpServers {
+		go func() {
+			if err := httpServer.launch(); err != nil {
+				s.logger.Error("firehose http server failed", zap.String("name", httpServer.name), zap.Error(err))
+				for _, srv := range s.servers {
+					srv.Shutdown(0) // the gRPC servers terminating shuts the app down, like when one of them fails
+				}
+			}
+		}()
+	}
+
 	wg.Wait()
 }
 
@@ -25,6 +25,7 @@ import (
 	"github.com/streamingfast/dstore"
 	"github.com/streamingfast/firehose-core/merger"
 	"github.com/streamingfast/firehose-core/merger/metrics"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 	"github.com/streamingfast/shutter"
 	"go.uber.org/zap"
 	pbhealth "google.golang.org/grpc/health/grpc_health_v1"
@@ -35,6 +36,9 @@ type Config struct {
 	StorageMergedBlocksFilesPath string
 	StorageForkedBlocksFilesPath string
 
+	// StorageBlockHashIndexPath, when set, is the store where a block hash index file is written for each merged bundle
+	StorageBlockHashIndexPath string
+
 	FilesDeleteThreads int
 
 	GRPCListenAddr string
@@ -44,6 +48,9 @@ type Config struct {
 	TimeBetweenPruning time.Duration
 	TimeBetweenPolling time.Duration
 	StopBlock          uint64
+
+	// ChainIdentity, when set, is the well-known chain whose genesis block and checkpoints the merged blocks must match
+	ChainIdentity *wellknown.Chain
 }
 
 type App struct {
@@ -82,6 +89,14 @@ func (a *App) Run() error {
 		}
 	}
 
+	var blockHashIndexStore dstore.Store
+	if a.config.StorageBlockHashIndexPath != "" {
+		blockHashIndexStore, err = dstore.NewStore(a.config.StorageBlockHashIndexPath, "", "", false)
+		if err != nil {
+			return fmt.Errorf("failed to init block hash index store: %w", err)
+		}
+	}
+
 	bundleSize := uint64(100)
 
 	// we are setting the backoff here for dstoreIO
@@ -91,6 +106,7 @@ func (a *App) Run() error {
 		oneBlockStoreStore,
 		mergedBlocksStore,
 		forkedBlocksStore,
+		blockHashIndexStore,
 		5,
 		500*time.Millisecond,
 		bundleSize,
@@ -107,6 +123,7 @@ func (a *App) Run() error {
 		a.config.TimeBetweenPolling,
 		a.config.StopBlock,
 	)
+	m.VerifyChainIdentity(a.config.ChainIdentity)
 	zlog.Info("merger initiated")
 
 	gs, err := dgrpc.NewInternalClient(a.config.GRPCListenAddr)
@@ -28,6 +28,7 @@ import (
 	"github.com/streamingfast/bstream"
 	"github.com/streamingfast/bstream/forkable"
 	"github.com/streamingfast/firehose-core/merger/metrics"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 	"github.com/streamingfast/logging"
 	"go.uber.org/zap"
 )
@@ -53,6 +54,9 @@ type Bundler struct {
 	irreversibleBlocks []*bstream.OneBlockFile
 	forkable           *forkable.Forkable
 
+	// chainIdentity, when set, is the well-known chain whose genesis block and checkpoints the irreversible blocks must match
+	chainIdentity *wellknown.Chain
+
 	logger *zap.Logger
 }
 
@@ -146,6 +150,11 @@ func (b *Bundler) ProcessBlock(_ *pbbstream.Block, obj interface{}) error {
 		return nil
 	}
 
+	if err := b.chainIdentity.VerifyBlockIDSuffix(obf.Num, obf.ID); err != nil {
+		b.logger.Error("refusing to merge a block from another chain, check the one-block files store", zap.Stringer("block", obf), zap.Error(err))
+		return err
+	}
+
 	if b.enforceNextBlockOnBoundary {
 		if obf.Num != b.baseBlockNum && obf.Num != b.firstStreamableBlock {
 			//{"severity":"ERROR","timestamp":"2023-11-07T12:28:34.735713163-05:00","logger":"merger","message":"expecting to start at block `base_block_num` but got block `block_num` (and we have no previous blockID to align with..). First streamable block is configured to be: `first_streamable_block`",
@@ -12,6 +12,7 @@ import (
 	//	"github.com/streamingfast/bstream"
 	//"github.com/streamingfast/firehose-core/merger/bundle"
 	"github.com/streamingfast/bstream"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -224,3 +225,29 @@ func TestBundlerMergeKeepOne(t *testing.T) {
 		})
 	}
 }
+
+func TestBundlerChainIdentityMismatch(t *testing.T) {
+	var merged []uint64
+	b := NewBundler(100, 700, 2, 2, &TestMergerIO{
+		MergeAndStoreFunc: func(_ context.Context, inclusiveLowerBlock uint64, _ []*bstream.OneBlockFile) (err error) {
+			merged = append(merged, inclusiveLowerBlock)
+			return nil
+		},
+	})
+	b.chainIdentity = &wellknown.Chain{
+		Name:           "acme-mainnet",
+		GenesisBlockID: "00",
+		Checkpoints:    []*wellknown.Checkpoint{{Number: 101, ID: "ffff0000000000000101b"}},
+	}
+
+	require.NoError(t, b.HandleBlockFile(block100()))
+	require.NoError(t, b.HandleBlockFile(block101()))
+	require.NoError(t, b.HandleBlockFile(block102Final100()))
+
+	// Block 101 becomes irreversible, it does not match the checkpoint
+	assert.ErrorIs(t, b.HandleBlockFile(block103Final101()), wellknown.ErrChainIdentityMismatch)
+
+	b.inProcess.Lock()
+	b.inProcess.Unlock()
+	assert.Empty(t, merged)
+}
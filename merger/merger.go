@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/streamingfast/bstream"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 	"github.com/streamingfast/shutter"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -69,6 +70,12 @@ func NewMerger(
 	return m
 }
 
+// VerifyChainIdentity makes the merger stop with an error instead of merging an irreversible block at
+// the genesis block or at a checkpoint of [chain] which does not have the expected ID.
+func (m *Merger) VerifyChainIdentity(chain *wellknown.Chain) {
+	m.bundler.chainIdentity = chain
+}
+
 func (m *Merger) Run() {
 	m.logger.Info("starting merger")
 
@@ -16,6 +16,7 @@ import (
 
 	"github.com/streamingfast/bstream"
 	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/firehose/blockhash"
 	"github.com/streamingfast/firehose-core/merger/metrics"
 	"github.com/streamingfast/logging"
 	"go.uber.org/zap"
@@ -61,6 +62,9 @@ type DStoreIO struct {
 	oneBlocksStore    dstore.Store
 	mergedBlocksStore dstore.Store
 
+	// blockHashIndexStore, when set, receives a block hash index file for each merged bundle
+	blockHashIndexStore dstore.Store
+
 	retryAttempts int
 	retryCooldown time.Duration
 
@@ -78,6 +82,7 @@ func NewDStoreIO(
 	oneBlocksStore dstore.Store,
 	mergedBlocksStore dstore.Store,
 	forkedBlocksStore dstore.Store,
+	blockHashIndexStore dstore.Store,
 	retryAttempts int,
 	retryCooldown time.Duration,
 	bundleSize uint64,
@@ -87,14 +92,15 @@ func NewDStoreIO(
 	od := &oneBlockFilesDeleter{store: oneBlocksStore, logger: logger}
 	od.Start(numDeleteThreads, DefaultFilesDeleteBatchSize*2)
 	dstoreIO := &DStoreIO{
-		oneBlocksStore:    oneBlocksStore,
-		mergedBlocksStore: mergedBlocksStore,
-		retryAttempts:     retryAttempts,
-		retryCooldown:     retryCooldown,
-		bundleSize:        bundleSize,
-		logger:            logger,
-		tracer:            tracer,
-		od:                od,
+		oneBlocksStore:      oneBlocksStore,
+		mergedBlocksStore:   mergedBlocksStore,
+		blockHashIndexStore: blockHashIndexStore,
+		retryAttempts:       retryAttempts,
+		retryCooldown:       retryCooldown,
+		bundleSize:          bundleSize,
+		logger:              logger,
+		tracer:              tracer,
+		od:                  od,
 	}
 
 	forkAware := forkedBlocksStore != nil
@@ -156,9 +162,50 @@ func (s *DStoreIO) MergeAndStore(ctx context.Context, inclusiveLowerBlock uint64
 
 	s.logger.Info("merged and uploaded", zap.String("filename", fileNameForBlocksBundle(inclusiveLowerBlock)), zap.Duration("merge_time", time.Since(t0)))
 
+	if s.blockHashIndexStore != nil {
+		s.writeBlockHashIndex(ctx, inclusiveLowerBlock, filteredOBF)
+	}
+
 	return
 }
 
+// writeBlockHashIndex writes the block hash index of the bundle. One-block filenames only hold a truncated
+// block ID so full IDs are read from the blocks data, already downloaded when the bundle was merged.
+//
+// A failure is only logged: the merged bundle is already written and would not be merged again, blocks
+// of this bundle are then only retrievable by number.
+func (s *DStoreIO) writeBlockHashIndex(ctx context.Context, baseBlockNum uint64, oneBlockFiles []*bstream.OneBlockFile) {
+	refs := make([]bstream.BlockRef, 0, len(oneBlockFiles))
+	for _, obf := range oneBlockFiles {
+		data, err := obf.Data(ctx, s.DownloadOneBlockFile)
+		if err != nil {
+			s.logger.Error("unable to write block hash index, cannot get one block data", zap.Stringer("block", obf), zap.Error(err))
+			return
+		}
+
+		blk, err := bstream.DecodeOneblockfileData(data)
+		if err != nil {
+			s.logger.Error("unable to write block hash index, cannot decode one block data", zap.Stringer("block", obf), zap.Error(err))
+			return
+		}
+
+		refs = append(refs, blk.AsRef())
+	}
+
+	err := Retry(s.logger, s.retryAttempts, s.retryCooldown, func() error {
+		inCtx, cancel := context.WithTimeout(ctx, WriteObjectTimeout)
+		defer cancel()
+
+		return blockhash.WriteIndex(inCtx, s.blockHashIndexStore, baseBlockNum, s.bundleSize, refs)
+	})
+	if err != nil {
+		s.logger.Error("unable to write block hash index", zap.Uint64("base_block_num", baseBlockNum), zap.Error(err))
+		return
+	}
+
+	s.logger.Debug("wrote block hash index", zap.String("filename", blockhash.IndexFilename(baseBlockNum, s.bundleSize)))
+}
+
 func (s *DStoreIO) WalkOneBlockFiles(ctx context.Context, lowestBlock uint64, callback func(*bstream.OneBlockFile) error) error {
 	return s.oneBlocksStore.WalkFrom(ctx, "", fileNameForBlocksBundle(lowestBlock), func(filename string) error {
 		if strings.HasSuffix(filename, ".tmp") {
@@ -29,6 +29,7 @@ import (
 
 	"github.com/streamingfast/bstream"
 	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/firehose/blockhash"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,6 +40,7 @@ func TestNewDstore(t *testing.T) {
 		dstore.NewMockStore(nil),
 		dstore.NewMockStore(nil),
 		dstore.NewMockStore(nil),
+		nil,
 		1,
 		0,
 		100,
@@ -55,6 +57,7 @@ func TestNewDstore(t *testing.T) {
 		dstore.NewMockStore(nil),
 		dstore.NewMockStore(nil),
 		nil,
+		nil,
 		1,
 		0,
 		100,
@@ -69,7 +72,7 @@ func newTestDStoreIO(
 	oneBlocksStore dstore.Store,
 	mergedBlocksStore dstore.Store,
 ) IOInterface {
-	return NewDStoreIO(testLogger, testTracer, oneBlocksStore, mergedBlocksStore, nil, 0, 0, 100, 0)
+	return NewDStoreIO(testLogger, testTracer, oneBlocksStore, mergedBlocksStore, nil, nil, 0, 0, 100, 0)
 }
 
 func TestMergerIO_MergeUploadPerfect(t *testing.T) {
@@ -233,3 +236,39 @@ func TestMergerIO_MergeUploadFilteredToZero(t *testing.T) {
 	err := mio.MergeAndStore(context.Background(), 114, files)
 	require.NoError(t, err)
 }
+
+func TestMergerIO_MergeUploadWritesBlockHashIndex(t *testing.T) {
+	oneBlockFile := func(filename, id, previousID string) *bstream.OneBlockFile {
+		obf := bstream.MustNewOneBlockFile(filename)
+
+		out := new(bytes.Buffer)
+		w, err := bstream.NewDBinBlockWriter(out)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(bstream.TestBlockWithNumbers(id, previousID, obf.Num, obf.Num-1)))
+
+		obf.MemoizeData = out.Bytes()
+		return obf
+	}
+
+	files := []*bstream.OneBlockFile{
+		oneBlockFile("0000000100-0000000000000100a-0000000000000099a-98-suffix", "00000000000000000000000000000100a", "00000000000000000000000000000099a"),
+		oneBlockFile("0000000101-0000000000000101a-0000000000000100a-99-suffix", "00000000000000000000000000000101a", "00000000000000000000000000000100a"),
+	}
+
+	blockHashIndexStore := dstore.NewMockStore(nil)
+	mio := NewDStoreIO(testLogger, testTracer, dstore.NewMockStore(nil), dstore.NewMockStore(nil), nil, blockHashIndexStore, 0, 0, 100, 0)
+
+	require.NoError(t, mio.MergeAndStore(context.Background(), 100, files))
+
+	reader, err := blockHashIndexStore.OpenObject(context.Background(), blockhash.IndexFilename(100, 100))
+	require.NoError(t, err)
+	defer reader.Close()
+
+	indexed := map[string]uint64{}
+	require.NoError(t, blockhash.ReadIndex(reader, func(id string, num uint64) { indexed[id] = num }))
+
+	require.Equal(t, map[string]uint64{
+		"00000000000000000000000000000100a": 100,
+		"00000000000000000000000000000101a": 101,
+	}, indexed)
+}
@@ -0,0 +1,111 @@
+package metering
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/dmetering"
+)
+
+type aggregatorKey struct{}
+
+// Aggregator sums the metering events of a single request, hence of a single user, API key and
+// endpoint, into one event emitted once [window] elapsed since the first event it holds or once it
+// holds [maxBlocks] blocks, whichever comes first, and on [Aggregator.Flush] which must be called
+// when the request ends. Metrics are summed so that totals are the same as emitting each event.
+type Aggregator struct {
+	window    time.Duration
+	maxBlocks uint64
+	emit      func(ctx context.Context, event dmetering.Event)
+
+	mu      sync.Mutex
+	ctx     context.Context
+	pending *dmetering.Event
+	timer   *time.Timer
+}
+
+// NewAggregator creates an aggregator emitting to the default emitter (or the request's substreams
+// emitter), a zero [window] or [maxBlocks] disables the corresponding limit.
+func NewAggregator(window time.Duration, maxBlocks uint64) *Aggregator {
+	return &Aggregator{
+		window:    window,
+		maxBlocks: maxBlocks,
+		emit:      emit,
+	}
+}
+
+// WithAggregator makes [Send] add the events of the request to [aggregator] instead of emitting them.
+func WithAggregator(ctx context.Context, aggregator *Aggregator) context.Context {
+	return context.WithValue(ctx, aggregatorKey{}, aggregator)
+}
+
+func getAggregator(ctx context.Context) *Aggregator {
+	aggregator, _ := ctx.Value(aggregatorKey{}).(*Aggregator)
+	return aggregator
+}
+
+// Add sums [event] into the pending event, emitting it when it reaches the blocks limit.
+func (a *Aggregator) Add(ctx context.Context, event dmetering.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.pending != nil && !sameIdentity(a.pending, &event) {
+		a.flush()
+	}
+
+	if a.pending == nil {
+		pending := event
+		pending.Metrics = make(map[string]float64, len(event.Metrics))
+		for name, value := range event.Metrics {
+			pending.Metrics[name] = value
+		}
+
+		a.pending = &pending
+		a.ctx = context.WithoutCancel(ctx)
+		if a.window > 0 {
+			a.timer = time.AfterFunc(a.window, a.Flush)
+		}
+	} else {
+		for name, value := range event.Metrics {
+			a.pending.Metrics[name] += value
+		}
+		a.pending.Timestamp = event.Timestamp
+	}
+
+	if a.maxBlocks > 0 && a.pending.Metrics[MeterBlockCount] >= float64(a.maxBlocks) {
+		a.flush()
+	}
+}
+
+// Flush emits the pending event, if any.
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.flush()
+}
+
+func (a *Aggregator) flush() {
+	if a.pending == nil {
+		return
+	}
+
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+
+	a.emit(a.ctx, *a.pending)
+	a.pending = nil
+	a.ctx = nil
+}
+
+func sameIdentity(a, b *dmetering.Event) bool {
+	return a.UserID == b.UserID &&
+		a.ApiKeyID == b.ApiKeyID &&
+		a.IpAddress == b.IpAddress &&
+		a.Meta == b.Meta &&
+		a.Endpoint == b.Endpoint &&
+		a.OutputModuleHash == b.OutputModuleHash
+}
@@ -0,0 +1,146 @@
+package metering
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/dmetering"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingEmitter struct {
+	sync.Mutex
+	events []dmetering.Event
+}
+
+func (e *recordingEmitter) Emit(_ context.Context, ev dmetering.Event) {
+	e.Lock()
+	defer e.Unlock()
+	e.events = append(e.events, ev)
+}
+
+func (e *recordingEmitter) Shutdown(error) {}
+
+func (e *recordingEmitter) Events() []dmetering.Event {
+	e.Lock()
+	defer e.Unlock()
+	return append([]dmetering.Event(nil), e.events...)
+}
+
+func (e *recordingEmitter) Totals() map[string]float64 {
+	totals := map[string]float64{}
+	for _, ev := range e.Events() {
+		for name, value := range ev.Metrics {
+			totals[name] += value
+		}
+	}
+	return totals
+}
+
+// sendBlocks simulates a stream of [count] blocks, each block reading and writing a different
+// amount of bytes, and returns the events emitted.
+func sendBlocks(t *testing.T, count int, aggregator *Aggregator) *recordingEmitter {
+	t.Helper()
+
+	emitter := &recordingEmitter{}
+	ctx := reqctx.WithEmitter(context.Background(), emitter)
+	if aggregator != nil {
+		ctx = WithAggregator(ctx, aggregator)
+	}
+
+	meter := dmetering.NewBytesMeter()
+	for i := 1; i <= count; i++ {
+		meter.AddBytesRead(i * 10)
+		meter.AddBytesWritten(i * 3)
+		meter.CountInc(MeterFileCompressedReadBytes, i*7)
+		meter.CountInc(MeterLiveUncompressedReadBytes, i)
+
+		resp := &pbfirehose.Response{Cursor: "cursor"}
+		Send(ctx, meter, "user", "key", "1.2.3.4", "meta", "sf.firehose.v2.Stream/Blocks", resp)
+	}
+
+	if aggregator != nil {
+		aggregator.Flush()
+	}
+
+	return emitter
+}
+
+func TestAggregator_SameTotalsAsPerBlockEmission(t *testing.T) {
+	perBlock := sendBlocks(t, 25, nil)
+	require.Len(t, perBlock.Events(), 25)
+
+	tests := []struct {
+		name           string
+		window         time.Duration
+		maxBlocks      uint64
+		expectedEvents int
+	}{
+		{"max blocks", 0, 10, 3},
+		{"max blocks dividing the stream", 0, 5, 5},
+		{"flush on end only", time.Hour, 0, 1},
+		{"window and max blocks", time.Hour, 20, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregated := sendBlocks(t, 25, NewAggregator(tt.window, tt.maxBlocks))
+
+			events := aggregated.Events()
+			require.Len(t, events, tt.expectedEvents)
+			assert.Equal(t, perBlock.Totals(), aggregated.Totals())
+
+			for _, ev := range events {
+				assert.Equal(t, "user", ev.UserID)
+				assert.Equal(t, "key", ev.ApiKeyID)
+				assert.Equal(t, "sf.firehose.v2.Stream/Blocks", ev.Endpoint)
+			}
+		})
+	}
+}
+
+func TestAggregator_Window(t *testing.T) {
+	emitter := &recordingEmitter{}
+	aggregator := NewAggregator(20*time.Millisecond, 0)
+	aggregator.emit = emitter.Emit
+
+	event := func() dmetering.Event {
+		return dmetering.Event{UserID: "user", Endpoint: "sf.firehose.v2.Stream/Blocks", Metrics: map[string]float64{MeterBlockCount: 1, "egress_bytes": 100}}
+	}
+
+	aggregator.Add(context.Background(), event())
+	aggregator.Add(context.Background(), event())
+	assert.Empty(t, emitter.Events())
+
+	require.Eventually(t, func() bool { return len(emitter.Events()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, map[string]float64{MeterBlockCount: 2, "egress_bytes": 200}, emitter.Events()[0].Metrics)
+
+	// Nothing left to emit on end
+	aggregator.Flush()
+	assert.Len(t, emitter.Events(), 1)
+
+	aggregator.Add(context.Background(), event())
+	aggregator.Flush()
+	require.Len(t, emitter.Events(), 2)
+	assert.Equal(t, map[string]float64{MeterBlockCount: 1, "egress_bytes": 100}, emitter.Events()[1].Metrics)
+}
+
+func TestAggregator_IdentityChange(t *testing.T) {
+	emitter := &recordingEmitter{}
+	aggregator := NewAggregator(0, 100)
+	aggregator.emit = emitter.Emit
+
+	aggregator.Add(context.Background(), dmetering.Event{UserID: "user", ApiKeyID: "a", Metrics: map[string]float64{MeterBlockCount: 1}})
+	aggregator.Add(context.Background(), dmetering.Event{UserID: "user", ApiKeyID: "b", Metrics: map[string]float64{MeterBlockCount: 1}})
+	aggregator.Flush()
+
+	events := emitter.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "a", events[0].ApiKeyID)
+	assert.Equal(t, "b", events[1].ApiKeyID)
+}
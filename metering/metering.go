@@ -23,6 +23,9 @@ const (
 	MeterFileCompressedReadBytes         = "file_compressed_read_bytes"
 
 	TotalReadBytes = "total_read_bytes"
+
+	MeterBlockCount  = "block_count"
+	MeterEgressBytes = "egress_bytes"
 )
 
 func WithBlockBytesReadMeteringOptions(meter dmetering.Meter, logger *zap.Logger) []dstore.Option {
@@ -37,6 +40,19 @@ func WithForkedBlockBytesReadMeteringOptions(meter dmetering.Meter, logger *zap.
 	})}
 }
 
+type usageObserverKey struct{}
+
+// WithUsageObserver makes [Send] call [observer] with every event of the request, before it is
+// aggregated or emitted, so that usage can be tracked from the exact values that are metered.
+func WithUsageObserver(ctx context.Context, observer func(event dmetering.Event)) context.Context {
+	return context.WithValue(ctx, usageObserverKey{}, observer)
+}
+
+func getUsageObserver(ctx context.Context) func(event dmetering.Event) {
+	observer, _ := ctx.Value(usageObserverKey{}).(func(event dmetering.Event))
+	return observer
+}
+
 func GetTotalBytesRead(meter dmetering.Meter) uint64 {
 	total := uint64(meter.GetCount(TotalReadBytes))
 	return total
@@ -67,7 +83,7 @@ func Send(ctx context.Context, meter dmetering.Meter, userID, apiKeyID, ip, user
 
 		Endpoint: endpoint,
 		Metrics: map[string]float64{
-			"egress_bytes":                       float64(egressBytes),
+			MeterEgressBytes:                     float64(egressBytes),
 			"written_bytes":                      float64(bytesWritten),
 			"read_bytes":                         float64(bytesRead),
 			MeterLiveUncompressedReadBytes:       float64(liveUncompressedReadBytes),
@@ -76,11 +92,24 @@ func Send(ctx context.Context, meter dmetering.Meter, userID, apiKeyID, ip, user
 			MeterFileUncompressedReadForkedBytes: float64(fileUncompressedReadForkedBytes),
 			MeterFileCompressedReadForkedBytes:   float64(fileCompressedReadForkedBytes),
 			MeterFileCompressedReadBytes:         float64(fileCompressedReadBytes),
-			"block_count":                        1,
+			MeterBlockCount:                      1,
 		},
 		Timestamp: time.Now(),
 	}
 
+	if observer := getUsageObserver(ctx); observer != nil {
+		observer(event)
+	}
+
+	if aggregator := getAggregator(ctx); aggregator != nil {
+		aggregator.Add(ctx, event)
+		return
+	}
+
+	emit(ctx, event)
+}
+
+func emit(ctx context.Context, event dmetering.Event) {
 	emitter := reqctx.Emitter(ctx)
 	if emitter == nil {
 		dmetering.Emit(context.WithoutCancel(ctx), event)
@@ -0,0 +1,232 @@
+// Package prometheus is a metering plugin exposing the usage reported by the metering events as
+// Prometheus counters, labeled by `user_id`, `api_key_id` and `endpoint`, for deployments without
+// a metering backend.
+//
+// It is selected with `prometheus://` as the metering plugin, accepting the following query
+// parameters to bound the number of series (distinct `user_id`, `api_key_id` and `endpoint`
+// label values) it creates:
+//
+//   - `max-series` (default `1000`): process-wide, once reached, the usage of new series is counted under the
+//     [OverflowLabel] `user_id` and `api_key_id` (see the `metering_series_overflow_events` metric)
+//   - `max-label-length` (default `64`): label values are truncated to this length
+//   - `api-key-label` (default `true`): when `false`, the `api_key_id` label is left empty, counting
+//     the usage of every API key of a user together
+//   - `series-ttl` (default `0`, never): series not updated for this duration are removed, making
+//     room for new ones, their counters restart from zero if they come back
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/dmetrics"
+	"github.com/streamingfast/firehose-core/metering"
+	"go.uber.org/zap"
+)
+
+// OverflowLabel is the `user_id` and `api_key_id` label value of the usage of the series created
+// after `max-series` was reached.
+const OverflowLabel = "__overflow__"
+
+const (
+	defaultMaxSeries      = 1000
+	defaultMaxLabelLength = 64
+)
+
+var labels = []string{"user_id", "api_key_id", "endpoint"}
+
+var MetricSet = dmetrics.NewSet()
+
+// counters are the usage counters, by name of the event metric they count, other event metrics are ignored
+var counters = map[string]*dmetrics.CounterVec{
+	metering.MeterEgressBytes: MetricSet.NewCounterVec("metering_egress_bytes", labels, "Bytes sent to clients"),
+	"written_bytes":           MetricSet.NewCounterVec("metering_written_bytes", labels, "Bytes written"),
+	"read_bytes":              MetricSet.NewCounterVec("metering_read_bytes", labels, "Bytes read"),
+	metering.MeterBlockCount:  MetricSet.NewCounterVec("metering_block_count", labels, "Blocks sent to clients"),
+
+	metering.MeterLiveUncompressedReadBytes:       MetricSet.NewCounterVec("metering_live_uncompressed_read_bytes", labels, "Uncompressed bytes of the live blocks read"),
+	metering.MeterLiveUncompressedReadForkedBytes: MetricSet.NewCounterVec("metering_live_uncompressed_read_forked_bytes", labels, "Uncompressed bytes of the live forked blocks read"),
+	metering.MeterFileUncompressedReadBytes:       MetricSet.NewCounterVec("metering_file_uncompressed_read_bytes", labels, "Uncompressed bytes of the merged blocks files read"),
+	metering.MeterFileUncompressedReadForkedBytes: MetricSet.NewCounterVec("metering_file_uncompressed_read_forked_bytes", labels, "Uncompressed bytes of the forked blocks files read"),
+	metering.MeterFileCompressedReadBytes:         MetricSet.NewCounterVec("metering_file_compressed_read_bytes", labels, "Compressed bytes of the merged blocks files read"),
+	metering.MeterFileCompressedReadForkedBytes:   MetricSet.NewCounterVec("metering_file_compressed_read_forked_bytes", labels, "Compressed bytes of the forked blocks files read"),
+}
+
+var Series = MetricSet.NewGauge("metering_series", "Number of label sets (user, API key and endpoint) of the metering counters")
+var SeriesOverflowEvents = MetricSet.NewCounter("metering_series_overflow_events", "Number of metering events counted under the overflow labels because 'max-series' was reached")
+
+func Register() {
+	dmetering.Register("prometheus", func(config string, logger *zap.Logger) (dmetering.EventEmitter, error) {
+		c, err := newConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config string %s: %w", config, err)
+		}
+
+		dmetrics.Register(MetricSet)
+		return newEmitter(c), nil
+	})
+}
+
+type config struct {
+	maxSeries      int
+	maxLabelLength int
+	apiKeyLabel    bool
+	seriesTTL      time.Duration
+}
+
+func newConfig(configURL string) (*config, error) {
+	u, err := url.Parse(configURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &config{
+		maxSeries:      defaultMaxSeries,
+		maxLabelLength: defaultMaxLabelLength,
+		apiKeyLabel:    true,
+	}
+
+	query := u.Query()
+	if value := query.Get("max-series"); value != "" {
+		if c.maxSeries, err = strconv.Atoi(value); err != nil || c.maxSeries <= 0 {
+			return nil, fmt.Errorf("invalid max-series value %q, must be a positive integer", value)
+		}
+	}
+
+	if value := query.Get("max-label-length"); value != "" {
+		if c.maxLabelLength, err = strconv.Atoi(value); err != nil || c.maxLabelLength <= 0 {
+			return nil, fmt.Errorf("invalid max-label-length value %q, must be a positive integer", value)
+		}
+	}
+
+	if value := query.Get("api-key-label"); value != "" {
+		if c.apiKeyLabel, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid api-key-label value %q, must be 'true' or 'false'", value)
+		}
+	}
+
+	if value := query.Get("series-ttl"); value != "" {
+		if c.seriesTTL, err = time.ParseDuration(value); err != nil || c.seriesTTL < 0 {
+			return nil, fmt.Errorf("invalid series-ttl value %q, must be a duration", value)
+		}
+	}
+
+	return c, nil
+}
+
+type series struct {
+	userID   string
+	apiKeyID string
+	endpoint string
+}
+
+func (s series) labelValues() []string {
+	return []string{s.userID, s.apiKeyID, s.endpoint}
+}
+
+// seriesTracker records when each series of the counters was last updated. Like the counters, it is
+// shared by every emitter of the process, `dmetering.New` being called more than once (Substreams
+// tier2 creates an emitter per request), so that `max-series` bounds the series of the process.
+type seriesTracker struct {
+	mu        sync.Mutex
+	lastSeen  map[series]time.Time
+	lastSweep time.Time
+}
+
+var tracker = &seriesTracker{lastSeen: make(map[series]time.Time)}
+
+type emitter struct {
+	config *config
+	now    func() time.Time
+}
+
+func newEmitter(config *config) *emitter {
+	return &emitter{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+func (e *emitter) Emit(_ context.Context, ev dmetering.Event) {
+	key := series{
+		userID:   e.truncate(ev.UserID),
+		endpoint: e.truncate(ev.Endpoint),
+	}
+	if e.config.apiKeyLabel {
+		key.apiKeyID = e.truncate(ev.ApiKeyID)
+	}
+
+	// The counters are updated under the lock so that a concurrent sweep cannot delete the series
+	// between it being recorded as seen and its usage being added, leaving it untracked.
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	key = e.track(key)
+	for name, value := range ev.Metrics {
+		if counter, found := counters[name]; found && value > 0 {
+			counter.AddFloat64(value, key.labelValues()...)
+		}
+	}
+}
+
+// track returns the series the usage of [key] is counted under, recording it as seen, the
+// tracker lock must be held.
+func (e *emitter) track(key series) series {
+	now := e.now()
+	e.sweep(now)
+
+	if _, found := tracker.lastSeen[key]; !found && len(tracker.lastSeen) >= e.config.maxSeries {
+		SeriesOverflowEvents.Inc()
+		key = series{userID: OverflowLabel, apiKeyID: OverflowLabel, endpoint: key.endpoint}
+	}
+
+	// Overflow series are tracked like the others, they are bounded by the number of endpoints
+	tracker.lastSeen[key] = now
+	Series.SetUint64(uint64(len(tracker.lastSeen)))
+
+	return key
+}
+
+// sweep removes the series not seen for `series-ttl`, scanning them at most every half of it, the
+// tracker lock must be held.
+func (e *emitter) sweep(now time.Time) {
+	if e.config.seriesTTL == 0 || now.Sub(tracker.lastSweep) < e.config.seriesTTL/2 {
+		return
+	}
+	tracker.lastSweep = now
+
+	for key, lastSeen := range tracker.lastSeen {
+		if now.Sub(lastSeen) < e.config.seriesTTL {
+			continue
+		}
+
+		for _, counter := range counters {
+			counter.DeleteLabelValues(key.labelValues()...)
+		}
+		delete(tracker.lastSeen, key)
+	}
+}
+
+// truncate bounds [value] to `max-label-length` bytes, label values must be valid UTF-8 so
+// invalid sequences are replaced and truncation happens on a rune boundary.
+func (e *emitter) truncate(value string) string {
+	value = strings.ToValidUTF8(value, "\uFFFD")
+	if len(value) <= e.config.maxLabelLength {
+		return value
+	}
+
+	end := e.config.maxLabelLength
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return value[:end]
+}
+
+func (e *emitter) Shutdown(error) {}
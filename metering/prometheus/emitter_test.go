@@ -0,0 +1,123 @@
+package prometheus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/firehose-core/metering"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetCounters() {
+	for _, counter := range counters {
+		counter.Native().Reset()
+	}
+	tracker = &seriesTracker{lastSeen: make(map[series]time.Time)}
+}
+
+func counterValue(name string, labelValues ...string) float64 {
+	return testutil.ToFloat64(counters[name].Native().WithLabelValues(labelValues...))
+}
+
+func event(userID, apiKeyID string, egressBytes float64) dmetering.Event {
+	return dmetering.Event{
+		UserID:   userID,
+		ApiKeyID: apiKeyID,
+		Endpoint: "sf.firehose.v2.Firehose/Blocks",
+		Metrics: map[string]float64{
+			metering.MeterEgressBytes:             egressBytes,
+			metering.MeterBlockCount:              1,
+			metering.MeterFileCompressedReadBytes: 7,
+			"unknown_metric":                      1,
+		},
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	c, err := newConfig("prometheus://")
+	require.NoError(t, err)
+	assert.Equal(t, &config{maxSeries: defaultMaxSeries, maxLabelLength: defaultMaxLabelLength, apiKeyLabel: true}, c)
+
+	c, err = newConfig("prometheus://?max-series=10&max-label-length=8&api-key-label=false&series-ttl=1h")
+	require.NoError(t, err)
+	assert.Equal(t, &config{maxSeries: 10, maxLabelLength: 8, apiKeyLabel: false, seriesTTL: time.Hour}, c)
+
+	_, err = newConfig("prometheus://?max-series=0")
+	assert.ErrorContains(t, err, `invalid max-series value "0"`)
+}
+
+func TestEmitter_Counters(t *testing.T) {
+	resetCounters()
+	c, err := newConfig("prometheus://")
+	require.NoError(t, err)
+	e := newEmitter(c)
+
+	e.Emit(context.Background(), event("alice", "key1", 100))
+	e.Emit(context.Background(), event("alice", "key1", 50))
+	e.Emit(context.Background(), event("alice", "key2", 10))
+
+	endpoint := "sf.firehose.v2.Firehose/Blocks"
+	assert.Equal(t, float64(150), counterValue(metering.MeterEgressBytes, "alice", "key1", endpoint))
+	assert.Equal(t, float64(2), counterValue(metering.MeterBlockCount, "alice", "key1", endpoint))
+	assert.Equal(t, float64(14), counterValue(metering.MeterFileCompressedReadBytes, "alice", "key1", endpoint))
+	assert.Equal(t, float64(10), counterValue(metering.MeterEgressBytes, "alice", "key2", endpoint))
+}
+
+func TestEmitter_CardinalitySafeguards(t *testing.T) {
+	resetCounters()
+	c, err := newConfig("prometheus://?max-series=2&max-label-length=5&api-key-label=false&series-ttl=1m")
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := newEmitter(c)
+	e.now = func() time.Time { return now }
+
+	endpoint := "sf.f"
+	overflowBefore := testutil.ToFloat64(SeriesOverflowEvents.Native())
+
+	e.Emit(context.Background(), dmetering.Event{UserID: "alice-long-id", ApiKeyID: "key1", Endpoint: endpoint, Metrics: map[string]float64{metering.MeterBlockCount: 1}})
+	e.Emit(context.Background(), dmetering.Event{UserID: "alice-long-id", ApiKeyID: "key2", Endpoint: endpoint, Metrics: map[string]float64{metering.MeterBlockCount: 1}})
+	e.Emit(context.Background(), dmetering.Event{UserID: "bob", Endpoint: endpoint, Metrics: map[string]float64{metering.MeterBlockCount: 1}})
+	e.Emit(context.Background(), dmetering.Event{UserID: "carol", Endpoint: endpoint, Metrics: map[string]float64{metering.MeterBlockCount: 1}})
+
+	// Truncated user ID, API keys counted together
+	assert.Equal(t, float64(2), counterValue(metering.MeterBlockCount, "alice", "", endpoint))
+	assert.Equal(t, float64(1), counterValue(metering.MeterBlockCount, "bob", "", endpoint))
+	// Third series, over the limit
+	assert.Equal(t, float64(1), counterValue(metering.MeterBlockCount, OverflowLabel, OverflowLabel, endpoint))
+	assert.Equal(t, float64(1), testutil.ToFloat64(SeriesOverflowEvents.Native())-overflowBefore)
+
+	// Idle series expire, making room for new ones
+	now = now.Add(2 * time.Minute)
+	e.Emit(context.Background(), dmetering.Event{UserID: "carol", Endpoint: endpoint, Metrics: map[string]float64{metering.MeterBlockCount: 1}})
+	assert.Equal(t, float64(1), counterValue(metering.MeterBlockCount, "carol", "", endpoint))
+	assert.Equal(t, 1, testutil.CollectAndCount(counters[metering.MeterBlockCount].Native()))
+}
+
+func TestEmitter_SharedSeriesLimit(t *testing.T) {
+	resetCounters()
+	c, err := newConfig("prometheus://?max-series=1")
+	require.NoError(t, err)
+
+	// Substreams tier2 creates an emitter per request, the limit applies to all of them
+	first, second := newEmitter(c), newEmitter(c)
+	first.Emit(context.Background(), event("alice", "key1", 100))
+	second.Emit(context.Background(), event("bob", "key1", 10))
+
+	endpoint := "sf.firehose.v2.Firehose/Blocks"
+	assert.Equal(t, float64(100), counterValue(metering.MeterEgressBytes, "alice", "key1", endpoint))
+	assert.Equal(t, float64(10), counterValue(metering.MeterEgressBytes, OverflowLabel, OverflowLabel, endpoint))
+	assert.Equal(t, 2, testutil.CollectAndCount(counters[metering.MeterEgressBytes].Native()))
+}
+
+func TestEmitter_Truncate(t *testing.T) {
+	e := newEmitter(&config{maxLabelLength: 4})
+
+	assert.Equal(t, "abcd", e.truncate("abcdef"))
+	assert.Equal(t, "abc", e.truncate("abcé"))
+	assert.Equal(t, "a�", e.truncate("a\xff"))
+}
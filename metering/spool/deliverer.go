@@ -0,0 +1,110 @@
+package spool
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/streamingfast/dgrpc"
+	pbmetering "github.com/streamingfast/dmetering/pb/sf/metering/v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// EventIDsHeader is the gRPC request header listing the [Record.ID] of every event sent in an
+// `sf.metering.v1.Metering/Emit` call, comma separated and in order, so that the backend can
+// deduplicate the events replayed after a failure it did not report.
+const EventIDsHeader = "x-metering-event-ids"
+
+// EventIDFieldNumber is the field of the `sf.metering.v1.Event` messages holding their [Record.ID],
+// as a string. The field is not part of the `sf.metering.v1` definitions, backends declaring it
+// (`string event_id = 100;`) read the ID of each event alongside it, others ignore it.
+const EventIDFieldNumber protowire.Number = 100
+
+const deliveryTimeout = 10 * time.Second
+
+// Deliverer sends spooled records to the metering backend. A nil error means the backend
+// acknowledged the records, which are then removed from the spool.
+type Deliverer interface {
+	Deliver(ctx context.Context, records []*Record) error
+	Close()
+}
+
+// NewDeliverer creates the deliverer of the metering plugin configured by [plugin] (see
+// `common-metering-plugin`). Only the `grpc://` plugin is supported, its backend response
+// acknowledges the events, the other plugins accept events without reporting whether they were
+// delivered so the spool would drop them on the first failure.
+func NewDeliverer(plugin string) (Deliverer, error) {
+	u, err := url.Parse(plugin)
+	if err != nil {
+		return nil, fmt.Errorf("parse metering plugin: %w", err)
+	}
+
+	if u.Scheme != "grpc" {
+		return nil, fmt.Errorf("metering spool requires the 'grpc://' metering plugin, the %q plugin does not acknowledge delivered events", u.Scheme)
+	}
+
+	return newGRPCDeliverer(u)
+}
+
+type grpcDeliverer struct {
+	client  pbmetering.MeteringClient
+	close   func() error
+	network string
+}
+
+// newGRPCDeliverer accepts the same `grpc://host:port?network=<name>` URL as the dmetering `grpc`
+// plugin, its batching parameters are ignored, the spool batches events itself.
+func newGRPCDeliverer(u *url.URL) (*grpcDeliverer, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("metering plugin endpoint not specified (as hostname)")
+	}
+
+	network := u.Query().Get("network")
+	if network == "" {
+		return nil, fmt.Errorf("metering plugin network not specified (as query param)")
+	}
+
+	conn, err := dgrpc.NewInternalNoWaitClientConn(u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("create metering gRPC client: %w", err)
+	}
+
+	return &grpcDeliverer{
+		client:  pbmetering.NewMeteringClient(conn),
+		close:   conn.Close,
+		network: network,
+	}, nil
+}
+
+func (d *grpcDeliverer) Deliver(ctx context.Context, records []*Record) error {
+	events := make([]*pbmetering.Event, len(records))
+	ids := make([]string, len(records))
+	for i, record := range records {
+		events[i] = eventWithID(record.Event.ToProto(d.network), record.ID)
+		ids[i] = record.ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	ctx = metadata.AppendToOutgoingContext(ctx, EventIDsHeader, strings.Join(ids, ","))
+	_, err := d.client.Emit(ctx, &pbmetering.Events{Events: events})
+	return err
+}
+
+func (d *grpcDeliverer) Close() {
+	d.close()
+}
+
+// eventWithID sets [id] in the [EventIDFieldNumber] field of [event].
+func eventWithID(event *pbmetering.Event, id string) *pbmetering.Event {
+	field := protowire.AppendTag(nil, EventIDFieldNumber, protowire.BytesType)
+	field = protowire.AppendString(field, id)
+
+	message := event.ProtoReflect()
+	message.SetUnknown(append(message.GetUnknown(), field...))
+	return event
+}
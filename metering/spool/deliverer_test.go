@@ -0,0 +1,45 @@
+package spool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewDeliverer_RequiresGRPCPlugin(t *testing.T) {
+	for _, plugin := range []string{"null://", "logger://", "prometheus://"} {
+		_, err := NewDeliverer(plugin)
+		assert.ErrorContains(t, err, "requires the 'grpc://' metering plugin", plugin)
+	}
+}
+
+func TestEventWithID(t *testing.T) {
+	event := eventWithID(testEvent(10).ToProto("network"), "instance-42")
+
+	encoded, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	var ids []string
+	for len(encoded) > 0 {
+		number, kind, n := protowire.ConsumeTag(encoded)
+		require.GreaterOrEqual(t, n, 0)
+		encoded = encoded[n:]
+
+		if number == EventIDFieldNumber && kind == protowire.BytesType {
+			id, n := protowire.ConsumeString(encoded)
+			require.GreaterOrEqual(t, n, 0)
+			ids = append(ids, id)
+		}
+
+		n = protowire.ConsumeFieldValue(number, kind, encoded)
+		require.GreaterOrEqual(t, n, 0)
+		encoded = encoded[n:]
+	}
+	assert.Equal(t, []string{"instance-42"}, ids)
+
+	// The known fields are untouched
+	assert.Equal(t, "sf.firehose.v2.Stream/Blocks", event.Endpoint)
+}
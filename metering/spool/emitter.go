@@ -0,0 +1,335 @@
+// Package spool buffers metering events in a write-ahead log on local disk and replays them to the
+// metering backend, so that usage is not lost while the backend is unavailable.
+//
+// The spool is configured through a path (or `file://` URL) to its directory, with the following
+// query parameters:
+//
+//   - `max-size` (default `1GiB`): events emitted while the spool holds this size are dropped (see the
+//     `metering_spool_dropped_events` metric), `0` means unbounded
+//   - `fsync` (default `interval`): `always` flushes every event to disk before emitting returns,
+//     `interval` flushes them every `fsync-interval` (default `1s`) and `never` leaves it to the
+//     operating system
+//   - `batch` (default `100`): events sent to the backend at once
+//   - `flush-interval` (default `1s`): how often newly spooled events are sent to the backend
+//
+// Only the `grpc://` metering plugin is supported. Delivery is at-least-once: events are removed
+// from the spool once the backend acknowledged them and are replayed, with the same [Record.ID],
+// after a delivery failure or a restart. The IDs are sent in each event (see [EventIDFieldNumber])
+// and in the [EventIDsHeader] request header for the backend to deduplicate.
+package spool
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/streamingfast/dmetering"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxSize       = 1024 * 1024 * 1024
+	defaultFsyncInterval = time.Second
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+
+	// shutdownDeliveryTimeout bounds the delivery of the remaining events on shutdown, the events
+	// not delivered by then stay spooled until the next start
+	shutdownDeliveryTimeout = 5 * time.Second
+)
+
+type options struct {
+	dir           string
+	maxSize       int64
+	fsync         FsyncPolicy
+	fsyncInterval time.Duration
+	batchSize     int
+	flushInterval time.Duration
+}
+
+func parseOptions(config string) (*options, error) {
+	u, err := url.Parse(config)
+	if err != nil {
+		return nil, fmt.Errorf("parse metering spool %q: %w", config, err)
+	}
+
+	if u.Scheme != "" && u.Scheme != "file" {
+		return nil, fmt.Errorf("unsupported metering spool %q, must be a local path", config)
+	}
+
+	o := &options{
+		dir:           u.Path,
+		maxSize:       defaultMaxSize,
+		fsync:         FsyncInterval,
+		fsyncInterval: defaultFsyncInterval,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+	}
+
+	if o.dir == "" {
+		return nil, fmt.Errorf("metering spool %q has no directory", config)
+	}
+
+	query := u.Query()
+	if value := query.Get("max-size"); value != "" {
+		size, err := humanize.ParseBytes(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max-size value %q, must be a size like '1GiB'", value)
+		}
+		o.maxSize = int64(size)
+	}
+
+	if value := query.Get("fsync"); value != "" {
+		if o.fsync, err = ParseFsyncPolicy(value); err != nil {
+			return nil, err
+		}
+	}
+
+	if value := query.Get("fsync-interval"); value != "" {
+		if o.fsyncInterval, err = time.ParseDuration(value); err != nil || o.fsyncInterval <= 0 {
+			return nil, fmt.Errorf("invalid fsync-interval value %q, must be a positive duration", value)
+		}
+	}
+
+	if value := query.Get("batch"); value != "" {
+		if o.batchSize, err = strconv.Atoi(value); err != nil || o.batchSize <= 0 {
+			return nil, fmt.Errorf("invalid batch value %q, must be a positive integer", value)
+		}
+	}
+
+	if value := query.Get("flush-interval"); value != "" {
+		if o.flushInterval, err = time.ParseDuration(value); err != nil || o.flushInterval <= 0 {
+			return nil, fmt.Errorf("invalid flush-interval value %q, must be a positive duration", value)
+		}
+	}
+
+	return o, nil
+}
+
+// Emitter is a [dmetering.EventEmitter] spooling the events it receives and delivering them to
+// the metering backend from a single goroutine.
+type Emitter struct {
+	options   *options
+	spool     *Spool
+	deliverer Deliverer
+	logger    *zap.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+
+	// oldest is when the oldest event not acknowledged yet was spooled, as of the last delivery attempt
+	oldestMu sync.Mutex
+	oldest   time.Time
+}
+
+// New opens the spool configured by [config] (see the package documentation) delivering to the
+// metering plugin configured by [plugin] (see [NewDeliverer]).
+func New(config string, plugin string, logger *zap.Logger) (*Emitter, error) {
+	o, err := parseOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	deliverer, err := NewDeliverer(plugin)
+	if err != nil {
+		return nil, err
+	}
+
+	emitter, err := newEmitter(o, deliverer, logger)
+	if err != nil {
+		deliverer.Close()
+		return nil, err
+	}
+
+	return emitter, nil
+}
+
+func newEmitter(o *options, deliverer Deliverer, logger *zap.Logger) (*Emitter, error) {
+	logger = logger.Named("metering.spool")
+
+	spool, err := Open(o.dir, o.maxSize, o.fsync, logger)
+	if err != nil {
+		return nil, fmt.Errorf("open metering spool: %w", err)
+	}
+
+	e := &Emitter{
+		options:   o,
+		spool:     spool,
+		deliverer: deliverer,
+		logger:    logger,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	logger.Info("spooling metering events", zap.String("dir", o.dir), zap.Int64("max_size", o.maxSize), zap.String("fsync", string(o.fsync)))
+	go e.run()
+
+	return e, nil
+}
+
+// Emit spools [ev], it only blocks on the local disk write (and flush with the `always` fsync policy).
+func (e *Emitter) Emit(_ context.Context, ev dmetering.Event) {
+	if ev.Endpoint == "" {
+		e.logger.Warn("events must contain endpoint, dropping event", zap.Object("event", ev))
+		return
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		SpoolDroppedEvents.Inc()
+		e.logger.Warn("metering spool is shutting down, dropping event", zap.Object("event", ev))
+		return
+	}
+
+	if err := e.spool.Append(ev); err != nil {
+		SpoolDroppedEvents.Inc()
+		e.logger.Warn("unable to spool metering event, dropping it", zap.Object("event", ev), zap.Error(err))
+		return
+	}
+
+	e.updateMetrics()
+}
+
+// Shutdown stops accepting events, delivers the spooled ones for a short while and closes the
+// spool, the events not delivered are replayed on the next start.
+func (e *Emitter) Shutdown(err error) {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	e.mu.Unlock()
+
+	close(e.stop)
+	<-e.done
+
+	if err := e.spool.Close(); err != nil {
+		e.logger.Warn("unable to close metering spool", zap.Error(err))
+	}
+
+	if pending, _ := e.spool.Stats(); pending > 0 {
+		e.logger.Info("metering events left in spool, they will be delivered on next start", zap.Int("pending_events", pending))
+	}
+
+	e.deliverer.Close()
+}
+
+func (e *Emitter) run() {
+	defer close(e.done)
+
+	flushTicker := time.NewTicker(e.options.flushInterval)
+	defer flushTicker.Stop()
+
+	var fsyncTicks <-chan time.Time
+	if e.options.fsync == FsyncInterval {
+		fsyncTicker := time.NewTicker(e.options.fsyncInterval)
+		defer fsyncTicker.Stop()
+		fsyncTicks = fsyncTicker.C
+	}
+
+	retryDelay := time.Duration(0)
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-e.stop:
+			e.drain()
+			return
+
+		case <-fsyncTicks:
+			if err := e.spool.Sync(); err != nil {
+				e.logger.Warn("unable to sync metering spool", zap.Error(err))
+			}
+			continue
+
+		case <-flushTicker.C:
+			if retry != nil {
+				e.updateMetrics()
+				continue
+			}
+
+		case <-retry:
+			retry = nil
+		}
+
+		if err := e.deliverAll(context.Background()); err != nil {
+			SpoolDeliveryErrors.Inc()
+
+			retryDelay = min(max(2*retryDelay, minRetryDelay), maxRetryDelay)
+			retry = time.After(retryDelay)
+			e.logger.Warn("unable to deliver spooled metering events, retrying", zap.Duration("retry_in", retryDelay), zap.Error(err))
+			continue
+		}
+
+		retryDelay = 0
+	}
+}
+
+// deliverAll delivers the spooled events until the spool is empty or a delivery fails.
+func (e *Emitter) deliverAll(ctx context.Context) error {
+	for {
+		batch, err := e.spool.Read(e.options.batchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(batch.Records) == 0 {
+			e.setOldest(time.Time{})
+			return nil
+		}
+
+		e.setOldest(batch.Records[0].SpooledAt)
+		if err := e.deliverer.Deliver(ctx, batch.Records); err != nil {
+			return err
+		}
+
+		if err := e.spool.Ack(batch); err != nil {
+			return fmt.Errorf("acknowledge delivered events: %w", err)
+		}
+	}
+}
+
+func (e *Emitter) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownDeliveryTimeout)
+	defer cancel()
+
+	if err := e.deliverAll(ctx); err != nil {
+		e.logger.Warn("unable to deliver spooled metering events on shutdown", zap.Error(err))
+	}
+}
+
+func (e *Emitter) setOldest(oldest time.Time) {
+	e.oldestMu.Lock()
+	e.oldest = oldest
+	e.oldestMu.Unlock()
+
+	e.updateMetrics()
+}
+
+func (e *Emitter) updateMetrics() {
+	pending, size := e.spool.Stats()
+	SpoolDepth.SetUint64(uint64(pending))
+	SpoolSizeBytes.SetUint64(uint64(size))
+
+	e.oldestMu.Lock()
+	oldest := e.oldest
+	e.oldestMu.Unlock()
+
+	if pending == 0 || oldest.IsZero() {
+		SpoolOldestEventAge.SetFloat64(0)
+		return
+	}
+
+	SpoolOldestEventAge.SetFloat64(time.Since(oldest).Seconds())
+}
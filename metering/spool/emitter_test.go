@@ -0,0 +1,138 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testDeliverer struct {
+	sync.Mutex
+
+	// failures is the number of upcoming deliveries failing, negative fails them all
+	failures int
+	// lostAck records the failing deliveries, as a backend whose response was lost would
+	lostAck bool
+
+	delivered []*Record
+}
+
+func (d *testDeliverer) Deliver(_ context.Context, records []*Record) error {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.failures != 0 {
+		d.failures--
+		if d.lostAck {
+			d.delivered = append(d.delivered, records...)
+		}
+		return errors.New("backend unavailable")
+	}
+
+	d.delivered = append(d.delivered, records...)
+	return nil
+}
+
+func (d *testDeliverer) Close() {}
+
+func (d *testDeliverer) Delivered() []*Record {
+	d.Lock()
+	defer d.Unlock()
+	return append([]*Record(nil), d.delivered...)
+}
+
+func testOptions(dir string) *options {
+	return &options{
+		dir:           dir,
+		fsync:         FsyncInterval,
+		fsyncInterval: 10 * time.Millisecond,
+		batchSize:     4,
+		flushInterval: 10 * time.Millisecond,
+	}
+}
+
+func TestEmitter_ReplaysAfterBackendOutage(t *testing.T) {
+	deliverer := &testDeliverer{failures: 1, lostAck: true}
+	emitter, err := newEmitter(testOptions(t.TempDir()), deliverer, zap.NewNop())
+	require.NoError(t, err)
+	defer emitter.Shutdown(nil)
+
+	for i := 1; i <= 10; i++ {
+		emitter.Emit(context.Background(), testEvent(float64(i)))
+	}
+
+	unique := func() map[string]float64 {
+		out := map[string]float64{}
+		for _, record := range deliverer.Delivered() {
+			out[record.ID] = record.Event.Metrics["egress_bytes"]
+		}
+		return out
+	}
+
+	require.Eventually(t, func() bool { return len(unique()) == 10 }, 5*time.Second, 10*time.Millisecond)
+
+	// The batch whose acknowledgment was lost is delivered twice, with the same IDs
+	delivered := deliverer.Delivered()
+	assert.Greater(t, len(delivered), 10)
+	assert.Equal(t, delivered[0].ID, delivered[len(delivered)-10].ID)
+
+	total := 0.0
+	for _, egress := range unique() {
+		total += egress
+	}
+	assert.Equal(t, float64(55), total)
+
+	pending, _ := emitter.spool.Stats()
+	assert.Equal(t, 0, pending)
+}
+
+func TestEmitter_ReplaysAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	down := &testDeliverer{failures: -1}
+	emitter, err := newEmitter(testOptions(dir), down, zap.NewNop())
+	require.NoError(t, err)
+
+	for i := 1; i <= 5; i++ {
+		emitter.Emit(context.Background(), testEvent(float64(i)))
+	}
+	emitter.Shutdown(nil)
+	assert.Empty(t, down.Delivered())
+
+	// Emitted after shutdown, dropped
+	emitter.Emit(context.Background(), testEvent(6))
+
+	up := &testDeliverer{}
+	emitter, err = newEmitter(testOptions(dir), up, zap.NewNop())
+	require.NoError(t, err)
+	defer emitter.Shutdown(nil)
+
+	require.Eventually(t, func() bool { return len(up.Delivered()) == 5 }, 5*time.Second, 10*time.Millisecond)
+	for i, record := range up.Delivered() {
+		assert.Equal(t, float64(i+1), record.Event.Metrics["egress_bytes"])
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	o, err := parseOptions("/data/spool?max-size=10MiB&fsync=always&batch=50&flush-interval=2s")
+	require.NoError(t, err)
+	assert.Equal(t, &options{dir: "/data/spool", maxSize: 10 * 1024 * 1024, fsync: FsyncAlways, fsyncInterval: time.Second, batchSize: 50, flushInterval: 2 * time.Second}, o)
+
+	o, err = parseOptions("file:///data/spool")
+	require.NoError(t, err)
+	assert.Equal(t, "/data/spool", o.dir)
+	assert.Equal(t, FsyncInterval, o.fsync)
+	assert.Equal(t, int64(defaultMaxSize), o.maxSize)
+
+	_, err = parseOptions("/data/spool?fsync=sometimes")
+	assert.ErrorContains(t, err, `invalid fsync policy "sometimes"`)
+
+	_, err = parseOptions("gs://bucket/spool")
+	assert.ErrorContains(t, err, "must be a local path")
+}
@@ -0,0 +1,11 @@
+package spool
+
+import "github.com/streamingfast/dmetrics"
+
+var MetricSet = dmetrics.NewSet()
+
+var SpoolDepth = MetricSet.NewGauge("metering_spool_depth", "Number of metering events spooled on disk and not yet acknowledged by the metering backend")
+var SpoolSizeBytes = MetricSet.NewGauge("metering_spool_size_bytes", "Size in bytes of the metering spool segments on disk")
+var SpoolOldestEventAge = MetricSet.NewGauge("metering_spool_oldest_event_age_seconds", "Age in seconds of the oldest metering event not yet acknowledged by the metering backend, 0 when the spool is empty")
+var SpoolDroppedEvents = MetricSet.NewCounter("metering_spool_dropped_events", "Number of metering events dropped because the spool was full, closed or failed to write them")
+var SpoolDeliveryErrors = MetricSet.NewCounter("metering_spool_delivery_errors", "Number of failed attempts to deliver spooled metering events to the metering backend")
@@ -0,0 +1,568 @@
+package spool
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/dmetering"
+	"go.uber.org/zap"
+)
+
+// ErrSpoolFull is returned by [Spool.Append] when the event would make the spool exceed its maximum size.
+var ErrSpoolFull = errors.New("metering spool is full")
+
+const (
+	segmentSuffix      = ".spool"
+	stateFileName      = "state.json"
+	recordHeaderSize   = 8
+	defaultSegmentSize = 8 * 1024 * 1024
+
+	// maxRecordSize bounds the payload of a record, a metering event takes a few hundred bytes so a
+	// longer length read from disk can only come from a corrupted or torn record
+	maxRecordSize = 1024 * 1024
+)
+
+// FsyncPolicy controls when the spool files are flushed to stable storage.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways flushes every appended event before [Spool.Append] returns
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval flushes the appended events periodically, see [Spool.Sync]
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever leaves flushing to the operating system
+	FsyncNever FsyncPolicy = "never"
+)
+
+func ParseFsyncPolicy(in string) (FsyncPolicy, error) {
+	switch policy := FsyncPolicy(in); policy {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("invalid fsync policy %q, must be one of 'always', 'interval' or 'never'", in)
+}
+
+// Record is a spooled event, [Record.ID] identifies it across replays so that the backend can
+// deduplicate the events it receives more than once.
+type Record struct {
+	Seq       uint64          `json:"seq"`
+	ID        string          `json:"id"`
+	SpooledAt time.Time       `json:"spooled_at"`
+	Event     dmetering.Event `json:"event"`
+}
+
+// Batch is a run of the oldest records not acknowledged yet, see [Spool.Read] and [Spool.Ack].
+type Batch struct {
+	Records []*Record
+
+	end position
+}
+
+type position struct {
+	segment int
+	offset  int64
+}
+
+type segment struct {
+	firstSeq uint64
+	path     string
+	size     int64
+}
+
+type state struct {
+	InstanceID string `json:"instance_id"`
+	AckedSeq   uint64 `json:"acked_seq"`
+}
+
+// Spool is a write-ahead log of metering events on local disk. Events are appended to segment
+// files, named after the sequence number of their first event, and read back in order until they
+// are acknowledged, at which point the fully acknowledged segments are deleted. The last
+// acknowledged sequence number is persisted so that events are replayed after a restart if, and
+// only if, they were not acknowledged.
+type Spool struct {
+	dir         string
+	maxSize     int64
+	segmentSize int64
+	fsync       FsyncPolicy
+	logger      *zap.Logger
+
+	mu       sync.Mutex
+	state    state
+	segments []*segment
+	active   *os.File
+	cursor   position
+	nextSeq  uint64
+	size     int64
+	pending  int
+	dirty    bool
+}
+
+// Open opens the spool stored in [dir], creating it when needed, and recovers the events that were
+// not acknowledged. A record partially written when the process stopped is discarded.
+func Open(dir string, maxSize int64, fsync FsyncPolicy, logger *zap.Logger) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create spool directory: %w", err)
+	}
+
+	s := &Spool{
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: defaultSegmentSize,
+		fsync:       fsync,
+		logger:      logger,
+		nextSeq:     1,
+	}
+
+	if err := s.loadState(); err != nil {
+		return nil, err
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Spool) loadState() error {
+	content, err := os.ReadFile(filepath.Join(s.dir, stateFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read spool state: %w", err)
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(content, &s.state); err != nil {
+			return fmt.Errorf("decode spool state: %w", err)
+		}
+	}
+
+	if s.state.InstanceID == "" {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("generate spool instance id: %w", err)
+		}
+
+		s.state.InstanceID = hex.EncodeToString(id)
+		return s.writeState()
+	}
+
+	return nil
+}
+
+// writeState atomically replaces the state file, it is always flushed as losing it would replay
+// every spooled event.
+func (s *Spool) writeState() error {
+	content, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+
+	temp := filepath.Join(s.dir, stateFileName+".tmp")
+	file, err := os.Create(temp)
+	if err != nil {
+		return fmt.Errorf("write spool state: %w", err)
+	}
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return fmt.Errorf("write spool state: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync spool state: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write spool state: %w", err)
+	}
+
+	if err := os.Rename(temp, filepath.Join(s.dir, stateFileName)); err != nil {
+		return fmt.Errorf("write spool state: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Spool) recover() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("list spool segments: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		firstSeq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		s.segments = append(s.segments, &segment{firstSeq: firstSeq, path: filepath.Join(s.dir, name)})
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].firstSeq < s.segments[j].firstSeq })
+
+	for i, seg := range s.segments {
+		last := i == len(s.segments)-1
+		validSize, err := s.scanSegment(i, seg, last)
+		if err != nil {
+			return err
+		}
+
+		seg.size = validSize
+		s.size += validSize
+	}
+
+	if s.pending == 0 && len(s.segments) > 0 {
+		// Everything was acknowledged, reading resumes with the next appended event
+		last := len(s.segments) - 1
+		s.cursor = position{segment: last, offset: s.segments[last].size}
+	}
+
+	if s.nextSeq <= s.state.AckedSeq {
+		s.nextSeq = s.state.AckedSeq + 1
+	}
+
+	if s.pending > 0 {
+		s.logger.Info("recovered metering spool", zap.String("dir", s.dir), zap.Int("pending_events", s.pending), zap.Int64("size", s.size))
+	}
+
+	return s.deleteConsumedSegments()
+}
+
+// scanSegment reads every record of [seg] to count the pending ones and find where reading must
+// start, a torn record at the end of the [last] segment is truncated.
+func (s *Spool) scanSegment(index int, seg *segment, last bool) (int64, error) {
+	file, err := os.Open(seg.path)
+	if err != nil {
+		return 0, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat spool segment: %w", err)
+	}
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		record, size, err := readRecord(reader, stat.Size()-offset)
+		if err == io.EOF {
+			return offset, nil
+		}
+
+		if err != nil {
+			if !last {
+				return 0, fmt.Errorf("spool segment %q is corrupted at offset %d: %w", seg.path, offset, err)
+			}
+
+			s.logger.Warn("truncating torn record at the end of metering spool segment", zap.String("segment", seg.path), zap.Int64("offset", offset), zap.Error(err))
+			if err := os.Truncate(seg.path, offset); err != nil {
+				return 0, fmt.Errorf("truncate spool segment: %w", err)
+			}
+			return offset, nil
+		}
+
+		if record.Seq > s.state.AckedSeq {
+			if s.pending == 0 {
+				s.cursor = position{segment: index, offset: offset}
+			}
+			s.pending++
+		}
+
+		s.nextSeq = record.Seq + 1
+		offset += size
+	}
+}
+
+// Append writes [event] at the end of the spool.
+func (s *Spool) Append(event dmetering.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := &Record{
+		Seq:       s.nextSeq,
+		ID:        s.state.InstanceID + "-" + strconv.FormatUint(s.nextSeq, 10),
+		SpooledAt: time.Now(),
+		Event:     event,
+	}
+
+	encoded, err := encodeRecord(record)
+	if err != nil {
+		return fmt.Errorf("encode spooled event: %w", err)
+	}
+
+	if s.maxSize > 0 && s.size+int64(len(encoded)) > s.maxSize {
+		return ErrSpoolFull
+	}
+
+	if err := s.ensureActiveSegment(); err != nil {
+		return err
+	}
+
+	current := s.segments[len(s.segments)-1]
+	if _, err := s.active.Write(encoded); err != nil {
+		// Drop whatever was partially written so that the segment stays readable
+		s.active.Truncate(current.size)
+		s.active.Seek(current.size, io.SeekStart)
+		return fmt.Errorf("append spooled event: %w", err)
+	}
+
+	if s.fsync == FsyncAlways {
+		if err := s.active.Sync(); err != nil {
+			return fmt.Errorf("sync spool segment: %w", err)
+		}
+	} else {
+		s.dirty = true
+	}
+
+	current.size += int64(len(encoded))
+	s.size += int64(len(encoded))
+	s.nextSeq++
+	s.pending++
+
+	return nil
+}
+
+func (s *Spool) ensureActiveSegment() error {
+	if s.active != nil && s.segments[len(s.segments)-1].size < s.segmentSize {
+		return nil
+	}
+
+	if s.active != nil {
+		if err := s.active.Sync(); err != nil {
+			return fmt.Errorf("sync spool segment: %w", err)
+		}
+		s.active.Close()
+		s.active = nil
+	}
+
+	// After a restart, appending goes on in the last segment if it still has room
+	if count := len(s.segments); count > 0 && s.segments[count-1].size < s.segmentSize {
+		file, err := os.OpenFile(s.segments[count-1].path, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("open spool segment: %w", err)
+		}
+
+		s.active = file
+		return nil
+	}
+
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d%s", s.nextSeq, segmentSuffix))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("create spool segment: %w", err)
+	}
+
+	s.active = file
+	s.segments = append(s.segments, &segment{firstSeq: s.nextSeq, path: path})
+	return nil
+}
+
+// Sync flushes the appended events to stable storage, it is a no-op if nothing was appended since
+// the last call.
+func (s *Spool) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sync()
+}
+
+func (s *Spool) sync() error {
+	if !s.dirty || s.active == nil {
+		return nil
+	}
+
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("sync spool segment: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// Read returns up to [max] of the oldest records not acknowledged yet, without consuming them:
+// the same records are returned until the batch is passed to [Spool.Ack].
+func (s *Spool) Read(max int) (*Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch := &Batch{end: s.cursor}
+	for batch.end.segment < len(s.segments) && len(batch.Records) < max {
+		seg := s.segments[batch.end.segment]
+		if batch.end.offset >= seg.size {
+			if batch.end.segment == len(s.segments)-1 {
+				break
+			}
+
+			batch.end = position{segment: batch.end.segment + 1}
+			continue
+		}
+
+		file, err := os.Open(seg.path)
+		if err != nil {
+			return nil, fmt.Errorf("open spool segment: %w", err)
+		}
+
+		// Only the bytes known to hold complete records are read, an append might be in progress
+		reader := bufio.NewReader(io.NewSectionReader(file, batch.end.offset, seg.size-batch.end.offset))
+		for len(batch.Records) < max {
+			record, size, err := readRecord(reader, seg.size-batch.end.offset)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("read spool segment %q at offset %d: %w", seg.path, batch.end.offset, err)
+			}
+
+			batch.Records = append(batch.Records, record)
+			batch.end.offset += size
+		}
+		file.Close()
+	}
+
+	return batch, nil
+}
+
+// Ack marks the records of [batch] as delivered, they are never returned by [Spool.Read] again,
+// even after a restart.
+func (s *Spool) Ack(batch *Batch) error {
+	if len(batch.Records) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.AckedSeq = batch.Records[len(batch.Records)-1].Seq
+	if err := s.writeState(); err != nil {
+		return err
+	}
+
+	s.cursor = batch.end
+	s.pending -= len(batch.Records)
+
+	return s.deleteConsumedSegments()
+}
+
+// deleteConsumedSegments removes the segments before the one being read, every record they hold
+// has been acknowledged.
+func (s *Spool) deleteConsumedSegments() error {
+	consumed := s.cursor.segment
+	if consumed > len(s.segments)-1 {
+		// The last segment is kept, it is the one events are appended to
+		consumed = len(s.segments) - 1
+	}
+
+	if consumed <= 0 {
+		return nil
+	}
+
+	for _, seg := range s.segments[:consumed] {
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("delete spool segment: %w", err)
+		}
+		s.size -= seg.size
+	}
+
+	s.segments = append([]*segment(nil), s.segments[consumed:]...)
+	s.cursor.segment -= consumed
+	return nil
+}
+
+// Stats returns the number of events not acknowledged yet and the size of the spool on disk.
+func (s *Spool) Stats() (pending int, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pending, s.size
+}
+
+// Close flushes and closes the spool, the events not acknowledged are replayed once it is opened again.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return nil
+	}
+
+	err := s.active.Sync()
+	s.active.Close()
+	s.active = nil
+	return err
+}
+
+// encodeRecord frames [record] as its length and CRC32 checksum, both big endian uint32, followed
+// by its JSON encoding.
+func encodeRecord(record *Record) ([]byte, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) > maxRecordSize {
+		return nil, fmt.Errorf("record of %d bytes exceeds the maximum of %d bytes", len(payload), maxRecordSize)
+	}
+
+	encoded := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(encoded[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(encoded[4:8], crc32.ChecksumIEEE(payload))
+	copy(encoded[recordHeaderSize:], payload)
+
+	return encoded, nil
+}
+
+// readRecord returns [io.EOF] only when [reader] ends right at a record boundary, [remaining] is the
+// number of bytes left to read from [reader], a record length past it is reported as truncated.
+func readRecord(reader io.Reader, remaining int64) (*Record, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, 0, fmt.Errorf("truncated record header")
+		}
+		return nil, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecordSize || int64(length) > remaining-recordHeaderSize {
+		return nil, 0, fmt.Errorf("truncated record: length %d exceeds the %d bytes left or the maximum record size", length, remaining-recordHeaderSize)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, 0, fmt.Errorf("truncated record: %w", err)
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, 0, fmt.Errorf("record checksum mismatch")
+	}
+
+	record := &Record{}
+	if err := json.Unmarshal(payload, record); err != nil {
+		return nil, 0, fmt.Errorf("decode record: %w", err)
+	}
+
+	return record, int64(recordHeaderSize + len(payload)), nil
+}
@@ -0,0 +1,235 @@
+package spool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/dmetering"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func testEvent(egress float64) dmetering.Event {
+	return dmetering.Event{UserID: "user", Endpoint: "sf.firehose.v2.Stream/Blocks", Metrics: map[string]float64{"egress_bytes": egress}}
+}
+
+func appendEvents(t *testing.T, s *Spool, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		require.NoError(t, s.Append(testEvent(float64(i))))
+	}
+}
+
+func seqs(batch *Batch) (out []uint64) {
+	for _, record := range batch.Records {
+		out = append(out, record.Seq)
+	}
+	return
+}
+
+func TestSpool_ReadAck(t *testing.T) {
+	s, err := Open(t.TempDir(), 0, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	appendEvents(t, s, 1, 5)
+
+	batch, err := s.Read(3)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, seqs(batch))
+	assert.Equal(t, float64(1), batch.Records[0].Event.Metrics["egress_bytes"])
+
+	// Not acknowledged, read again
+	batch, err = s.Read(3)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, seqs(batch))
+
+	require.NoError(t, s.Ack(batch))
+	pending, _ := s.Stats()
+	assert.Equal(t, 2, pending)
+
+	batch, err = s.Read(10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{4, 5}, seqs(batch))
+	require.NoError(t, s.Ack(batch))
+
+	batch, err = s.Read(10)
+	require.NoError(t, err)
+	assert.Empty(t, batch.Records)
+
+	appendEvents(t, s, 6, 6)
+	batch, err = s.Read(10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{6}, seqs(batch))
+}
+
+func TestSpool_Recover(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 0, FsyncAlways, zap.NewNop())
+	require.NoError(t, err)
+	appendEvents(t, s, 1, 5)
+
+	batch, err := s.Read(2)
+	require.NoError(t, err)
+	require.NoError(t, s.Ack(batch))
+
+	pendingBatch, err := s.Read(10)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = Open(dir, 0, FsyncAlways, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	pending, _ := s.Stats()
+	assert.Equal(t, 3, pending)
+
+	// Replayed events keep their ID
+	replayed, err := s.Read(10)
+	require.NoError(t, err)
+	require.Len(t, replayed.Records, 3)
+	for i, record := range replayed.Records {
+		assert.Equal(t, pendingBatch.Records[i].ID, record.ID)
+	}
+
+	appendEvents(t, s, 6, 6)
+	batch, err = s.Read(10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3, 4, 5, 6}, seqs(batch))
+}
+
+func TestSpool_RecoverAllAcknowledged(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 0, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	appendEvents(t, s, 1, 3)
+	batch, err := s.Read(10)
+	require.NoError(t, err)
+	require.NoError(t, s.Ack(batch))
+	require.NoError(t, s.Close())
+
+	s, err = Open(dir, 0, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Appends go on in the last segment, reading must not skip them
+	appendEvents(t, s, 4, 4)
+	batch, err = s.Read(10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{4}, seqs(batch))
+}
+
+func TestSpool_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 0, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	appendEvents(t, s, 1, 3)
+	require.NoError(t, s.Close())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	file, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{0, 0, 1, 0, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	s, err = Open(dir, 0, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	appendEvents(t, s, 4, 4)
+	batch, err := s.Read(10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, seqs(batch))
+}
+
+func TestSpool_OversizedRecordLength(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 0, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	appendEvents(t, s, 1, 2)
+	require.NoError(t, s.Close())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	// A complete header announcing a 4 GiB record is a torn tail, not an allocation of its length
+	file, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	s, err = Open(dir, 0, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	appendEvents(t, s, 3, 3)
+	batch, err := s.Read(10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, seqs(batch))
+}
+
+func TestSpool_MaxSize(t *testing.T) {
+	s, err := Open(t.TempDir(), 500, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+
+	var err2 error
+	appended := 0
+	for ; appended < 100; appended++ {
+		if err2 = s.Append(testEvent(1)); err2 != nil {
+			break
+		}
+	}
+
+	assert.ErrorIs(t, err2, ErrSpoolFull)
+	assert.Greater(t, appended, 0)
+
+	pending, size := s.Stats()
+	assert.Equal(t, appended, pending)
+	assert.LessOrEqual(t, size, int64(500))
+}
+
+func TestSpool_Segments(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, 0, FsyncNever, zap.NewNop())
+	require.NoError(t, err)
+	defer s.Close()
+	s.segmentSize = 500
+
+	appendEvents(t, s, 1, 20)
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	assert.Greater(t, len(segments), 2)
+
+	var read []uint64
+	for {
+		batch, err := s.Read(3)
+		require.NoError(t, err)
+		if len(batch.Records) == 0 {
+			break
+		}
+		read = append(read, seqs(batch)...)
+		require.NoError(t, s.Ack(batch))
+	}
+	assert.Len(t, read, 20)
+	assert.Equal(t, uint64(20), read[19])
+
+	// Acknowledged segments are deleted, but the one being appended to
+	segments, err = filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	assert.Len(t, segments, 1)
+}
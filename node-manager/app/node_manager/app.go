@@ -16,6 +16,7 @@ package nodemanager
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -37,6 +38,7 @@ type Config struct {
 	StartupDelay time.Duration
 
 	HTTPAddr           string // was ManagerAPIAddress
+	HTTPTLS            bool   // operator HTTP API is served over TLS
 	ConnectionWatchdog bool
 
 	GRPCAddr string
@@ -113,14 +115,21 @@ func (a *App) IsReady() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 	defer cancel()
 
-	url := fmt.Sprintf("http://%s/healthz", a.config.HTTPAddr)
+	scheme := "http"
+	client := http.DefaultClient
+	if a.config.HTTPTLS {
+		// We are checking our own server, its certificate is not necessarily valid for the listen address
+		scheme = "https"
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	}
+
+	url := fmt.Sprintf("%s://%s/healthz", scheme, a.config.HTTPAddr)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		a.zlogger.Warn("unable to build get health request", zap.Error(err))
 		return false
 	}
 
-	client := http.DefaultClient
 	res, err := client.Do(req)
 	if err != nil {
 		a.zlogger.Debug("unable to execute get health request", zap.Error(err))
@@ -26,6 +26,7 @@ import (
 	nodeManager "github.com/streamingfast/firehose-core/node-manager"
 	logplugin "github.com/streamingfast/firehose-core/node-manager/log_plugin"
 	"github.com/streamingfast/firehose-core/node-manager/mindreader"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 	"github.com/streamingfast/logging"
 	pbheadinfo "github.com/streamingfast/pbgo/sf/headinfo/v1"
 	"github.com/streamingfast/shutter"
@@ -47,6 +48,9 @@ type Config struct {
 	// MaxLineLengthInBytes configures the maximum bytes a single line consumed can be
 	// without any error. If left unspecified or 0, the default is 50 MiB (50 * 1024 * 1024).
 	MaxLineLengthInBytes int64
+
+	// ChainIdentity, when set, is the well-known chain whose genesis block and checkpoints the read blocks must match
+	ChainIdentity *wellknown.Chain
 }
 
 type Modules struct {
@@ -104,6 +108,7 @@ func (a *App) Run() error {
 	if err != nil {
 		return err
 	}
+	mindreaderLogPlugin.VerifyChainIdentity(a.Config.ChainIdentity)
 
 	a.zlogger.Debug("configuring shutter")
 	mindreaderLogPlugin.OnTerminated(a.Shutdown)
@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/node-manager/operator"
+)
+
+const catalogTimeout = 5 * time.Minute
+
+// DstoreCatalog is a backup catalog storing each entry as a JSON object named `<module>/<backup>`
+// in a dstore. The catalog URL should be dedicated to it, files not being entries are skipped.
+type DstoreCatalog struct {
+	store dstore.Store
+}
+
+func NewDstoreCatalog(catalogURL string) (*DstoreCatalog, error) {
+	store, err := dstore.NewStore(catalogURL, "json", "", true)
+	if err != nil {
+		return nil, fmt.Errorf("backup catalog store %q: %w", catalogURL, err)
+	}
+
+	return &DstoreCatalog{store: store}, nil
+}
+
+func (c *DstoreCatalog) Record(entry *operator.BackupCatalogEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), catalogTimeout)
+	defer cancel()
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	name := entryName(entry.Module, entry.Name)
+	if err := c.store.WriteObject(ctx, name, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("write catalog entry %q: %w", name, err)
+	}
+	return nil
+}
+
+func (c *DstoreCatalog) Delete(module, backupName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), catalogTimeout)
+	defer cancel()
+
+	name := entryName(module, backupName)
+	if err := c.store.DeleteObject(ctx, name); err != nil && !errors.Is(err, dstore.ErrNotFound) {
+		return fmt.Errorf("delete catalog entry %q: %w", name, err)
+	}
+	return nil
+}
+
+func (c *DstoreCatalog) Entries() ([]*operator.BackupCatalogEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), catalogTimeout)
+	defer cancel()
+
+	var names []string
+	if err := c.store.Walk(ctx, "", func(filename string) error {
+		names = append(names, filename)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("list catalog entries: %w", err)
+	}
+
+	var entries []*operator.BackupCatalogEntry
+	for _, name := range names {
+		entry, err := c.readEntry(ctx, name)
+		if errors.Is(err, dstore.ErrNotFound) {
+			// Not a '.json' file, its name is walked with its extension
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	operator.SortBackupCatalogEntries(entries)
+	return entries, nil
+}
+
+func (c *DstoreCatalog) readEntry(ctx context.Context, name string) (*operator.BackupCatalogEntry, error) {
+	reader, err := c.store.OpenObject(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read catalog entry %q: %w", name, err)
+	}
+
+	entry := &operator.BackupCatalogEntry{}
+	if err := json.Unmarshal(content, entry); err != nil {
+		return nil, fmt.Errorf("decode catalog entry %q: %w", name, err)
+	}
+	return entry, nil
+}
+
+func entryName(module, backupName string) string {
+	return path.Join(module, backupName)
+}
@@ -0,0 +1,46 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/firehose-core/node-manager/operator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDstoreCatalog(t *testing.T) {
+	dir := t.TempDir()
+	catalog, err := NewDstoreCatalog(dir)
+	require.NoError(t, err)
+
+	entries, err := catalog.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := &operator.BackupCatalogEntry{Name: "node-000000000100-20240101T000000Z", Module: "tarball", BlockNum: 100, BlockHash: "aa", NodeVersion: "v1.0.0", SizeBytes: 10, DurationSeconds: 1.5, CreatedAt: at, URL: "gs://bucket/node-000000000100-20240101T000000Z.tar.zst"}
+	second := &operator.BackupCatalogEntry{Name: "nested/node-000000000200-20240101T010000Z", Module: "tarball", BlockNum: 200, CreatedAt: at.Add(time.Hour)}
+
+	require.NoError(t, catalog.Record(first))
+	require.NoError(t, catalog.Record(second))
+
+	// Files not written by the catalog are skipped
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("catalog"), 0o644))
+
+	entries, err = catalog.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, second.Name, entries[0].Name)
+	assert.Equal(t, first, entries[1])
+
+	require.NoError(t, catalog.Delete("tarball", second.Name))
+	require.NoError(t, catalog.Delete("tarball", "unknown"))
+
+	entries, err = catalog.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, first.Name, entries[0].Name)
+}
@@ -0,0 +1,446 @@
+// Package backup holds the reader node backup modules not tied to a cloud provider.
+package backup
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/firehose-core/node-manager/operator"
+	"go.uber.org/zap"
+)
+
+// TarballExtension is the extension of the backup archives, the same the tarball bootstrapper
+// (`--reader-node-bootstrap-data-url`) expects, so that any backup can bootstrap a new node.
+const TarballExtension = "tar.zst"
+
+const nameTimeLayout = "20060102T150405Z"
+
+// Tarball is a backup module archiving the node data directory as a zstd compressed tarball in a
+// dstore (local directory, GCS, S3, Azure, etc.), named after the last block seen by the node.
+type Tarball struct {
+	store        dstore.Store
+	dataDir      string
+	prefix       string
+	keep         int
+	requiresStop bool
+	logger       *zap.Logger
+
+	now func() time.Time
+}
+
+// NewTarballFactory returns the factory of the `tarball` backup module, backing up [dataDir]
+// unless its configuration sets `data-dir`. The configuration keys are:
+//
+//   - `store` (required): dstore URL the backups are written to
+//   - `prefix`: prefix of the backup names, can contain `/` to use a sub folder of the store
+//   - `keep`: number of backups kept, the oldest ones are deleted after each backup, `0` (default) keeps them all
+//   - `data-dir`: directory to back up, defaults to the node data directory
+//   - `requires-stop`: whether the node is stopped while backing up and restoring (default `true`),
+//     only disable it when the node data is consistent on disk while running
+func NewTarballFactory(dataDir string, logger *zap.Logger) operator.BackupModuleFactory {
+	return func(conf operator.BackupModuleConfig) (operator.BackupModule, error) {
+		return NewTarball(conf, dataDir, logger)
+	}
+}
+
+func NewTarball(conf operator.BackupModuleConfig, dataDir string, logger *zap.Logger) (*Tarball, error) {
+	storeURL := conf["store"]
+	if storeURL == "" {
+		return nil, fmt.Errorf("tarball backup requires a 'store'")
+	}
+
+	store, err := dstore.NewStore(storeURL, TarballExtension, "zstd", false)
+	if err != nil {
+		return nil, fmt.Errorf("tarball backup store %q: %w", storeURL, err)
+	}
+
+	t := &Tarball{
+		store:        store,
+		dataDir:      dataDir,
+		prefix:       conf["prefix"],
+		requiresStop: true,
+		logger:       logger.Named("backup.tarball"),
+		now:          time.Now,
+	}
+
+	if value := conf["data-dir"]; value != "" {
+		t.dataDir = value
+	}
+
+	if t.dataDir == "" {
+		return nil, fmt.Errorf("tarball backup requires a 'data-dir'")
+	}
+
+	if value := conf["keep"]; value != "" {
+		if t.keep, err = strconv.Atoi(value); err != nil || t.keep < 0 {
+			return nil, fmt.Errorf("invalid keep value %q, must be a positive integer", value)
+		}
+	}
+
+	if value := conf["requires-stop"]; value != "" {
+		if t.requiresStop, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid requires-stop value %q, must be 'true' or 'false'", value)
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Tarball) RequiresStop() bool {
+	return t.requiresStop
+}
+
+// Backup archives the data directory under a name made of the prefix, [lastSeenBlockNum] and the
+// current time, then applies the retention.
+func (t *Tarball) Backup(lastSeenBlockNum uint64) (string, error) {
+	ctx := context.Background()
+	name := fmt.Sprintf("%s%012d-%s", t.prefix, lastSeenBlockNum, t.now().UTC().Format(nameTimeLayout))
+
+	t.logger.Info("backing up data directory", zap.String("data_dir", t.dataDir), zap.String("backup", t.store.ObjectURL(name)))
+	start := time.Now()
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTarball(t.dataDir, writer))
+	}()
+
+	if err := t.store.WriteObject(ctx, name, reader); err != nil {
+		reader.CloseWithError(err)
+		return "", fmt.Errorf("write backup %q: %w", name, err)
+	}
+
+	t.logger.Info("data directory backed up", zap.String("backup", name), zap.Duration("elapsed", time.Since(start)))
+
+	if err := t.applyRetention(ctx); err != nil {
+		t.logger.Warn("unable to delete old backups", zap.Error(err))
+	}
+
+	return name, nil
+}
+
+// Restore replaces the data directory by the content of the backup [name], `latest` restoring the
+// backup of the highest block.
+func (t *Tarball) Restore(name string) error {
+	ctx := context.Background()
+
+	if name == "" || name == "latest" {
+		backups, err := t.backups(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(backups) == 0 {
+			return fmt.Errorf("no backup found in %s", t.store.BaseURL().Redacted())
+		}
+		name = backups[0].name
+	}
+
+	reader, err := t.store.OpenObject(ctx, name)
+	if err != nil {
+		return fmt.Errorf("open backup %q: %w", name, err)
+	}
+	defer reader.Close()
+
+	t.logger.Info("restoring data directory", zap.String("data_dir", t.dataDir), zap.String("backup", t.store.ObjectURL(name)))
+
+	// Extracted next to the data directory then swapped with it, so that a failed restore leaves
+	// the current data untouched
+	restoreDir := filepath.Clean(t.dataDir) + ".restoring"
+	if err := os.RemoveAll(restoreDir); err != nil {
+		return fmt.Errorf("clean restore directory: %w", err)
+	}
+
+	if err := extractTarball(reader, restoreDir); err != nil {
+		os.RemoveAll(restoreDir)
+		return fmt.Errorf("extract backup %q: %w", name, err)
+	}
+
+	if err := os.RemoveAll(t.dataDir); err != nil {
+		return fmt.Errorf("remove data directory: %w", err)
+	}
+
+	if err := os.Rename(restoreDir, t.dataDir); err != nil {
+		return fmt.Errorf("move restored data directory: %w", err)
+	}
+
+	t.logger.Info("data directory restored", zap.String("backup", name))
+	return nil
+}
+
+// List returns the names of the backups, the most recent (highest block) first.
+func (t *Tarball) List(offset, limit int) ([]string, error) {
+	backups, err := t.backups(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(backups))
+	for _, backup := range backups {
+		names = append(names, backup.name)
+	}
+
+	return paginate(names, offset, limit), nil
+}
+
+// Describe returns the URL of the backup [name], usable as `--reader-node-bootstrap-data-url`, and
+// its compressed size.
+func (t *Tarball) Describe(name string) (string, int64, error) {
+	attributes, err := t.store.ObjectAttributes(context.Background(), name)
+	if err != nil {
+		return "", 0, fmt.Errorf("backup %q attributes: %w", name, err)
+	}
+
+	return t.store.ObjectURL(name), attributes.Size, nil
+}
+
+func (t *Tarball) applyRetention(ctx context.Context) error {
+	if t.keep == 0 {
+		return nil
+	}
+
+	backups, err := t.backups(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range backups[min(t.keep, len(backups)):] {
+		t.logger.Info("deleting old backup", zap.String("backup", backup.name))
+		if err := t.store.DeleteObject(ctx, backup.name); err != nil && !errors.Is(err, dstore.ErrNotFound) {
+			return fmt.Errorf("delete backup %q: %w", backup.name, err)
+		}
+	}
+
+	return nil
+}
+
+type backupRef struct {
+	name     string
+	blockNum uint64
+	time     time.Time
+}
+
+// backups returns the backups of the store, the most recent first, files not named like a backup
+// are ignored.
+func (t *Tarball) backups(ctx context.Context) ([]*backupRef, error) {
+	var backups []*backupRef
+	err := t.store.Walk(ctx, t.prefix, func(filename string) error {
+		if backup, ok := parseBackupName(t.prefix, filename); ok {
+			backups = append(backups, backup)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list backups: %w", err)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].blockNum != backups[j].blockNum {
+			return backups[i].blockNum > backups[j].blockNum
+		}
+		return backups[i].time.After(backups[j].time)
+	})
+
+	return backups, nil
+}
+
+func parseBackupName(prefix, name string) (*backupRef, bool) {
+	rest, found := strings.CutPrefix(name, prefix)
+	if !found {
+		return nil, false
+	}
+
+	rawBlockNum, rawTime, found := strings.Cut(rest, "-")
+	if !found {
+		return nil, false
+	}
+
+	blockNum, err := strconv.ParseUint(rawBlockNum, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	at, err := time.Parse(nameTimeLayout, rawTime)
+	if err != nil {
+		return nil, false
+	}
+
+	return &backupRef{name: name, blockNum: blockNum, time: at}, true
+}
+
+func paginate(names []string, offset, limit int) []string {
+	if offset >= len(names) {
+		return []string{}
+	}
+
+	names = names[offset:]
+	if limit > 0 && limit < len(names) {
+		names = names[:limit]
+	}
+	return names
+}
+
+// writeTarball writes the content of [dir] to [w], with paths relative to it.
+func writeTarball(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			// Sockets, pipes and devices are not part of the data
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("archive %q: %w", dir, err)
+	}
+
+	return tw.Close()
+}
+
+// extractTarball extracts [r] into [dir], refusing entries escaping it, either by their path,
+// by a symlink target outside of [dir] or by writing through a symlink already extracted.
+func extractTarball(r io.Reader, dir string) error {
+	dir = filepath.Clean(dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !isWithinDir(dir, path) || path == dir {
+			return fmt.Errorf("entry %q is outside of the data directory", header.Name)
+		}
+
+		if err := checkNoSymlink(dir, path); err != nil {
+			return fmt.Errorf("entry %q: %w", header.Name, err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, header.FileInfo().Mode().Perm()|0o700); err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, header.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+
+			if _, err := io.Copy(file, tr); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			if filepath.IsAbs(header.Linkname) || !isWithinDir(dir, filepath.Join(filepath.Dir(path), header.Linkname)) {
+				return fmt.Errorf("entry %q links to %q, outside of the data directory", header.Name, header.Linkname)
+			}
+
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, path); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// isWithinDir returns whether the clean [path] is [dir] or one of its descendants.
+func isWithinDir(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// checkNoSymlink returns an error when [path] or one of its parents up to [dir] is a symlink, so
+// that nothing is written through a symlink extracted earlier.
+func checkNoSymlink(dir, path string) error {
+	for current := path; current != dir; current = filepath.Dir(current) {
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%q is a symlink, refusing to write through it", current)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/firehose-core/node-manager/operator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestTarball(t *testing.T, conf operator.BackupModuleConfig) (*Tarball, string) {
+	t.Helper()
+
+	dataDir := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "db"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "db", "state"), []byte("v1"), 0o644))
+	require.NoError(t, os.Symlink("db/state", filepath.Join(dataDir, "current")))
+
+	if conf["store"] == "" {
+		conf["store"] = t.TempDir()
+	}
+
+	module, err := NewTarball(conf, dataDir, zap.NewNop())
+	require.NoError(t, err)
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	module.now = func() time.Time {
+		at = at.Add(time.Minute)
+		return at
+	}
+
+	return module, dataDir
+}
+
+func TestTarball_BackupRestore(t *testing.T) {
+	module, dataDir := newTestTarball(t, operator.BackupModuleConfig{"prefix": "node-"})
+	assert.True(t, module.RequiresStop())
+
+	first, err := module.Backup(100)
+	require.NoError(t, err)
+	assert.Equal(t, "node-000000000100-20240101T000100Z", first)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "db", "state"), []byte("v2"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "db", "extra"), []byte("x"), 0o644))
+
+	second, err := module.Backup(200)
+	require.NoError(t, err)
+
+	names, err := module.List(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second, first}, names)
+
+	require.NoError(t, module.Restore(first))
+	assertFileContent(t, filepath.Join(dataDir, "db", "state"), "v1")
+	assertFileContent(t, filepath.Join(dataDir, "current"), "v1")
+	assert.NoFileExists(t, filepath.Join(dataDir, "db", "extra"))
+	assert.NoDirExists(t, dataDir+".restoring")
+
+	require.NoError(t, module.Restore("latest"))
+	assertFileContent(t, filepath.Join(dataDir, "db", "state"), "v2")
+	assertFileContent(t, filepath.Join(dataDir, "db", "extra"), "x")
+
+	assert.Error(t, module.Restore("node-000000000300-20240101T000100Z"))
+	assertFileContent(t, filepath.Join(dataDir, "db", "state"), "v2")
+}
+
+func TestTarball_Retention(t *testing.T) {
+	module, _ := newTestTarball(t, operator.BackupModuleConfig{"keep": "2", "requires-stop": "false"})
+	assert.False(t, module.RequiresStop())
+
+	var names []string
+	for _, blockNum := range []uint64{300, 100, 200, 400} {
+		name, err := module.Backup(blockNum)
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+
+	// Ordered by block, not by backup time, the 100 and 200 ones are gone
+	listed, err := module.List(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{names[3], names[0]}, listed)
+
+	listed, err = module.List(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{names[0]}, listed)
+
+	listed, err = module.List(5, 0)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+}
+
+func TestTarball_BlocksAbove32Bits(t *testing.T) {
+	module, _ := newTestTarball(t, operator.BackupModuleConfig{"keep": "3"})
+
+	var names []string
+	for _, blockNum := range []uint64{1_000_000_000_000, 4_294_967_295, 999_999_999_999, 4_294_967_296} {
+		name, err := module.Backup(blockNum)
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	assert.Equal(t, "1000000000000-20240101T000100Z", names[0])
+	assert.Equal(t, "004294967296-20240101T000400Z", names[3])
+
+	// Ordered numerically although the names of the blocks past 12 digits are longer
+	listed, err := module.List(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{names[0], names[2], names[3]}, listed)
+}
+
+func TestTarball_RestoreWithoutBackup(t *testing.T) {
+	module, _ := newTestTarball(t, operator.BackupModuleConfig{})
+	assert.Error(t, module.Restore("latest"))
+}
+
+func TestNewTarball_InvalidConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		conf operator.BackupModuleConfig
+	}{
+		{"missing store", operator.BackupModuleConfig{}},
+		{"invalid keep", operator.BackupModuleConfig{"store": t.TempDir(), "keep": "-1"}},
+		{"invalid requires-stop", operator.BackupModuleConfig{"store": t.TempDir(), "requires-stop": "maybe"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NewTarball(c.conf, "/data", zap.NewNop())
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestExtractTarball_RejectsEscapingEntries(t *testing.T) {
+	for _, name := range []string{"../escape", "db/../../escape", "/abs/../../escape"} {
+		t.Run(name, func(t *testing.T) {
+			buffer := bytes.NewBuffer(nil)
+			tw := tar.NewWriter(buffer)
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: 1}))
+			_, err := tw.Write([]byte("x"))
+			require.NoError(t, err)
+			require.NoError(t, tw.Close())
+
+			dir := filepath.Join(t.TempDir(), "data")
+			require.Error(t, extractTarball(buffer, dir))
+			assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "escape"))
+		})
+	}
+}
+
+func TestExtractTarball_RejectsEscapingSymlinks(t *testing.T) {
+	type entry struct {
+		name, link string
+	}
+
+	cases := []struct {
+		name    string
+		entries []entry
+	}{
+		{"absolute link target", []entry{{name: "x", link: "/etc"}}},
+		{"relative link target outside", []entry{{name: "db/x", link: "../../etc"}}},
+		{"write through symlink", []entry{{name: "x", link: "db"}, {name: "x/passwd"}}},
+		{"overwrite symlink", []entry{{name: "db/", link: ""}, {name: "x", link: "db/state"}, {name: "x"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buffer := bytes.NewBuffer(nil)
+			tw := tar.NewWriter(buffer)
+			for _, e := range c.entries {
+				switch {
+				case e.link != "":
+					require.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: tar.TypeSymlink, Linkname: e.link, Mode: 0o777}))
+				case strings.HasSuffix(e.name, "/"):
+					require.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: tar.TypeDir, Mode: 0o755}))
+				default:
+					require.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: 1}))
+					_, err := tw.Write([]byte("x"))
+					require.NoError(t, err)
+				}
+			}
+			require.NoError(t, tw.Close())
+
+			dir := filepath.Join(t.TempDir(), "data")
+			require.Error(t, extractTarball(buffer, dir))
+		})
+	}
+}
+
+func TestExtractTarball_SymlinkThenFileOutside(t *testing.T) {
+	outside := t.TempDir()
+
+	buffer := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buffer)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "x", Typeflag: tar.TypeSymlink, Linkname: outside, Mode: 0o777}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "x/passwd", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1}))
+	_, err := tw.Write([]byte("x"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	require.Error(t, extractTarball(buffer, filepath.Join(t.TempDir(), "data")))
+	assert.NoFileExists(t, filepath.Join(outside, "passwd"))
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
@@ -31,3 +31,7 @@ func NewHeadBlockNumber(serviceName string) *dmetrics.HeadBlockNum {
 func NewAppReadiness(serviceName string) *dmetrics.AppReadiness {
 	return Metricset.NewAppReadiness(serviceName)
 }
+
+var NodeRestarts = Metricset.NewCounter("node_restarts", "Number of times the node process was restarted after exiting outside of an operator command")
+var NodeRestartsInWindow = Metricset.NewGauge("node_restarts_in_window", "Number of restarts of the node process counted against the restart policy window")
+var NodeRecoveryActions = Metricset.NewCounterVec("node_recovery_actions", []string{"result"}, "Number of recovery actions run before restarting the node process, by result ('success' or 'failure')")
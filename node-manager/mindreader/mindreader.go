@@ -22,6 +22,7 @@ import (
 	"path"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/streamingfast/bstream"
 	"github.com/streamingfast/bstream/blockstream"
@@ -29,6 +30,7 @@ import (
 	"github.com/streamingfast/dstore"
 	"github.com/streamingfast/firehose-core/internal/utils"
 	nodeManager "github.com/streamingfast/firehose-core/node-manager"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 	"github.com/streamingfast/logging"
 	"github.com/streamingfast/shutter"
 	"go.uber.org/zap"
@@ -59,8 +61,11 @@ type MindReaderPlugin struct {
 	stopBlock                uint64 // if set, call shutdownFunc(nil) when we hit this number
 	channelCapacity          int    // transformed blocks are buffered in a channel
 	forceFinalityAfterBlocks *uint64
+	chainIdentity            *wellknown.Chain // if set, blocks at its genesis block and checkpoints must match their ID once final
+	checkpointCandidates     map[uint64]string
 
 	lastSeenBlock     bstream.BlockRef
+	lastSeenBlockTime time.Time
 	lastSeenBlockLock sync.RWMutex
 
 	headBlockUpdater  nodeManager.HeadBlockUpdater
@@ -318,8 +323,15 @@ func (p *MindReaderPlugin) readOneMessage(blocks chan<- *pbbstream.Block) error
 		return nil
 	}
 
+	if err := p.verifyChainIdentity(block); err != nil {
+		return fmt.Errorf("refusing to write block, the node is syncing another chain: %w", err)
+	}
+
 	p.lastSeenBlockLock.Lock()
 	p.lastSeenBlock = block.AsRef()
+	if block.Timestamp != nil {
+		p.lastSeenBlockTime = block.Timestamp.AsTime()
+	}
 	p.lastSeenBlockLock.Unlock()
 
 	if p.headBlockUpdater != nil {
@@ -347,6 +359,31 @@ func (p *MindReaderPlugin) readOneMessage(blocks chan<- *pbbstream.Block) error
 	return nil
 }
 
+// verifyChainIdentity verifies the blocks read at the genesis block and checkpoints of the chain
+// identity once the LIB reaches them, a fork at a checkpoint height being legit until then. The last
+// block read at a checkpoint height is the canonical one, the node emitting the blocks again on reorgs.
+func (p *MindReaderPlugin) verifyChainIdentity(block *pbbstream.Block) error {
+	if _, found := p.chainIdentity.ExpectedBlockID(block.Number); found {
+		if p.checkpointCandidates == nil {
+			p.checkpointCandidates = make(map[uint64]string)
+		}
+		p.checkpointCandidates[block.Number] = block.Id
+	}
+
+	for blockNum, blockID := range p.checkpointCandidates {
+		if blockNum > block.LibNum {
+			continue
+		}
+
+		delete(p.checkpointCandidates, blockNum)
+		if err := p.chainIdentity.VerifyBlock(blockNum, blockID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // LogLine receives log line and write it to "pipe" of the local console reader
 func (p *MindReaderPlugin) LogLine(in string) {
 	if p.IsTerminating() {
@@ -356,6 +393,12 @@ func (p *MindReaderPlugin) LogLine(in string) {
 	p.lines <- in
 }
 
+// VerifyChainIdentity makes the plugin shut down instead of writing a block once the block it read at
+// the genesis block or at a checkpoint of [chain] is final and does not have the expected ID.
+func (p *MindReaderPlugin) VerifyChainIdentity(chain *wellknown.Chain) {
+	p.chainIdentity = chain
+}
+
 func (p *MindReaderPlugin) OnBlockWritten(callback nodeManager.OnBlockWritten) {
 	p.onBlockWritten = callback
 }
@@ -372,3 +415,11 @@ func (p *MindReaderPlugin) LastSeenBlock() bstream.BlockRef {
 
 	return p.lastSeenBlock
 }
+
+// LastSeenBlockTime is the time of the last block seen, see [MindReaderPlugin.LastSeenBlock].
+func (p *MindReaderPlugin) LastSeenBlockTime() time.Time {
+	p.lastSeenBlockLock.RLock()
+	defer p.lastSeenBlockLock.RUnlock()
+
+	return p.lastSeenBlockTime
+}
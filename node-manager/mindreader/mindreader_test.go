@@ -11,6 +11,7 @@ import (
 	"time"
 
 	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
+	wellknown "github.com/streamingfast/firehose-core/well-known"
 
 	"github.com/streamingfast/shutter"
 	"github.com/stretchr/testify/assert"
@@ -108,6 +109,78 @@ func TestMindReaderPlugin_StopAtBlockNumReached(t *testing.T) {
 	assert.Equal(t, numOfLines, len(blocks)) // moderate requirement, race condition can make it pass more blocks
 }
 
+func TestMindReaderPlugin_ChainIdentityMismatch(t *testing.T) {
+	lines := make(chan string, 2)
+	blocks := make(chan *pbbstream.Block, 2)
+
+	mindReader := &MindReaderPlugin{
+		Shutter:       shutter.New(),
+		lines:         lines,
+		consoleReader: newTestConsoleReader(lines),
+		zlogger:       testLogger,
+	}
+	mindReader.VerifyChainIdentity(&wellknown.Chain{
+		Name:           "acme-mainnet",
+		GenesisBlockID: "00000000a",
+		Checkpoints:    []*wellknown.Checkpoint{{Number: 2, ID: "00000002b"}},
+	})
+
+	mindReader.LogLine(`DMLOG {"id":"00000001a"}`)
+	mindReader.LogLine(`DMLOG {"id":"00000002a","lib":2}`)
+
+	require.NoError(t, mindReader.readOneMessage(blocks))
+	assert.ErrorIs(t, mindReader.readOneMessage(blocks), wellknown.ErrChainIdentityMismatch)
+
+	// The mismatching block is not written
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "00000001a", (<-blocks).Id)
+}
+
+func TestMindReaderPlugin_ChainIdentityVerifiedOnceFinal(t *testing.T) {
+	newMindReader := func() (*MindReaderPlugin, chan *pbbstream.Block) {
+		lines := make(chan string, 8)
+		mindReader := &MindReaderPlugin{
+			Shutter:       shutter.New(),
+			lines:         lines,
+			consoleReader: newTestConsoleReader(lines),
+			zlogger:       testLogger,
+		}
+		mindReader.VerifyChainIdentity(&wellknown.Chain{
+			Name:           "acme-mainnet",
+			GenesisBlockID: "00000000a",
+			Checkpoints:    []*wellknown.Checkpoint{{Number: 2, ID: "00000002b"}},
+		})
+		return mindReader, make(chan *pbbstream.Block, 8)
+	}
+
+	t.Run("fork at checkpoint", func(t *testing.T) {
+		mindReader, blocks := newMindReader()
+
+		// The fork at the checkpoint height is replaced by the canonical block before the LIB passes it
+		for _, line := range []string{
+			`DMLOG {"id":"00000002a","lib":1}`,
+			`DMLOG {"id":"00000002b","lib":1}`,
+			`DMLOG {"id":"00000003b","lib":2}`,
+			`DMLOG {"id":"00000004b","lib":3}`,
+		} {
+			mindReader.LogLine(line)
+			require.NoError(t, mindReader.readOneMessage(blocks))
+		}
+		assert.Len(t, blocks, 4)
+	})
+
+	t.Run("final mismatch", func(t *testing.T) {
+		mindReader, blocks := newMindReader()
+
+		mindReader.LogLine(`DMLOG {"id":"00000002a","lib":1}`)
+		mindReader.LogLine(`DMLOG {"id":"00000003a","lib":2}`)
+
+		require.NoError(t, mindReader.readOneMessage(blocks))
+		assert.ErrorIs(t, mindReader.readOneMessage(blocks), wellknown.ErrChainIdentityMismatch)
+		assert.Len(t, blocks, 1)
+	})
+}
+
 func TestMindReaderPlugin_OneBlockSuffixFormat(t *testing.T) {
 	assert.Error(t, validateOneBlockSuffix(""))
 	assert.NoError(t, validateOneBlockSuffix("example"))
@@ -142,7 +215,8 @@ func (c *testConsoleReader) ReadBlock() (*pbbstream.Block, error) {
 	}
 
 	type block struct {
-		ID string `json:"id"`
+		ID     string `json:"id"`
+		LibNum uint64 `json:"lib"`
 	}
 
 	data := new(block)
@@ -152,6 +226,7 @@ func (c *testConsoleReader) ReadBlock() (*pbbstream.Block, error) {
 	return &pbbstream.Block{
 		Id:     data.ID,
 		Number: toBlockNum(data.ID),
+		LibNum: data.LibNum,
 	}, nil
 }
 
@@ -0,0 +1,157 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Audit outcomes reported in [AuditEntry.Outcome].
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+	AuditDenied  = "denied"
+)
+
+// AuditEntry records a command issued through the operator HTTP API, written once it completes,
+// or a request denied by the authorization.
+type AuditEntry struct {
+	IssuedAt    time.Time      `json:"issued_at"`
+	CompletedAt time.Time      `json:"completed_at"`
+	Principal   string         `json:"principal"`
+	Role        Role           `json:"role"`
+	AuthMethod  string         `json:"auth_method"`
+	RemoteAddr  string         `json:"remote_addr"`
+	HTTPMethod  string         `json:"http_method"`
+	Path        string         `json:"path"`
+	Command     string         `json:"command,omitempty"`
+	Params      map[string]any `json:"params,omitempty"`
+	Outcome     string         `json:"outcome"`
+	Error       string         `json:"error,omitempty"`
+}
+
+func newAuditEntry(r *http.Request, principal *Principal, command string, params map[string]any) *AuditEntry {
+	return &AuditEntry{
+		IssuedAt:   time.Now(),
+		Principal:  principal.Name,
+		Role:       principal.Role,
+		AuthMethod: principal.Method,
+		RemoteAddr: r.RemoteAddr,
+		HTTPMethod: r.Method,
+		Path:       r.URL.Path,
+		Command:    command,
+		Params:     auditParams(params),
+	}
+}
+
+func (e *AuditEntry) completed(err error) *AuditEntry {
+	e.CompletedAt = time.Now()
+	e.Outcome = AuditSuccess
+	if err != nil {
+		e.Outcome = AuditFailure
+		e.Error = err.Error()
+	}
+	return e
+}
+
+func (e *AuditEntry) denied(err error) *AuditEntry {
+	e.CompletedAt = e.IssuedAt
+	e.Outcome = AuditDenied
+	e.Error = err.Error()
+	return e
+}
+
+func (e *AuditEntry) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddTime("issued_at", e.IssuedAt)
+	encoder.AddTime("completed_at", e.CompletedAt)
+	encoder.AddString("principal", e.Principal)
+	encoder.AddString("role", string(e.Role))
+	encoder.AddString("auth_method", e.AuthMethod)
+	encoder.AddString("remote_addr", e.RemoteAddr)
+	encoder.AddString("http_method", e.HTTPMethod)
+	encoder.AddString("path", e.Path)
+	encoder.AddString("command", e.Command)
+	if err := encoder.AddReflected("params", e.Params); err != nil {
+		return err
+	}
+	encoder.AddString("outcome", e.Outcome)
+	encoder.AddString("error", e.Error)
+	return nil
+}
+
+// auditParams returns the command [params] to audit, the values of the extra environment
+// variables, which may hold secrets, are redacted.
+func auditParams(params map[string]any) map[string]any {
+	if len(params) == 0 {
+		return nil
+	}
+
+	out := make(map[string]any, len(params))
+	for key, value := range params {
+		if env, ok := value.(map[string]string); ok && key == "extra-env" {
+			redacted := make(map[string]string, len(env))
+			for name := range env {
+				redacted[name] = "<redacted>"
+			}
+			value = redacted
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// AuditSink receives the audit entries of the operator HTTP API, on top of them being logged.
+type AuditSink interface {
+	Write(entry *AuditEntry) error
+}
+
+// FileAuditSink appends the audit entries to a file, one JSON object per line.
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log %q: %w", path, err)
+	}
+
+	return &FileAuditSink{file: file}, nil
+}
+
+func (s *FileAuditSink) Write(entry *AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+func (o *Operator) audit(entry *AuditEntry) {
+	o.zlogger.Info("operator API audit", zap.Object("audit", entry))
+
+	if o.options.AuditSink != nil {
+		if err := o.options.AuditSink.Write(entry); err != nil {
+			o.zlogger.Warn("unable to write operator API audit entry", zap.Error(err))
+		}
+	}
+}
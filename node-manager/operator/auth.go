@@ -0,0 +1,275 @@
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Role is what a caller of the operator HTTP API is allowed to do.
+type Role string
+
+const (
+	// RoleRead allows the `GET` endpoints (status, backups listing, etc.)
+	RoleRead Role = "read"
+	// RoleAdmin allows every endpoint, including the commands (maintenance, restore, reload, backup, etc.)
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) allows(required Role) bool {
+	return r == RoleAdmin || r == required
+}
+
+func parseRole(in string) (Role, error) {
+	switch role := Role(in); role {
+	case RoleRead, RoleAdmin:
+		return role, nil
+	default:
+		return "", fmt.Errorf("invalid role %q, must be 'read' or 'admin'", in)
+	}
+}
+
+// Principal is an authenticated caller of the operator HTTP API.
+type Principal struct {
+	Name string
+	Role Role
+	// Method is how the caller authenticated, `token`, `mtls` or `none` when authentication is disabled
+	Method string
+}
+
+var anonymous = &Principal{Name: "anonymous", Role: RoleAdmin, Method: "none"}
+
+// publicPaths are served without authentication, for the liveness and readiness probes
+var publicPaths = map[string]bool{
+	"/healthz":    true,
+	"/v1/healthz": true,
+	"/v1/ping":    true,
+}
+
+// APIAuth authenticates the callers of the operator HTTP API, by bearer token (`Authorization:
+// Bearer <token>` header) or by TLS client certificate common name, see [ParseAPIAuth].
+type APIAuth struct {
+	tokens  []*apiToken
+	clients map[string]*Principal
+}
+
+type apiToken struct {
+	hash      [sha256.Size]byte
+	principal *Principal
+}
+
+type apiAuthFile struct {
+	Tokens []struct {
+		Name     string `yaml:"name"`
+		Role     string `yaml:"role"`
+		Token    string `yaml:"token"`
+		TokenEnv string `yaml:"token-env"`
+	} `yaml:"tokens"`
+	Clients []struct {
+		CommonName string `yaml:"common-name"`
+		Role       string `yaml:"role"`
+	} `yaml:"clients"`
+}
+
+// ReadAPIAuthFile reads the operator HTTP API auth file at [path], see [ParseAPIAuth].
+func ReadAPIAuthFile(path string) (*APIAuth, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read operator API auth file %q: %w", path, err)
+	}
+
+	auth, err := ParseAPIAuth(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse operator API auth file %q: %w", path, err)
+	}
+
+	return auth, nil
+}
+
+// ParseAPIAuth parses a YAML operator HTTP API auth file, listing the bearer `tokens` (given
+// inline with `token` or read from the `token-env` environment variable) and the TLS `clients`
+// (by certificate `common-name`) allowed, each with its `role`:
+//
+//	tokens:
+//	  - {name: dashboard, role: read, token-env: OPERATOR_DASHBOARD_TOKEN}
+//	  - {name: ops, role: admin, token: 9b2c...}
+//	clients:
+//	  - {common-name: ops.example.com, role: admin}
+func ParseAPIAuth(content []byte) (*APIAuth, error) {
+	var parsed apiAuthFile
+	if err := yaml.UnmarshalStrict(content, &parsed); err != nil {
+		return nil, err
+	}
+
+	auth := &APIAuth{clients: make(map[string]*Principal)}
+	for i, entry := range parsed.Tokens {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("tokens entry #%d: name is required", i)
+		}
+
+		role, err := parseRole(entry.Role)
+		if err != nil {
+			return nil, fmt.Errorf("tokens entry %q: %w", entry.Name, err)
+		}
+
+		token := entry.Token
+		if entry.TokenEnv != "" {
+			if token != "" {
+				return nil, fmt.Errorf("tokens entry %q: only one of token and token-env can be set", entry.Name)
+			}
+			token = os.Getenv(entry.TokenEnv)
+		}
+
+		if token == "" {
+			return nil, fmt.Errorf("tokens entry %q: token is empty", entry.Name)
+		}
+
+		auth.tokens = append(auth.tokens, &apiToken{
+			hash:      sha256.Sum256([]byte(token)),
+			principal: &Principal{Name: entry.Name, Role: role, Method: "token"},
+		})
+	}
+
+	for i, entry := range parsed.Clients {
+		if entry.CommonName == "" {
+			return nil, fmt.Errorf("clients entry #%d: common-name is required", i)
+		}
+
+		role, err := parseRole(entry.Role)
+		if err != nil {
+			return nil, fmt.Errorf("clients entry %q: %w", entry.CommonName, err)
+		}
+
+		if _, found := auth.clients[entry.CommonName]; found {
+			return nil, fmt.Errorf("clients entry %q is defined more than once", entry.CommonName)
+		}
+		auth.clients[entry.CommonName] = &Principal{Name: entry.CommonName, Role: role, Method: "mtls"}
+	}
+
+	if len(auth.tokens) == 0 && len(auth.clients) == 0 {
+		return nil, fmt.Errorf("no tokens nor clients defined")
+	}
+
+	return auth, nil
+}
+
+// authenticate returns the principal of [r], the bearer token taking precedence over the TLS
+// client certificate.
+func (a *APIAuth) authenticate(r *http.Request) (*Principal, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			return nil, fmt.Errorf("unsupported authorization scheme, must be 'Bearer'")
+		}
+
+		hash := sha256.Sum256([]byte(token))
+		for _, candidate := range a.tokens {
+			if subtle.ConstantTimeCompare(hash[:], candidate.hash[:]) == 1 {
+				return candidate.principal, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		commonName := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		if principal, found := a.clients[commonName]; found {
+			return principal, nil
+		}
+		return nil, fmt.Errorf("client certificate %q is not allowed", commonName)
+	}
+
+	return nil, fmt.Errorf("missing credentials")
+}
+
+func (a *APIAuth) hasClients() bool {
+	return len(a.clients) > 0
+}
+
+// NewAPITLSConfig returns the TLS configuration serving the operator HTTP API with the certificate
+// [certFile] and key [keyFile], verifying the client certificates against [clientCAFile] when set.
+// Client certificates are optional at the TLS level so that the probes and the token callers can
+// connect, the authorization happens per request.
+func NewAPITLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		content, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("client CA %q contains no PEM certificate", clientCAFile)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return config, nil
+}
+
+// requiredRole is the role needed to call [r], the `GET` endpoints are read-only.
+func requiredRole(r *http.Request) Role {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return RoleRead
+	}
+	return RoleAdmin
+}
+
+type principalKey struct{}
+
+func principalFromContext(ctx context.Context) *Principal {
+	if principal, ok := ctx.Value(principalKey{}).(*Principal); ok {
+		return principal
+	}
+	return anonymous
+}
+
+// authMiddleware authenticates and authorizes the requests, auditing the denied ones.
+func (o *Operator) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if o.options.APIAuth == nil || publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		required := requiredRole(r)
+		principal, err := o.options.APIAuth.authenticate(r)
+		if err != nil {
+			o.audit(newAuditEntry(r, &Principal{Method: "none"}, "", nil).denied(err))
+
+			w.Header().Set("WWW-Authenticate", `Bearer realm="operator"`)
+			http.Error(w, fmt.Sprintf("unauthorized: %s", err), http.StatusUnauthorized)
+			return
+		}
+
+		if !principal.Role.allows(required) {
+			err := fmt.Errorf("role %q required, %q has role %q", required, principal.Name, principal.Role)
+			o.audit(newAuditEntry(r, principal, "", nil).denied(err))
+
+			http.Error(w, fmt.Sprintf("forbidden: %s", err), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
@@ -0,0 +1,174 @@
+package operator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testAuthFile = `
+tokens:
+  - {name: dashboard, role: read, token: read-token}
+  - {name: ops, role: admin, token-env: TEST_OPERATOR_ADMIN_TOKEN}
+clients:
+  - {common-name: ops.example.com, role: admin}
+`
+
+func TestParseAPIAuth(t *testing.T) {
+	t.Setenv("TEST_OPERATOR_ADMIN_TOKEN", "admin-token")
+
+	auth, err := ParseAPIAuth([]byte(testAuthFile))
+	require.NoError(t, err)
+	assert.Len(t, auth.tokens, 2)
+	assert.True(t, auth.hasClients())
+
+	cases := []struct {
+		name    string
+		content string
+	}{
+		{"empty", `tokens: []`},
+		{"unknown field", `tokens: [{name: a, role: read, token: t, extra: 1}]`},
+		{"invalid role", `tokens: [{name: a, role: root, token: t}]`},
+		{"missing name", `tokens: [{role: read, token: t}]`},
+		{"empty token", `tokens: [{name: a, role: read, token-env: TEST_OPERATOR_UNSET_TOKEN}]`},
+		{"token and token-env", `tokens: [{name: a, role: read, token: t, token-env: TEST_OPERATOR_ADMIN_TOKEN}]`},
+		{"duplicate client", `clients: [{common-name: a, role: read}, {common-name: a, role: admin}]`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseAPIAuth([]byte(c.content))
+			require.Error(t, err)
+		})
+	}
+}
+
+type testAuditSink struct {
+	entries []*AuditEntry
+}
+
+func (s *testAuditSink) Write(entry *AuditEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func newTestAuthOperator(t *testing.T) (*Operator, *testAuditSink) {
+	t.Helper()
+	t.Setenv("TEST_OPERATOR_ADMIN_TOKEN", "admin-token")
+
+	auth, err := ParseAPIAuth([]byte(testAuthFile))
+	require.NoError(t, err)
+
+	sink := &testAuditSink{}
+	o, err := New(zap.NewNop(), newTestSuperviser(), testReadiness{}, &Options{
+		APIAuth:      auth,
+		APITLSConfig: &tls.Config{ClientCAs: x509.NewCertPool()},
+		AuditSink:    sink,
+	})
+	require.NoError(t, err)
+
+	return o, sink
+}
+
+func TestOperator_AuthMiddleware(t *testing.T) {
+	o, sink := newTestAuthOperator(t)
+
+	handler := o.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(principalFromContext(r.Context()).Name))
+	}))
+
+	cases := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		clientCN       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"public healthz", "GET", "/healthz", "", "", http.StatusOK, "anonymous"},
+		{"missing credentials", "GET", "/v1/status", "", "", http.StatusUnauthorized, ""},
+		{"invalid token", "GET", "/v1/status", "nope", "", http.StatusUnauthorized, ""},
+		{"read token on read endpoint", "GET", "/v1/status", "read-token", "", http.StatusOK, "dashboard"},
+		{"read token on command", "POST", "/v1/maintenance", "read-token", "", http.StatusForbidden, ""},
+		{"admin token on command", "POST", "/v1/maintenance", "admin-token", "", http.StatusOK, "ops"},
+		{"allowed client certificate", "POST", "/v1/reload", "", "ops.example.com", http.StatusOK, "ops.example.com"},
+		{"unknown client certificate", "GET", "/v1/status", "", "other.example.com", http.StatusUnauthorized, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.path, nil)
+			if c.token != "" {
+				req.Header.Set("Authorization", "Bearer "+c.token)
+			}
+			if c.clientCN != "" {
+				cert := &x509.Certificate{Subject: pkix.Name{CommonName: c.clientCN}}
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, c.expectedStatus, w.Code)
+			if c.expectedBody != "" {
+				assert.Equal(t, c.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	var denied int
+	for _, entry := range sink.entries {
+		assert.Equal(t, AuditDenied, entry.Outcome)
+		denied++
+	}
+	assert.Equal(t, 4, denied)
+}
+
+func TestOperator_AuditCommands(t *testing.T) {
+	o, sink := newTestAuthOperator(t)
+	handler := o.authMiddleware(http.HandlerFunc(o.resumeHandler))
+
+	form := url.Values{"extra-env": []string{"SECRET=value"}}
+	req := httptest.NewRequest("POST", "/v1/resume", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer admin-token")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// Audited once the operator completes the command
+	assert.Empty(t, sink.entries)
+	cmd := <-o.commandChan
+	cmd.Return(errors.New("boom"))
+
+	require.Len(t, sink.entries, 1)
+	entry := sink.entries[0]
+	assert.Equal(t, "ops", entry.Principal)
+	assert.Equal(t, RoleAdmin, entry.Role)
+	assert.Equal(t, "token", entry.AuthMethod)
+	assert.Equal(t, "resume", entry.Command)
+	assert.Equal(t, map[string]string{"SECRET": "<redacted>"}, entry.Params["extra-env"])
+	assert.Equal(t, AuditFailure, entry.Outcome)
+	assert.Equal(t, "boom", entry.Error)
+	assert.False(t, entry.CompletedAt.Before(entry.IssuedAt))
+}
+
+func TestNew_APIAuthClientsRequireClientCA(t *testing.T) {
+	auth, err := ParseAPIAuth([]byte(`clients: [{common-name: ops.example.com, role: admin}]`))
+	require.NoError(t, err)
+
+	_, err = New(zap.NewNop(), newTestSuperviser(), testReadiness{}, &Options{APIAuth: auth})
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package operator
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -15,6 +16,13 @@ type BackupModuleConfig map[string]string
 type BackupModuleFactory func(conf BackupModuleConfig) (BackupModule, error)
 
 type BackupModule interface {
+	Backup(lastSeenBlockNum uint64) (string, error)
+	RequiresStop() bool
+}
+
+// LegacyBackupModule is a backup module taking 32 bits block numbers, like the GKE PVC snapshotter,
+// see [AdaptLegacyBackupModule].
+type LegacyBackupModule interface {
 	Backup(lastSeenBlockNum uint32) (string, error)
 	RequiresStop() bool
 }
@@ -24,13 +32,54 @@ type RestorableBackupModule interface {
 	Restore(name string) error
 }
 
+// ListableBackupModule is a backup module able to list its backups, the most recent first.
+type ListableBackupModule interface {
+	BackupModule
+	List(offset, limit int) ([]string, error)
+}
+
 type BackupSchedule struct {
-	BlocksBetweenRuns     int
+	BlocksBetweenRuns     uint64
 	TimeBetweenRuns       time.Duration
 	RequiredHostnameMatch string // will not run backup if !empty env.Hostname != HostnameMatch
 	BackuperName          string // must match id of backupModule
 }
 
+// AdaptLegacyBackupModule returns the [BackupModule] of [mod], restorable when [mod] has a
+// `Restore(name string) error` method. Backups past block 4,294,967,295, which [mod] cannot
+// represent, fail instead of being taken with a truncated block number.
+func AdaptLegacyBackupModule(mod LegacyBackupModule) BackupModule {
+	adapted := &legacyBackupModule{legacy: mod}
+	if restorer, ok := mod.(interface{ Restore(name string) error }); ok {
+		return &legacyRestorableBackupModule{legacyBackupModule: adapted, restorer: restorer}
+	}
+	return adapted
+}
+
+type legacyBackupModule struct {
+	legacy LegacyBackupModule
+}
+
+func (m *legacyBackupModule) Backup(lastSeenBlockNum uint64) (string, error) {
+	if lastSeenBlockNum > math.MaxUint32 {
+		return "", fmt.Errorf("block #%d is past the maximum block #%d supported by the backup module", lastSeenBlockNum, uint32(math.MaxUint32))
+	}
+	return m.legacy.Backup(uint32(lastSeenBlockNum))
+}
+
+func (m *legacyBackupModule) RequiresStop() bool {
+	return m.legacy.RequiresStop()
+}
+
+type legacyRestorableBackupModule struct {
+	*legacyBackupModule
+	restorer interface{ Restore(name string) error }
+}
+
+func (m *legacyRestorableBackupModule) Restore(name string) error {
+	return m.restorer.Restore(name)
+}
+
 func (o *Operator) RegisterBackupModule(name string, mod BackupModule) error {
 	if o.backupModules == nil {
 		o.backupModules = make(map[string]BackupModule)
@@ -76,6 +125,18 @@ func selectBackupModule(mods map[string]BackupModule, optionalName string) (Back
 
 }
 
+// backupModuleName is the name of the module [selectBackupModule] selects for [optionalName].
+func backupModuleName(mods map[string]BackupModule, optionalName string) string {
+	if optionalName != "" {
+		return optionalName
+	}
+
+	for name := range mods { // single element in map, see selectBackupModule
+		return name
+	}
+	return ""
+}
+
 func selectRestoreModule(choices map[string]BackupModule, optionalName string) (RestorableBackupModule, error) {
 	mods := restorable(choices)
 	if len(mods) == 0 {
@@ -115,6 +176,37 @@ func restorable(in map[string]BackupModule) map[string]RestorableBackupModule {
 	return out
 }
 
+// listBackups returns the backups of the module [optionalName], or of every listable module when
+// empty, keyed by module name.
+func (o *Operator) listBackups(optionalName string, offset, limit int) (map[string][]string, error) {
+	out := make(map[string][]string)
+	for name, mod := range o.backupModules {
+		if optionalName != "" && name != optionalName {
+			continue
+		}
+
+		lister, ok := mod.(ListableBackupModule)
+		if !ok {
+			if optionalName != "" {
+				return nil, fmt.Errorf("backup module %q does not support listing", optionalName)
+			}
+			continue
+		}
+
+		backups, err := lister.List(offset, limit)
+		if err != nil {
+			return nil, fmt.Errorf("list backups of %q: %w", name, err)
+		}
+		out[name] = backups
+	}
+
+	if optionalName != "" && len(out) == 0 {
+		return nil, fmt.Errorf("invalid backup module: %s", optionalName)
+	}
+
+	return out, nil
+}
+
 func NewBackupSchedule(freqBlocks, freqTime, requiredHostname, backuperName string) (*BackupSchedule, error) {
 	switch {
 	case freqBlocks != "":
@@ -124,7 +216,7 @@ func NewBackupSchedule(freqBlocks, freqTime, requiredHostname, backuperName stri
 		}
 
 		return &BackupSchedule{
-			BlocksBetweenRuns:     int(freqUint),
+			BlocksBetweenRuns:     freqUint,
 			RequiredHostnameMatch: requiredHostname,
 			BackuperName:          backuperName,
 		}, nil
@@ -1,6 +1,10 @@
 package operator
 
 import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -71,3 +75,103 @@ func TestParseKVConfigString(t *testing.T) {
 		})
 	}
 }
+
+type testListableModule struct {
+	*testSuperviser
+	backups []string
+}
+
+func (m *testListableModule) List(offset, limit int) ([]string, error) {
+	out := m.backups[min(offset, len(m.backups)):]
+	if limit > 0 && limit < len(out) {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func TestOperator_ListBackupsHandler(t *testing.T) {
+	o, superviser := newTestOperator(t)
+	require.NoError(t, o.RegisterBackupModule("snapshot", superviser))
+	require.NoError(t, o.RegisterBackupModule("tarball", &testListableModule{superviser, []string{"b3", "b2", "b1"}}))
+
+	cases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       map[string][]string
+	}{
+		{"all listable", "", http.StatusOK, map[string][]string{"tarball": {"b3", "b2", "b1"}}},
+		{"paginated", "?name=tarball&offset=1&limit=1", http.StatusOK, map[string][]string{"tarball": {"b2"}}},
+		{"not listable", "?name=snapshot", http.StatusBadRequest, nil},
+		{"unknown module", "?name=other", http.StatusBadRequest, nil},
+		{"invalid limit", "?limit=-1", http.StatusBadRequest, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			o.listBackupsHandler(w, httptest.NewRequest(http.MethodGet, "/v1/list_backups"+c.query, nil))
+			require.Equal(t, c.expectedStatus, w.Code)
+
+			if c.expected != nil {
+				var response struct {
+					Backups map[string][]string `json:"backups"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, c.expected, response.Backups)
+			}
+		})
+	}
+}
+
+type testLegacyModule struct {
+	blockNums []uint32
+}
+
+func (m *testLegacyModule) RequiresStop() bool { return true }
+
+func (m *testLegacyModule) Backup(lastSeenBlockNum uint32) (string, error) {
+	m.blockNums = append(m.blockNums, lastSeenBlockNum)
+	return "legacy", nil
+}
+
+type testLegacyRestorableModule struct {
+	testLegacyModule
+	restored string
+}
+
+func (m *testLegacyRestorableModule) Restore(name string) error {
+	m.restored = name
+	return nil
+}
+
+func TestAdaptLegacyBackupModule(t *testing.T) {
+	legacy := &testLegacyModule{}
+	mod := AdaptLegacyBackupModule(legacy)
+	assert.True(t, mod.RequiresStop())
+
+	_, restorable := mod.(RestorableBackupModule)
+	assert.False(t, restorable)
+
+	_, err := mod.Backup(math.MaxUint32)
+	require.NoError(t, err)
+
+	_, err = mod.Backup(math.MaxUint32 + 1)
+	require.Error(t, err)
+	assert.Equal(t, []uint32{math.MaxUint32}, legacy.blockNums)
+
+	legacyRestorable := &testLegacyRestorableModule{}
+	restorer, ok := AdaptLegacyBackupModule(legacyRestorable).(RestorableBackupModule)
+	require.True(t, ok)
+	require.NoError(t, restorer.Restore("snapshot-1"))
+	assert.Equal(t, "snapshot-1", legacyRestorable.restored)
+}
+
+func TestNewBackupSchedule_BlocksAbove32Bits(t *testing.T) {
+	sched, err := NewBackupSchedule("5000000000", "", "", "tarball")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5_000_000_000), sched.BlocksBetweenRuns)
+
+	_, err = NewBackupSchedule("18446744073709551616", "", "", "tarball")
+	require.Error(t, err)
+}
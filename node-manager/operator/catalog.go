@@ -0,0 +1,283 @@
+package operator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/mod/semver"
+)
+
+// BackupCatalogEntry describes a completed backup, recorded in the [BackupCatalog].
+type BackupCatalogEntry struct {
+	Name            string    `json:"name"`
+	Module          string    `json:"module"`
+	BlockNum        uint64    `json:"block_num"`
+	BlockHash       string    `json:"block_hash"`
+	NodeVersion     string    `json:"node_version"`
+	SizeBytes       int64     `json:"size_bytes"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	CreatedAt       time.Time `json:"created_at"`
+	// URL is where the backup can be fetched from, empty when it cannot be addressed by URL (disk
+	// snapshots for example), only the entries with an URL can bootstrap a node
+	URL string `json:"url"`
+}
+
+// BackupCatalog records the backups taken by the operator, across modules, so that restores and
+// bootstraps can pick one by block or node version.
+type BackupCatalog interface {
+	Record(entry *BackupCatalogEntry) error
+	Delete(module, name string) error
+	Entries() ([]*BackupCatalogEntry, error)
+}
+
+// DescribableBackupModule is a backup module giving the location and size of its backups,
+// recorded in the catalog. The url is empty when the backup is not addressable by URL.
+type DescribableBackupModule interface {
+	BackupModule
+	Describe(name string) (url string, sizeBytes int64, err error)
+}
+
+// BackupQuery selects a backup among the catalog entries, the zero value selecting the latest one.
+type BackupQuery struct {
+	// Module restricts the selection to the backups of this module when set
+	Module string
+	// AtOrBeforeBlock restricts the selection to the backups at or before this block when set
+	AtOrBeforeBlock *uint64
+	// NodeVersion restricts the selection to the backups compatible with this node version when
+	// set, see [NodeVersionsCompatible]
+	NodeVersion string
+	// RequireURL restricts the selection to the backups having an URL, the ones a bootstrapper can fetch
+	RequireURL bool
+}
+
+func (q *BackupQuery) String() string {
+	var criteria []string
+	if q.Module != "" {
+		criteria = append(criteria, fmt.Sprintf("module %q", q.Module))
+	}
+	if q.AtOrBeforeBlock != nil {
+		criteria = append(criteria, fmt.Sprintf("at or before block #%d", *q.AtOrBeforeBlock))
+	}
+	if q.NodeVersion != "" {
+		criteria = append(criteria, fmt.Sprintf("compatible with node version %s", q.NodeVersion))
+	}
+	if q.RequireURL {
+		criteria = append(criteria, "with an URL")
+	}
+
+	if len(criteria) == 0 {
+		return "latest"
+	}
+	return "latest " + strings.Join(criteria, ", ")
+}
+
+// SelectBackup returns the entry of the highest block, the most recent one on equal blocks,
+// matching [query].
+func SelectBackup(entries []*BackupCatalogEntry, query BackupQuery) (*BackupCatalogEntry, error) {
+	var selected *BackupCatalogEntry
+	for _, entry := range entries {
+		if query.Module != "" && entry.Module != query.Module {
+			continue
+		}
+		if query.AtOrBeforeBlock != nil && entry.BlockNum > *query.AtOrBeforeBlock {
+			continue
+		}
+		if query.NodeVersion != "" && !NodeVersionsCompatible(entry.NodeVersion, query.NodeVersion) {
+			continue
+		}
+		if query.RequireURL && entry.URL == "" {
+			continue
+		}
+
+		if selected == nil || entry.BlockNum > selected.BlockNum || (entry.BlockNum == selected.BlockNum && entry.CreatedAt.After(selected.CreatedAt)) {
+			selected = entry
+		}
+	}
+
+	if selected == nil {
+		return nil, fmt.Errorf("no backup found in catalog matching %s", query.String())
+	}
+	return selected, nil
+}
+
+// SortBackupCatalogEntries sorts [entries] the most recent (highest block) first.
+func SortBackupCatalogEntries(entries []*BackupCatalogEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].BlockNum != entries[j].BlockNum {
+			return entries[i].BlockNum > entries[j].BlockNum
+		}
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+}
+
+// NodeVersionsCompatible returns whether a backup taken by the node at [backupVersion] can be used
+// by the node at [nodeVersion]. Semantic versions (with or without the `v` prefix) are compatible
+// when they share the same major version (major and minor for `0.x`) and the backup was not taken
+// by a newer version, other versions must be equal.
+func NodeVersionsCompatible(backupVersion, nodeVersion string) bool {
+	backup, node := canonicalVersion(backupVersion), canonicalVersion(nodeVersion)
+	if !semver.IsValid(backup) || !semver.IsValid(node) {
+		return backupVersion == nodeVersion
+	}
+
+	if semver.Major(backup) != semver.Major(node) {
+		return false
+	}
+	if semver.Major(node) == "v0" && semver.MajorMinor(backup) != semver.MajorMinor(node) {
+		return false
+	}
+
+	return semver.Compare(backup, node) <= 0
+}
+
+func canonicalVersion(version string) string {
+	if version != "" && !strings.HasPrefix(version, "v") {
+		return "v" + version
+	}
+	return version
+}
+
+// backupQueryFromCommand returns the backup query of the `restore` command when it selects the
+// backup through the catalog, with the `atOrBeforeBlock` and `compatible` params.
+func (o *Operator) backupQueryFromCommand(cmd *Command) (*BackupQuery, error) {
+	atOrBeforeBlock := GetCommandParamOr(cmd, "atOrBeforeBlock", "")
+	compatible := GetCommandParamOr(cmd, "compatible", "")
+	if atOrBeforeBlock == "" && (compatible == "" || compatible == "false") {
+		return nil, nil
+	}
+
+	query := &BackupQuery{Module: GetCommandParamOr(cmd, "name", "")}
+	if atOrBeforeBlock != "" {
+		blockNum, err := strconv.ParseUint(atOrBeforeBlock, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid atOrBeforeBlock %q: %w", atOrBeforeBlock, err)
+		}
+		query.AtOrBeforeBlock = &blockNum
+	}
+
+	if compatible == "true" {
+		if o.options.NodeVersion == "" {
+			return nil, fmt.Errorf("restoring a compatible backup requires the node version to be configured")
+		}
+		query.NodeVersion = o.options.NodeVersion
+	}
+
+	return query, nil
+}
+
+// selectCatalogBackup returns the module and name of the backup matching [query] in the catalog.
+func (o *Operator) selectCatalogBackup(query *BackupQuery) (string, string, error) {
+	if o.options.BackupCatalog == nil {
+		return "", "", fmt.Errorf("selecting a backup by block or node version requires a backup catalog")
+	}
+
+	entries, err := o.options.BackupCatalog.Entries()
+	if err != nil {
+		return "", "", fmt.Errorf("read backup catalog: %w", err)
+	}
+
+	entry, err := SelectBackup(entries, *query)
+	if err != nil {
+		return "", "", err
+	}
+
+	return entry.Module, entry.Name, nil
+}
+
+// recordBackup records the backup [name] completed by [module] at block [blockNum] of id [blockID] in
+// the catalog, then removes the entries of the backups the module no longer has. Failures are logged,
+// the backup itself succeeded.
+func (o *Operator) recordBackup(module string, mod BackupModule, name string, blockNum uint64, blockID string, duration time.Duration) {
+	catalog := o.options.BackupCatalog
+	if catalog == nil {
+		return
+	}
+
+	entry := &BackupCatalogEntry{
+		Name:            name,
+		Module:          module,
+		BlockNum:        blockNum,
+		BlockHash:       blockID,
+		NodeVersion:     o.options.NodeVersion,
+		DurationSeconds: duration.Seconds(),
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	if describable, ok := mod.(DescribableBackupModule); ok {
+		url, size, err := describable.Describe(name)
+		if err != nil {
+			o.zlogger.Warn("unable to describe backup, recording it without url nor size", zap.String("backup_name", name), zap.Error(err))
+		}
+		entry.URL, entry.SizeBytes = url, size
+	}
+
+	if err := catalog.Record(entry); err != nil {
+		o.zlogger.Warn("unable to record backup in catalog", zap.String("backup_name", name), zap.Error(err))
+		return
+	}
+
+	lister, ok := mod.(ListableBackupModule)
+	if !ok {
+		return
+	}
+
+	o.pruneCatalog(catalog, module, lister)
+}
+
+// pruneCatalog deletes the entries of [module] whose backup was deleted, by retention for example.
+func (o *Operator) pruneCatalog(catalog BackupCatalog, module string, lister ListableBackupModule) {
+	backups, err := lister.List(0, 0)
+	if err != nil {
+		o.zlogger.Warn("unable to list backups to prune catalog", zap.String("module", module), zap.Error(err))
+		return
+	}
+
+	existing := make(map[string]bool, len(backups))
+	for _, backup := range backups {
+		existing[backup] = true
+	}
+
+	entries, err := catalog.Entries()
+	if err != nil {
+		o.zlogger.Warn("unable to read backup catalog to prune it", zap.Error(err))
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.Module != module || existing[entry.Name] {
+			continue
+		}
+
+		o.zlogger.Info("removing deleted backup from catalog", zap.String("module", module), zap.String("backup_name", entry.Name))
+		if err := catalog.Delete(module, entry.Name); err != nil {
+			o.zlogger.Warn("unable to remove backup from catalog", zap.String("backup_name", entry.Name), zap.Error(err))
+		}
+	}
+}
+
+// catalogEntries returns the catalog entries of [module] (every module when empty), the most
+// recent first, paginated by [offset] and [limit].
+func (o *Operator) catalogEntries(module string, offset, limit int) ([]*BackupCatalogEntry, error) {
+	entries, err := o.options.BackupCatalog.Entries()
+	if err != nil {
+		return nil, fmt.Errorf("read backup catalog: %w", err)
+	}
+
+	out := make([]*BackupCatalogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if module == "" || entry.Module == module {
+			out = append(out, entry)
+		}
+	}
+	SortBackupCatalogEntries(out)
+
+	out = out[min(offset, len(out)):]
+	if limit > 0 && limit < len(out) {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,227 @@
+package operator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSelectBackup(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []*BackupCatalogEntry{
+		{Name: "a-100", Module: "a", BlockNum: 100, NodeVersion: "v1.2.0", URL: "gs://a/100.tar.zst", CreatedAt: at},
+		{Name: "b-200", Module: "b", BlockNum: 200, NodeVersion: "v1.3.0", CreatedAt: at},
+		{Name: "a-200", Module: "a", BlockNum: 200, NodeVersion: "v2.0.0", URL: "gs://a/200.tar.zst", CreatedAt: at.Add(time.Hour)},
+		{Name: "a-150", Module: "a", BlockNum: 150, NodeVersion: "custom", URL: "gs://a/150.tar.zst", CreatedAt: at},
+	}
+
+	block := func(num uint64) *uint64 { return &num }
+
+	cases := []struct {
+		name     string
+		query    BackupQuery
+		expected string
+	}{
+		{"latest, most recent on equal blocks", BackupQuery{}, "a-200"},
+		{"module", BackupQuery{Module: "b"}, "b-200"},
+		{"at or before block", BackupQuery{AtOrBeforeBlock: block(199)}, "a-150"},
+		{"at or before exact block", BackupQuery{AtOrBeforeBlock: block(100)}, "a-100"},
+		{"compatible node version", BackupQuery{NodeVersion: "v1.4.1"}, "b-200"},
+		{"compatible node version with URL", BackupQuery{NodeVersion: "1.4.1", RequireURL: true}, "a-100"},
+		{"non semantic node version", BackupQuery{NodeVersion: "custom"}, "a-150"},
+		{"none before block", BackupQuery{AtOrBeforeBlock: block(99)}, ""},
+		{"none compatible", BackupQuery{NodeVersion: "v1.1.0"}, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			entry, err := SelectBackup(entries, c.query)
+			if c.expected == "" {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, entry.Name)
+		})
+	}
+}
+
+func TestNodeVersionsCompatible(t *testing.T) {
+	cases := []struct {
+		backup, node string
+		expected     bool
+	}{
+		{"v1.2.0", "v1.2.0", true},
+		{"v1.2.0", "v1.9.3", true},
+		{"1.2.0", "v1.2.1", true},
+		{"v1.3.0", "v1.2.0", false},
+		{"v1.2.0", "v2.0.0", false},
+		{"v0.4.1", "v0.4.7", true},
+		{"v0.4.1", "v0.5.0", false},
+		{"nightly-abc", "nightly-abc", true},
+		{"nightly-abc", "v1.0.0", false},
+		{"", "v1.0.0", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, NodeVersionsCompatible(c.backup, c.node), "backup %q node %q", c.backup, c.node)
+	}
+}
+
+type memoryCatalog struct {
+	entries []*BackupCatalogEntry
+}
+
+func (c *memoryCatalog) Record(entry *BackupCatalogEntry) error {
+	c.entries = append(c.entries, entry)
+	return nil
+}
+
+func (c *memoryCatalog) Delete(module, name string) error {
+	c.entries = slices.DeleteFunc(c.entries, func(entry *BackupCatalogEntry) bool {
+		return entry.Module == module && entry.Name == name
+	})
+	return nil
+}
+
+func (c *memoryCatalog) Entries() ([]*BackupCatalogEntry, error) {
+	return slices.Clone(c.entries), nil
+}
+
+// testCatalogModule keeps its last 2 backups and records its restores.
+type testCatalogModule struct {
+	backups  []string
+	restored []string
+	onBackup func()
+}
+
+func (m *testCatalogModule) RequiresStop() bool { return false }
+
+func (m *testCatalogModule) Backup(lastSeenBlockNum uint64) (string, error) {
+	if m.onBackup != nil {
+		m.onBackup()
+	}
+
+	name := fmt.Sprintf("backup-%d", lastSeenBlockNum)
+	m.backups = append([]string{name}, m.backups[:min(1, len(m.backups))]...)
+	return name, nil
+}
+
+func (m *testCatalogModule) Restore(name string) error {
+	m.restored = append(m.restored, name)
+	return nil
+}
+
+func (m *testCatalogModule) List(offset, limit int) ([]string, error) {
+	return m.backups, nil
+}
+
+func (m *testCatalogModule) Describe(name string) (string, int64, error) {
+	return "file:///backups/" + name + ".tar.zst", 1024, nil
+}
+
+func TestOperator_BackupCatalog(t *testing.T) {
+	catalog := &memoryCatalog{}
+	superviser := newTestSuperviser()
+	o, err := New(zap.NewNop(), superviser, testReadiness{}, &Options{BackupCatalog: catalog, NodeVersion: "v1.2.0"})
+	require.NoError(t, err)
+
+	module := &testCatalogModule{}
+	require.NoError(t, o.RegisterBackupModule("tarball", module))
+
+	for _, blockNum := range []uint64{100, 200, 300} {
+		superviser.lastSeenBlockNum = blockNum
+		require.NoError(t, o.runCommand(&Command{cmd: "backup", logger: o.zlogger}))
+	}
+
+	// The entry of the backup deleted by the module is pruned from the catalog
+	require.Len(t, catalog.entries, 2)
+	entry := catalog.entries[1]
+	assert.Equal(t, "tarball", entry.Module)
+	assert.Equal(t, uint64(300), entry.BlockNum)
+	assert.Equal(t, "v1.2.0", entry.NodeVersion)
+	assert.Equal(t, "file:///backups/"+entry.Name+".tar.zst", entry.URL)
+	assert.Equal(t, int64(1024), entry.SizeBytes)
+	assert.False(t, entry.CreatedAt.IsZero())
+
+	restore := func(params map[string]any) error {
+		cmd := &Command{cmd: "restore", params: params, logger: o.zlogger, returnch: make(chan error, 1)}
+		require.NoError(t, o.runCommand(cmd))
+		select {
+		case err := <-cmd.returnch:
+			return err
+		default:
+			return nil
+		}
+	}
+
+	require.NoError(t, restore(map[string]any{"atOrBeforeBlock": "250"}))
+	require.NoError(t, restore(map[string]any{"compatible": "true"}))
+	assert.Equal(t, []string{catalog.entries[0].Name, catalog.entries[1].Name}, module.restored)
+
+	assert.Error(t, restore(map[string]any{"atOrBeforeBlock": "150"}))
+	assert.Error(t, restore(map[string]any{"atOrBeforeBlock": "abc"}))
+}
+
+// testIdentifiedSuperviser knows the id of its last seen block
+type testIdentifiedSuperviser struct {
+	*testSuperviser
+
+	lastSeenBlock bstream.BlockRef
+}
+
+func (s *testIdentifiedSuperviser) LastSeenBlock() bstream.BlockRef { return s.lastSeenBlock }
+
+func TestOperator_BackupCatalogBlockCapturedBeforeBackup(t *testing.T) {
+	catalog := &memoryCatalog{}
+	superviser := &testIdentifiedSuperviser{testSuperviser: newTestSuperviser(), lastSeenBlock: bstream.NewBlockRef("00a", 10)}
+	o, err := New(zap.NewNop(), superviser, testReadiness{}, &Options{BackupCatalog: catalog})
+	require.NoError(t, err)
+
+	// The node keeps producing blocks while a backup not requiring a stop runs
+	module := &testCatalogModule{onBackup: func() {
+		superviser.lastSeenBlock = bstream.NewBlockRef("00b", 11)
+	}}
+	require.NoError(t, o.RegisterBackupModule("tarball", module))
+	require.NoError(t, o.runCommand(&Command{cmd: "backup", logger: o.zlogger}))
+
+	require.Len(t, catalog.entries, 1)
+	assert.Equal(t, "backup-10", catalog.entries[0].Name)
+	assert.Equal(t, uint64(10), catalog.entries[0].BlockNum)
+	assert.Equal(t, "00a", catalog.entries[0].BlockHash)
+}
+
+func TestOperator_BackupCatalogBlocksAbove32Bits(t *testing.T) {
+	catalog := &memoryCatalog{}
+	superviser := newTestSuperviser()
+	o, err := New(zap.NewNop(), superviser, testReadiness{}, &Options{BackupCatalog: catalog})
+	require.NoError(t, err)
+
+	module := &testCatalogModule{}
+	require.NoError(t, o.RegisterBackupModule("tarball", module))
+
+	for _, blockNum := range []uint64{math.MaxUint32, math.MaxUint32 + 1} {
+		superviser.lastSeenBlockNum = blockNum
+		require.NoError(t, o.runCommand(&Command{cmd: "backup", logger: o.zlogger}))
+	}
+
+	require.Len(t, catalog.entries, 2)
+	assert.Equal(t, "backup-4294967296", catalog.entries[1].Name)
+	assert.Equal(t, uint64(math.MaxUint32+1), catalog.entries[1].BlockNum)
+	assert.Equal(t, uint64(math.MaxUint32+1), o.Status().Backups[0].LastBackupBlockNum)
+
+	query, err := o.backupQueryFromCommand(&Command{params: map[string]any{"atOrBeforeBlock": "4294967295"}})
+	require.NoError(t, err)
+
+	_, name, err := o.selectCatalogBackup(query)
+	require.NoError(t, err)
+	assert.Equal(t, "backup-4294967295", name)
+}
@@ -15,9 +15,13 @@
 package operator
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/streamingfast/derr"
@@ -26,6 +30,11 @@ import (
 
 type HTTPOption func(r *mux.Router)
 
+const (
+	defaultStatusStreamInterval = 10 * time.Second
+	statusStreamPollInterval    = time.Second
+)
+
 func (o *Operator) RunHTTPServer(httpListenAddr string, options ...HTTPOption) *http.Server {
 	r := mux.NewRouter()
 	r.HandleFunc("/v1/ping", o.pingHandler).Methods("GET")
@@ -33,6 +42,9 @@ func (o *Operator) RunHTTPServer(httpListenAddr string, options ...HTTPOption) *
 	r.HandleFunc("/v1/healthz", o.healthzHandler).Methods("GET")
 	r.HandleFunc("/v1/server_id", o.serverIDHandler).Methods("GET")
 	r.HandleFunc("/v1/is_running", o.isRunningHandler).Methods("GET")
+	r.HandleFunc("/v1/restarts", o.restartsHandler).Methods("GET")
+	r.HandleFunc("/v1/status", o.statusHandler).Methods("GET")
+	r.HandleFunc("/v1/status/stream", o.statusStreamHandler).Methods("GET")
 	r.HandleFunc("/v1/start_command", o.startcommandHandler).Methods("GET")
 	r.HandleFunc("/v1/maintenance", o.maintenanceHandler).Methods("POST")
 	r.HandleFunc("/v1/resume", o.resumeHandler).Methods("POST")
@@ -48,6 +60,8 @@ func (o *Operator) RunHTTPServer(httpListenAddr string, options ...HTTPOption) *
 		opt(r)
 	}
 
+	r.Use(o.authMiddleware)
+
 	o.zlogger.Info("starting webserver", zap.String("http_addr", httpListenAddr))
 	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 		pathTemplate, err := route.GetPathTemplate()
@@ -69,9 +83,16 @@ func (o *Operator) RunHTTPServer(httpListenAddr string, options ...HTTPOption) *
 		o.zlogger.Error("walking route methods", zap.Error(err))
 	}
 
-	srv := &http.Server{Addr: httpListenAddr, Handler: r}
+	srv := &http.Server{Addr: httpListenAddr, Handler: r, TLSConfig: o.options.APITLSConfig}
 	go func() {
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+
+		if err != http.ErrServerClosed {
 			o.zlogger.Info("http server did not close correctly")
 			o.Shutdown(err)
 		}
@@ -93,6 +114,84 @@ func (o *Operator) isRunningHandler(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte(fmt.Sprintf(`{"is_running":%t}`, o.Superviser.IsRunning())))
 }
 
+func (o *Operator) restartsHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(o.restarts.status())
+}
+
+func (o *Operator) statusHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(o.Status())
+}
+
+// statusStreamHandler streams the status as Server-Sent Events (`event: status`, with the JSON
+// [Status] as data), on connection, on every state change and at least every `interval` (default
+// `10s`, minimum `1s`) so that the uptimes and last seen block keep moving.
+func (o *Operator) statusStreamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	interval := defaultStatusStreamInterval
+	if value := r.FormValue("interval"); value != "" {
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil || interval < time.Second {
+			http.Error(w, fmt.Sprintf("invalid interval %q, must be a duration of at least 1s", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	poll := time.NewTicker(statusStreamPollInterval)
+	defer poll.Stop()
+
+	var lastKey []byte
+	var lastSentAt time.Time
+	force := true
+	for {
+		// Taken before reading the status so that a change happening in between is not missed
+		changed := o.state.changed()
+
+		status := o.Status()
+		key, err := json.Marshal(status.transitionKey())
+		if err != nil {
+			o.zlogger.Warn("unable to marshal operator status", zap.Error(err))
+			return
+		}
+
+		if force || !bytes.Equal(key, lastKey) || time.Since(lastSentAt) >= interval {
+			data, err := json.Marshal(status)
+			if err != nil {
+				o.zlogger.Warn("unable to marshal operator status", zap.Error(err))
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+
+			lastKey, lastSentAt = key, time.Now()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-o.Terminated():
+			return
+		case <-changed:
+			force = true
+		case <-poll.C:
+			force = false
+		}
+	}
+}
+
 func (o *Operator) serverIDHandler(w http.ResponseWriter, _ *http.Request) {
 	id, err := o.Superviser.ServerID()
 	if err != nil {
@@ -139,13 +238,44 @@ func (o *Operator) safelyPauseProdHandler(w http.ResponseWriter, r *http.Request
 }
 
 func (o *Operator) restoreHandler(w http.ResponseWriter, r *http.Request) {
-	params := getRequestParams(r, "backupName", "backupTag", "forceVerify")
+	params := getRequestParams(r, "name", "backupName", "backupTag", "forceVerify", "atOrBeforeBlock", "compatible")
 	o.triggerWebCommand("restore", params, w, r)
 }
 
+// listBackupsHandler lists the backups of the module `name`, or of every module supporting it, the
+// most recent first, paginated with `offset` and `limit`, along the matching backup catalog entries
+// when a catalog is configured.
 func (o *Operator) listBackupsHandler(w http.ResponseWriter, r *http.Request) {
-	params := getRequestParams(r, "offset", "limit")
-	o.triggerWebCommand("list", params, w, r)
+	var offset, limit int
+	for param, value := range map[string]*int{"offset": &offset, "limit": &limit} {
+		if raw := r.FormValue(param); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 0 {
+				http.Error(w, fmt.Sprintf("invalid %s %q, must be a positive integer", param, raw), http.StatusBadRequest)
+				return
+			}
+			*value = parsed
+		}
+	}
+
+	backups, err := o.listBackups(r.FormValue("name"), offset, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]any{"backups": backups}
+	if o.options.BackupCatalog != nil {
+		entries, err := o.catalogEntries(r.FormValue("name"), offset, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response["catalog"] = entries
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
 }
 
 func getRequestParams(r *http.Request, terms ...string) map[string]any {
@@ -197,6 +327,11 @@ func (o *Operator) resumeHandler(w http.ResponseWriter, r *http.Request) {
 func (o *Operator) triggerWebCommand(cmdName string, params map[string]any, w http.ResponseWriter, r *http.Request) {
 	c := &Command{cmd: cmdName, logger: o.zlogger}
 	c.params = params
+
+	entry := newAuditEntry(r, principalFromContext(r.Context()), cmdName, params)
+	c.audited = func(err error) {
+		o.audit(entry.completed(err))
+	}
 	sync := r.FormValue("sync")
 	if sync == "true" {
 		o.sendCommandSync(c, w)
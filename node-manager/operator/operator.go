@@ -15,6 +15,7 @@
 package operator
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/streamingfast/derr"
 	nodeManager "github.com/streamingfast/firehose-core/node-manager"
+	"github.com/streamingfast/firehose-core/node-manager/metrics"
 	"github.com/streamingfast/shutter"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
@@ -44,6 +46,9 @@ type Operator struct {
 	Superviser     nodeManager.ChainSuperviser
 	chainReadiness nodeManager.Readiness
 
+	restarts *restarts
+	state    *operatorState
+
 	aboutToStop *atomic.Bool
 	zlogger     *zap.Logger
 }
@@ -55,6 +60,22 @@ type Options struct {
 
 	// Delay before sending Stop() to superviser, during which we return NotReady
 	ShutdownDelay time.Duration
+
+	// RestartPolicy, when set, restarts the node process when it exits outside of a command instead
+	// of shutting down the operator
+	RestartPolicy *RestartPolicy
+
+	// APIAuth, when set, requires the callers of the HTTP API to authenticate, see [ParseAPIAuth]
+	APIAuth *APIAuth
+	// APITLSConfig, when set, serves the HTTP API over TLS, see [NewAPITLSConfig]
+	APITLSConfig *tls.Config
+	// AuditSink, when set, receives the audit entries of the HTTP API commands on top of the logs
+	AuditSink AuditSink
+
+	// BackupCatalog, when set, records the completed backups and allows restoring by block or node version
+	BackupCatalog BackupCatalog
+	// NodeVersion is the version of the node binary, recorded in the backup catalog
+	NodeVersion string
 }
 
 type Command struct {
@@ -63,6 +84,9 @@ type Command struct {
 	returnch chan error
 	closer   sync.Once
 	logger   *zap.Logger
+
+	// audited, when set, is called with the outcome of the command
+	audited func(err error)
 }
 
 func (c *Command) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
@@ -84,12 +108,24 @@ func GetCommandParamOr[T any](c *Command, key string, defaultValue T) T {
 func New(zlogger *zap.Logger, chainSuperviser nodeManager.ChainSuperviser, chainReadiness nodeManager.Readiness, options *Options) (*Operator, error) {
 	zlogger.Info("creating operator", zap.Reflect("options", options))
 
+	if options.RestartPolicy != nil {
+		if err := options.RestartPolicy.validate(); err != nil {
+			return nil, fmt.Errorf("invalid restart policy: %w", err)
+		}
+	}
+
+	if options.APIAuth != nil && options.APIAuth.hasClients() && (options.APITLSConfig == nil || options.APITLSConfig.ClientCAs == nil) {
+		return nil, fmt.Errorf("API auth clients are identified by their TLS certificate, a client CA is required")
+	}
+
 	o := &Operator{
 		Shutter:        shutter.New(),
 		chainReadiness: chainReadiness,
 		commandChan:    make(chan *Command, 10),
 		options:        options,
 		Superviser:     chainSuperviser,
+		restarts:       newRestarts(options.RestartPolicy),
+		state:          newOperatorState(),
 		aboutToStop:    atomic.NewBool(false),
 		zlogger:        zlogger,
 	}
@@ -117,6 +153,8 @@ func New(zlogger *zap.Logger, chainSuperviser nodeManager.ChainSuperviser, chain
 }
 
 func (o *Operator) Launch(httpListenAddr string, options ...HTTPOption) error {
+	o.state.launched()
+
 	o.zlogger.Info("launching operator HTTP server", zap.String("http_listen_addr", httpListenAddr))
 	o.httpServer = o.RunHTTPServer(httpListenAddr, options...)
 
@@ -138,36 +176,102 @@ func (o *Operator) Launch(httpListenAddr string, options ...HTTPOption) error {
 	}
 	o.commandChan <- &Command{cmd: "start", logger: o.zlogger}
 
+	// handledStop is the stop of the node process a restart was scheduled for, the superviser keeps
+	// reporting it as stopped until it is started again
+	var handledStop <-chan struct{}
+	var pendingRestart <-chan time.Time
+	var pendingRecovery bool
+
 	for {
 		o.zlogger.Info("operator ready to receive commands")
+
+		stopped := o.Superviser.Stopped()
+		if stopped == handledStop {
+			stopped = nil
+		}
+
 		select {
-		case <-o.Superviser.Stopped(): // the chain stopped outside of a command that was expecting it.
+		case <-stopped: // the chain stopped outside of a command that was expecting it.
 			if o.Superviser.IsTerminating() {
 				o.zlogger.Info("superviser terminating, waiting for operator...")
 				<-o.Terminating()
 				return o.Err()
 			}
-			// FIXME call a restore handler if passed...
+
+			backoff, runRecovery, err := o.restarts.onStop(o.Superviser.LastExitCode())
+			if err == nil {
+				o.zlogger.Warn("instance stopped, restarting it",
+					zap.String("name", o.Superviser.GetName()),
+					zap.Int("exit_code", o.Superviser.LastExitCode()),
+					zap.Duration("backoff", backoff),
+					zap.Bool("recovery", runRecovery),
+				)
+
+				handledStop = stopped
+				pendingRestart = time.After(backoff)
+				pendingRecovery = runRecovery
+				o.state.notify()
+				break
+			}
+
 			lastLogLines := o.Superviser.LastLogLines()
 
 			// FIXME: Actually, we should create a custom error type that contains the required data, the catching
 			//        code can thus perform the required formatting!
-			baseFormat := "instance %q stopped (exit code: %d), shutting down"
+			shuttingDown := "shutting down"
+			if o.options.RestartPolicy != nil {
+				shuttingDown = fmt.Sprintf("shutting down, %s", err)
+			}
+
+			baseFormat := "instance %q stopped (exit code: %d), %s"
 			var shutdownErr error
 			if len(lastLogLines) > 0 {
-				shutdownErr = fmt.Errorf(baseFormat+": last log lines:\n%s", o.Superviser.GetName(), o.Superviser.LastExitCode(), formatLogLines(lastLogLines))
+				shutdownErr = fmt.Errorf(baseFormat+": last log lines:\n%s", o.Superviser.GetName(), o.Superviser.LastExitCode(), shuttingDown, formatLogLines(lastLogLines))
 			} else {
-				shutdownErr = fmt.Errorf(baseFormat, o.Superviser.GetName(), o.Superviser.LastExitCode())
+				shutdownErr = fmt.Errorf(baseFormat, o.Superviser.GetName(), o.Superviser.LastExitCode(), shuttingDown)
 			}
 
 			o.Shutdown(shutdownErr)
 			break
 
+		case <-pendingRestart:
+			pendingRestart = nil
+			o.restarts.onRestart()
+
+			if o.Superviser.IsRunning() {
+				// A command, a backup requiring a stop for example, started the node during the backoff
+				o.zlogger.Info("instance already running, skipping pending restart")
+				o.state.notify()
+				break
+			}
+
+			if pendingRecovery {
+				o.runRecoveryAction()
+			}
+
+			metrics.NodeRestarts.Inc()
+			cmd := &Command{cmd: "start", logger: o.zlogger}
+			o.state.commandStarted(cmd)
+			err := o.runCommand(cmd)
+			o.state.commandEnded()
+			if err != nil {
+				return fmt.Errorf("restart failed: %w", err)
+			}
+
 		case cmd := <-o.commandChan:
+			if pendingRestart != nil && lifecycleCommands[cmd.cmd] {
+				o.zlogger.Info("cancelling pending restart, lifecycle command received", zap.String("command", cmd.cmd))
+				pendingRestart = nil
+				o.restarts.onRestart()
+				o.state.notify()
+			}
+
 			if cmd.cmd == "start" { // start 'sub' commands after a restore do NOT come through here
 				o.lastStartCommand = time.Now()
 			}
+			o.state.commandStarted(cmd)
 			err := o.runCommand(cmd)
+			o.state.commandEnded()
 			cmd.Return(err)
 			if err != nil {
 				if err == ErrCleanExit {
@@ -179,6 +283,33 @@ func (o *Operator) Launch(httpListenAddr string, options ...HTTPOption) error {
 	}
 }
 
+// lifecycleCommands decide themselves whether the node runs, they cancel a pending restart while
+// the other commands (backup, restore, production ones) leave it scheduled.
+var lifecycleCommands = map[string]bool{
+	"start":         true,
+	"resume":        true,
+	"reload":        true,
+	"safely_reload": true,
+	"maintenance":   true,
+}
+
+// runRecoveryAction runs the restart policy recovery action, the node is restarted whatever its
+// outcome, the restart policy bounding the attempts.
+func (o *Operator) runRecoveryAction() {
+	o.zlogger.Info("running recovery action before restarting instance")
+
+	err := o.options.RestartPolicy.RecoveryAction(o)
+	o.restarts.onRecovery(err)
+	if err != nil {
+		metrics.NodeRecoveryActions.Inc("failure")
+		o.zlogger.Error("recovery action failed, restarting instance anyway", zap.Error(err))
+		return
+	}
+
+	metrics.NodeRecoveryActions.Inc("success")
+	o.zlogger.Info("recovery action completed")
+}
+
 func formatLogLines(lines []string) string {
 	formattedLines := make([]string, len(lines))
 	for i, line := range lines {
@@ -219,7 +350,24 @@ func (o *Operator) runCommand(cmd *Command) error {
 		o.zlogger.Info("successfully put in maintenance")
 
 	case "restore":
-		restoreMod, err := selectRestoreModule(o.backupModules, GetCommandParamOr(cmd, "name", ""))
+		moduleName := GetCommandParamOr(cmd, "name", "")
+		backupName := GetCommandParamOr(cmd, "backupName", "latest")
+
+		query, err := o.backupQueryFromCommand(cmd)
+		if err != nil {
+			cmd.Return(err)
+			return nil
+		}
+
+		if query != nil {
+			if moduleName, backupName, err = o.selectCatalogBackup(query); err != nil {
+				cmd.Return(err)
+				return nil
+			}
+			o.zlogger.Info("selected backup from catalog", zap.Stringer("query", query), zap.String("module", moduleName), zap.String("backup_name", backupName))
+		}
+
+		restoreMod, err := selectRestoreModule(o.backupModules, moduleName)
 		if err != nil {
 			cmd.Return(err)
 			return nil
@@ -232,7 +380,7 @@ func (o *Operator) runCommand(cmd *Command) error {
 			}
 		}
 
-		if err := restoreMod.Restore(GetCommandParamOr(cmd, "backupName", "latest")); err != nil {
+		if err := restoreMod.Restore(backupName); err != nil {
 			return err
 		}
 
@@ -256,12 +404,27 @@ func (o *Operator) runCommand(cmd *Command) error {
 			}
 		}
 
-		backupName, err := backupMod.Backup(uint32(o.Superviser.LastSeenBlockNum()))
+		// The number and id are captured together before the backup starts, the node may still be
+		// producing blocks while backup modules not requiring a stop run
+		lastSeenBlockNum := o.Superviser.LastSeenBlockNum()
+		var lastSeenBlockID string
+		if identified, ok := o.Superviser.(nodeManager.BlockIDChainSuperviser); ok {
+			if ref := identified.LastSeenBlock(); ref != nil {
+				lastSeenBlockNum, lastSeenBlockID = ref.Num(), ref.ID()
+			}
+		}
+
+		start := time.Now()
+		backupName, err := backupMod.Backup(lastSeenBlockNum)
 		if err != nil {
 			return err
 		}
 		cmd.logger.Info("Completed backup", zap.String("backup_name", backupName))
 
+		moduleName := backupModuleName(o.backupModules, GetCommandParamOr(cmd, "name", ""))
+		o.state.backupCompleted(moduleName, backupName, lastSeenBlockNum)
+		o.recordBackup(moduleName, backupMod, backupName, lastSeenBlockNum, lastSeenBlockID, time.Since(start))
+
 		o.zlogger.Info("Restarting after backup")
 		if backupMod.RequiresStop() {
 			return o.runSubCommand("start", cmd)
@@ -359,6 +522,7 @@ func (o *Operator) runCommand(cmd *Command) error {
 			select {
 			case interimCmd := <-o.commandChan:
 				o.zlogger.Info("emptying command queue while safely_reload was running, dropped", zap.Any("interim_cmd", interimCmd))
+				interimCmd.Return(fmt.Errorf("dropped by safely_reload"))
 			default:
 				emptied = true
 			}
@@ -393,6 +557,7 @@ func (o *Operator) runCommand(cmd *Command) error {
 		if err := o.Superviser.Start(options...); err != nil {
 			return fmt.Errorf("error starting chain superviser: %w", err)
 		}
+		o.state.processStarted()
 
 		o.zlogger.Info("successfully start service")
 
@@ -407,6 +572,10 @@ func (c *Command) Return(err error) {
 			c.logger.Error("command failed", zap.String("cmd", c.cmd), zap.Error(err))
 		}
 
+		if c.audited != nil {
+			c.audited(err)
+		}
+
 		if c.returnch != nil {
 			c.returnch <- err
 		}
@@ -441,10 +610,10 @@ func (o *Operator) LaunchBackupSchedules() {
 		}
 		if sched.BlocksBetweenRuns > 0 {
 			o.zlogger.Info("starting block-based schedule for backup",
-				zap.Int("blocks_between_runs", sched.BlocksBetweenRuns),
+				zap.Uint64("blocks_between_runs", sched.BlocksBetweenRuns),
 				zap.String("backuper_name", sched.BackuperName),
 			)
-			go o.RunEveryXBlock(uint32(sched.BlocksBetweenRuns), "backup", cmdParams)
+			go o.RunEveryXBlock(sched.BlocksBetweenRuns, "backup", cmdParams)
 		}
 	}
 }
@@ -467,7 +636,7 @@ func (o *Operator) RunEveryPeriod(period time.Duration, commandName string, para
 	}
 }
 
-func (o *Operator) RunEveryXBlock(freq uint32, commandName string, params map[string]any) {
+func (o *Operator) RunEveryXBlock(freq uint64, commandName string, params map[string]any) {
 	var lastHeadReference uint64
 	for {
 		time.Sleep(1 * time.Second)
@@ -480,7 +649,7 @@ func (o *Operator) RunEveryXBlock(freq uint32, commandName string, params map[st
 			lastHeadReference = lastSeenBlockNum
 		}
 
-		if lastSeenBlockNum > lastHeadReference+uint64(freq) {
+		if lastSeenBlockNum > lastHeadReference+freq {
 			o.commandChan <- &Command{cmd: commandName, logger: o.zlogger, params: params}
 			lastHeadReference = lastSeenBlockNum
 		}
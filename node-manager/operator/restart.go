@@ -0,0 +1,202 @@
+package operator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/firehose-core/node-manager/metrics"
+)
+
+// RecoveryAction is run by the operator, with the node process stopped, before restarting it once
+// the restart policy `RecoveryAfter` consecutive failures is reached.
+type RecoveryAction func(o *Operator) error
+
+// RestoreBackupRecovery is a [RecoveryAction] restoring the backup [backupName] (`latest` when
+// empty) with the only restorable backup module registered.
+func RestoreBackupRecovery(backupName string) RecoveryAction {
+	if backupName == "" {
+		backupName = "latest"
+	}
+
+	return func(o *Operator) error {
+		restoreMod, err := selectRestoreModule(o.backupModules, "")
+		if err != nil {
+			return err
+		}
+
+		return restoreMod.Restore(backupName)
+	}
+}
+
+// RestartPolicy controls what the operator does when the node process exits outside of a command
+// expecting it. Without a policy, the operator shuts down.
+type RestartPolicy struct {
+	// MaxRestarts is the number of restarts allowed within Window, the operator shuts down when the
+	// node process exits once more.
+	MaxRestarts int
+	Window      time.Duration
+
+	// InitialBackoff is the delay before the first restart within Window, doubled on every
+	// subsequent one up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// RecoveryAction, when set, is run before the restart of the RecoveryAfter-th consecutive
+	// failure within Window.
+	RecoveryAfter  int
+	RecoveryAction RecoveryAction
+}
+
+func (p *RestartPolicy) validate() error {
+	if p.MaxRestarts <= 0 {
+		return fmt.Errorf("max restarts must be positive, got %d", p.MaxRestarts)
+	}
+
+	if p.Window <= 0 {
+		return fmt.Errorf("window must be positive, got %s", p.Window)
+	}
+
+	if p.InitialBackoff < 0 || p.MaxBackoff < p.InitialBackoff {
+		return fmt.Errorf("invalid backoff %s to %s, max backoff must be greater than or equal to the initial one", p.InitialBackoff, p.MaxBackoff)
+	}
+
+	if p.RecoveryAction != nil && (p.RecoveryAfter <= 0 || p.RecoveryAfter > p.MaxRestarts) {
+		return fmt.Errorf("recovery after must be between 1 and max restarts (%d), got %d", p.MaxRestarts, p.RecoveryAfter)
+	}
+
+	return nil
+}
+
+// RestartStatus is the restart state reported by the operator HTTP API.
+type RestartStatus struct {
+	Enabled     bool   `json:"enabled"`
+	MaxRestarts int    `json:"max_restarts"`
+	Window      string `json:"window"`
+
+	RestartsInWindow int    `json:"restarts_in_window"`
+	TotalRestarts    uint64 `json:"total_restarts"`
+	Recoveries       uint64 `json:"recoveries"`
+
+	LastExitCode      *int       `json:"last_exit_code"`
+	LastStoppedAt     *time.Time `json:"last_stopped_at"`
+	NextRestartAt     *time.Time `json:"next_restart_at"`
+	LastRecoveryError string     `json:"last_recovery_error"`
+}
+
+// restarts keeps track of the restarts of the node process against the restart policy, it is
+// updated by the operator loop and read by the HTTP API.
+type restarts struct {
+	policy *RestartPolicy
+	now    func() time.Time
+
+	mu                sync.Mutex
+	inWindow          []time.Time
+	total             uint64
+	recoveries        uint64
+	lastExitCode      *int
+	lastStoppedAt     time.Time
+	nextRestartAt     time.Time
+	lastRecoveryError string
+}
+
+func newRestarts(policy *RestartPolicy) *restarts {
+	return &restarts{policy: policy, now: time.Now}
+}
+
+// onStop records that the node process exited with [exitCode] and returns the delay before
+// restarting it and whether the recovery action must be run first. It returns an error when the
+// policy does not allow another restart.
+func (r *restarts) onStop(exitCode int) (backoff time.Duration, runRecovery bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.lastExitCode = &exitCode
+	r.lastStoppedAt = now
+
+	if r.policy == nil {
+		return 0, false, fmt.Errorf("no restart policy")
+	}
+
+	r.prune(now)
+	if len(r.inWindow) >= r.policy.MaxRestarts {
+		return 0, false, fmt.Errorf("restarted %d times within %s already", len(r.inWindow), r.policy.Window)
+	}
+
+	r.inWindow = append(r.inWindow, now)
+	r.total++
+	failures := len(r.inWindow)
+
+	backoff = r.policy.InitialBackoff
+	for i := 1; i < failures && backoff < r.policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, r.policy.MaxBackoff)
+
+	r.nextRestartAt = now.Add(backoff)
+	metrics.NodeRestartsInWindow.SetUint64(uint64(failures))
+
+	return backoff, r.policy.RecoveryAction != nil && failures == r.policy.RecoveryAfter, nil
+}
+
+// onRestart records that the pending restart happened, or was cancelled by a command.
+func (r *restarts) onRestart() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextRestartAt = time.Time{}
+}
+
+func (r *restarts) onRecovery(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recoveries++
+	r.lastRecoveryError = ""
+	if err != nil {
+		r.lastRecoveryError = err.Error()
+	}
+}
+
+// prune drops the restarts older than the policy window, the caller must hold the lock.
+func (r *restarts) prune(now time.Time) {
+	i := 0
+	for i < len(r.inWindow) && now.Sub(r.inWindow[i]) >= r.policy.Window {
+		i++
+	}
+	r.inWindow = r.inWindow[i:]
+}
+
+func (r *restarts) status() *RestartStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := &RestartStatus{
+		TotalRestarts:     r.total,
+		Recoveries:        r.recoveries,
+		LastExitCode:      r.lastExitCode,
+		LastRecoveryError: r.lastRecoveryError,
+	}
+
+	if r.policy != nil {
+		r.prune(r.now())
+
+		status.Enabled = true
+		status.MaxRestarts = r.policy.MaxRestarts
+		status.Window = r.policy.Window.String()
+		status.RestartsInWindow = len(r.inWindow)
+	}
+
+	if !r.lastStoppedAt.IsZero() {
+		lastStoppedAt := r.lastStoppedAt
+		status.LastStoppedAt = &lastStoppedAt
+	}
+
+	if !r.nextRestartAt.IsZero() {
+		nextRestartAt := r.nextRestartAt
+		status.NextRestartAt = &nextRestartAt
+	}
+
+	return status
+}
@@ -0,0 +1,206 @@
+package operator
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	nodeManager "github.com/streamingfast/firehose-core/node-manager"
+	"go.uber.org/zap"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestarts_OnStop(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	r := newRestarts(&RestartPolicy{
+		MaxRestarts:    4,
+		Window:         10 * time.Minute,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		RecoveryAfter:  3,
+		RecoveryAction: func(*Operator) error { return nil },
+	})
+	r.now = func() time.Time { return now }
+
+	expected := []struct {
+		backoff     time.Duration
+		runRecovery bool
+	}{
+		{time.Second, false},
+		{2 * time.Second, false},
+		{4 * time.Second, true},
+		{5 * time.Second, false},
+	}
+
+	for i, exp := range expected {
+		backoff, runRecovery, err := r.onStop(1)
+		require.NoError(t, err, "restart #%d", i)
+		assert.Equal(t, exp.backoff, backoff, "restart #%d", i)
+		assert.Equal(t, exp.runRecovery, runRecovery, "restart #%d", i)
+
+		now = now.Add(time.Minute)
+	}
+
+	_, _, err := r.onStop(1)
+	require.Error(t, err)
+
+	status := r.status()
+	assert.Equal(t, 4, status.RestartsInWindow)
+	assert.Equal(t, uint64(4), status.TotalRestarts)
+
+	// The first restarts leave the window, making room for new ones with a reset backoff
+	now = now.Add(7 * time.Minute)
+	backoff, runRecovery, err := r.onStop(1)
+	require.NoError(t, err)
+	assert.Equal(t, 4*time.Second, backoff)
+	assert.True(t, runRecovery)
+
+	now = now.Add(10 * time.Minute)
+	backoff, _, err = r.onStop(1)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, backoff)
+}
+
+func TestRestarts_Status(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	r := newRestarts(&RestartPolicy{MaxRestarts: 2, Window: time.Minute, InitialBackoff: time.Second, MaxBackoff: time.Second})
+	r.now = func() time.Time { return now }
+
+	status := r.status()
+	assert.True(t, status.Enabled)
+	assert.Nil(t, status.LastExitCode)
+	assert.Nil(t, status.NextRestartAt)
+
+	_, _, err := r.onStop(137)
+	require.NoError(t, err)
+
+	status = r.status()
+	require.NotNil(t, status.LastExitCode)
+	assert.Equal(t, 137, *status.LastExitCode)
+	require.NotNil(t, status.NextRestartAt)
+	assert.Equal(t, now.Add(time.Second), *status.NextRestartAt)
+
+	r.onRestart()
+	r.onRecovery(errors.New("no backup"))
+
+	status = r.status()
+	assert.Nil(t, status.NextRestartAt)
+	assert.Equal(t, uint64(1), status.Recoveries)
+	assert.Equal(t, "no backup", status.LastRecoveryError)
+}
+
+func TestRestarts_NoPolicy(t *testing.T) {
+	r := newRestarts(nil)
+
+	_, _, err := r.onStop(1)
+	require.Error(t, err)
+
+	status := r.status()
+	assert.False(t, status.Enabled)
+	require.NotNil(t, status.LastExitCode)
+	assert.Equal(t, 1, *status.LastExitCode)
+}
+
+func TestRestartPolicy_Validate(t *testing.T) {
+	recovery := func(*Operator) error { return nil }
+
+	cases := []struct {
+		name        string
+		policy      RestartPolicy
+		expectError bool
+	}{
+		{"valid", RestartPolicy{MaxRestarts: 3, Window: time.Minute, InitialBackoff: time.Second, MaxBackoff: time.Minute}, false},
+		{"valid with recovery", RestartPolicy{MaxRestarts: 3, Window: time.Minute, MaxBackoff: time.Minute, RecoveryAfter: 3, RecoveryAction: recovery}, false},
+		{"no max restarts", RestartPolicy{Window: time.Minute}, true},
+		{"no window", RestartPolicy{MaxRestarts: 3}, true},
+		{"max backoff lower than initial", RestartPolicy{MaxRestarts: 3, Window: time.Minute, InitialBackoff: time.Minute, MaxBackoff: time.Second}, true},
+		{"recovery never reached", RestartPolicy{MaxRestarts: 3, Window: time.Minute, RecoveryAfter: 4, RecoveryAction: recovery}, true},
+		{"recovery without threshold", RestartPolicy{MaxRestarts: 3, Window: time.Minute, RecoveryAction: recovery}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.policy.validate()
+			if c.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// crashingSuperviser is a [testSuperviser] whose node process can be crashed, its stopped channel
+// staying closed until it is started again.
+type crashingSuperviser struct {
+	*testSuperviser
+
+	mu      sync.Mutex
+	stopped chan struct{}
+	starts  int
+}
+
+func (s *crashingSuperviser) Start(...nodeManager.StartOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = make(chan struct{})
+	s.starts++
+	s.running.Store(true)
+	return nil
+}
+
+func (s *crashingSuperviser) Stopped() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stopped
+}
+
+func (s *crashingSuperviser) LastExitCode() int { return 1 }
+
+func (s *crashingSuperviser) crash() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running.Store(false)
+	close(s.stopped)
+}
+
+func (s *crashingSuperviser) startCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.starts
+}
+
+func TestOperator_RestartAfterCommandDuringBackoff(t *testing.T) {
+	superviser := &crashingSuperviser{testSuperviser: newTestSuperviser()}
+	o, err := New(zap.NewNop(), superviser, testReadiness{}, &Options{RestartPolicy: &RestartPolicy{
+		MaxRestarts:    3,
+		Window:         time.Minute,
+		InitialBackoff: 300 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+	}})
+	require.NoError(t, err)
+	require.NoError(t, o.RegisterBackupModule("snapshot", superviser.testSuperviser))
+
+	go o.Launch("127.0.0.1:0")
+	require.Eventually(t, func() bool { return superviser.startCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	superviser.crash()
+	require.Eventually(t, func() bool { return o.Status().State == StateRestartPending }, 5*time.Second, 10*time.Millisecond)
+
+	// A scheduled backup during the backoff must not cancel the restart
+	backup := &Command{cmd: "backup", logger: o.zlogger, returnch: make(chan error, 1)}
+	o.commandChan <- backup
+	require.NoError(t, <-backup.returnch)
+
+	require.Eventually(t, func() bool { return superviser.startCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, superviser.IsRunning())
+}
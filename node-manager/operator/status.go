@@ -0,0 +1,237 @@
+package operator
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	nodeManager "github.com/streamingfast/firehose-core/node-manager"
+)
+
+// Operator states reported in [Status.State].
+const (
+	StateStopped        = "stopped"
+	StateRunning        = "running"
+	StateRestartPending = "restart_pending"
+	StateTerminating    = "terminating"
+)
+
+// Status is the state of the operator and of the node process it supervises, served by the
+// `/v1/status` endpoint. Every field is always present, `null` when unknown, fields may be added
+// but are never removed or renamed.
+type Status struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Ready bool   `json:"ready"`
+
+	LaunchedAt           time.Time  `json:"launched_at"`
+	UptimeSeconds        float64    `json:"uptime_seconds"`
+	ProcessStartedAt     *time.Time `json:"process_started_at"`
+	ProcessUptimeSeconds float64    `json:"process_uptime_seconds"`
+	LastExitCode         int        `json:"last_exit_code"`
+
+	LastSeenBlockNum  uint64     `json:"last_seen_block_num"`
+	LastSeenBlockTime *time.Time `json:"last_seen_block_time"`
+
+	CurrentCommand *CommandStatus  `json:"current_command"`
+	Restarts       *RestartStatus  `json:"restarts"`
+	Backups        []*BackupStatus `json:"backups"`
+}
+
+// CommandStatus is an operator command being executed.
+type CommandStatus struct {
+	Name      string         `json:"name"`
+	Params    map[string]any `json:"params"`
+	StartedAt time.Time      `json:"started_at"`
+}
+
+// BackupStatus is the state of a registered backup module.
+type BackupStatus struct {
+	Name       string `json:"name"`
+	Restorable bool   `json:"restorable"`
+
+	// Schedules of the module, see [BackupSchedule]
+	Schedules []*BackupScheduleStatus `json:"schedules"`
+
+	LastBackupAt       *time.Time `json:"last_backup_at"`
+	LastBackupName     string     `json:"last_backup_name"`
+	LastBackupBlockNum uint64     `json:"last_backup_block_num"`
+}
+
+type BackupScheduleStatus struct {
+	BlocksBetweenRuns     uint64 `json:"blocks_between_runs"`
+	TimeBetweenRuns       string `json:"time_between_runs"`
+	RequiredHostnameMatch string `json:"required_hostname_match"`
+}
+
+type lastBackup struct {
+	at       time.Time
+	name     string
+	blockNum uint64
+}
+
+// operatorState holds the state reported in [Status] that only the operator knows about,
+// notifying the status stream on every change.
+type operatorState struct {
+	mu               sync.Mutex
+	launchedAt       time.Time
+	processStartedAt time.Time
+	currentCommand   *CommandStatus
+	lastBackups      map[string]*lastBackup
+
+	changesMu sync.Mutex
+	changes   chan struct{}
+}
+
+func newOperatorState() *operatorState {
+	return &operatorState{
+		launchedAt:  time.Now(),
+		lastBackups: make(map[string]*lastBackup),
+		changes:     make(chan struct{}),
+	}
+}
+
+// changed returns a channel closed on the next state change.
+func (s *operatorState) changed() <-chan struct{} {
+	s.changesMu.Lock()
+	defer s.changesMu.Unlock()
+
+	return s.changes
+}
+
+func (s *operatorState) notify() {
+	s.changesMu.Lock()
+	defer s.changesMu.Unlock()
+
+	close(s.changes)
+	s.changes = make(chan struct{})
+}
+
+func (s *operatorState) launched() {
+	s.mu.Lock()
+	s.launchedAt = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *operatorState) commandStarted(cmd *Command) {
+	s.mu.Lock()
+	// Published to every caller of the status endpoints, secrets are redacted like in the audit log
+	s.currentCommand = &CommandStatus{Name: cmd.cmd, Params: auditParams(cmd.params), StartedAt: time.Now()}
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+func (s *operatorState) commandEnded() {
+	s.mu.Lock()
+	s.currentCommand = nil
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+func (s *operatorState) processStarted() {
+	s.mu.Lock()
+	s.processStartedAt = time.Now()
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+func (s *operatorState) backupCompleted(moduleName, backupName string, blockNum uint64) {
+	s.mu.Lock()
+	s.lastBackups[moduleName] = &lastBackup{at: time.Now(), name: backupName, blockNum: blockNum}
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+// Status returns the current status of the operator.
+func (o *Operator) Status() *Status {
+	now := time.Now()
+	running := o.Superviser.IsRunning()
+
+	status := &Status{
+		Name:             o.Superviser.GetName(),
+		Ready:            running && o.chainReadiness.IsReady(),
+		LastExitCode:     o.Superviser.LastExitCode(),
+		LastSeenBlockNum: o.Superviser.LastSeenBlockNum(),
+		Restarts:         o.restarts.status(),
+		Backups:          []*BackupStatus{},
+	}
+
+	switch {
+	case o.IsTerminating():
+		status.State = StateTerminating
+	case running:
+		status.State = StateRunning
+	case status.Restarts.NextRestartAt != nil:
+		status.State = StateRestartPending
+	default:
+		status.State = StateStopped
+	}
+
+	if timer, ok := o.Superviser.(nodeManager.BlockTimeChainSuperviser); ok {
+		if blockTime := timer.LastSeenBlockTime(); !blockTime.IsZero() {
+			status.LastSeenBlockTime = &blockTime
+		}
+	}
+
+	o.state.mu.Lock()
+	defer o.state.mu.Unlock()
+
+	status.LaunchedAt = o.state.launchedAt
+	status.UptimeSeconds = now.Sub(o.state.launchedAt).Seconds()
+
+	if running && !o.state.processStartedAt.IsZero() {
+		processStartedAt := o.state.processStartedAt
+		status.ProcessStartedAt = &processStartedAt
+		status.ProcessUptimeSeconds = now.Sub(processStartedAt).Seconds()
+	}
+
+	if cmd := o.state.currentCommand; cmd != nil {
+		status.CurrentCommand = &CommandStatus{Name: cmd.Name, Params: cmd.Params, StartedAt: cmd.StartedAt}
+	}
+
+	for name, mod := range o.backupModules {
+		backup := &BackupStatus{Name: name, Schedules: []*BackupScheduleStatus{}}
+		_, backup.Restorable = mod.(RestorableBackupModule)
+
+		for _, sched := range o.backupSchedules {
+			if sched.BackuperName == name {
+				backup.Schedules = append(backup.Schedules, &BackupScheduleStatus{
+					BlocksBetweenRuns:     sched.BlocksBetweenRuns,
+					TimeBetweenRuns:       sched.TimeBetweenRuns.String(),
+					RequiredHostnameMatch: sched.RequiredHostnameMatch,
+				})
+			}
+		}
+
+		if last, found := o.state.lastBackups[name]; found {
+			lastBackupAt := last.at
+			backup.LastBackupAt = &lastBackupAt
+			backup.LastBackupName = last.name
+			backup.LastBackupBlockNum = last.blockNum
+		}
+
+		status.Backups = append(status.Backups, backup)
+	}
+
+	sort.Slice(status.Backups, func(i, j int) bool {
+		return status.Backups[i].Name < status.Backups[j].Name
+	})
+
+	return status
+}
+
+// transitionKey is the part of [Status] the status stream watches for changes, leaving out the
+// values moving continuously (uptimes and last seen block).
+func (s *Status) transitionKey() Status {
+	key := *s
+	key.UptimeSeconds = 0
+	key.ProcessUptimeSeconds = 0
+	key.LastSeenBlockNum = 0
+	key.LastSeenBlockTime = nil
+
+	return key
+}
@@ -0,0 +1,195 @@
+package operator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	nodeManager "github.com/streamingfast/firehose-core/node-manager"
+	logplugin "github.com/streamingfast/firehose-core/node-manager/log_plugin"
+	"github.com/streamingfast/shutter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+type testSuperviser struct {
+	*shutter.Shutter
+
+	running           *atomic.Bool
+	lastSeenBlockNum  uint64
+	lastSeenBlockTime time.Time
+}
+
+func newTestSuperviser() *testSuperviser {
+	return &testSuperviser{Shutter: shutter.New(), running: atomic.NewBool(false)}
+}
+
+func (s *testSuperviser) GetCommand() string                     { return "node run" }
+func (s *testSuperviser) GetName() string                        { return "node" }
+func (s *testSuperviser) ServerID() (string, error)              { return "", nil }
+func (s *testSuperviser) RegisterLogPlugin(logplugin.LogPlugin)  {}
+func (s *testSuperviser) Start(...nodeManager.StartOption) error { s.running.Store(true); return nil }
+func (s *testSuperviser) Stop() error                            { s.running.Store(false); return nil }
+func (s *testSuperviser) IsRunning() bool                        { return s.running.Load() }
+func (s *testSuperviser) Stopped() <-chan struct{}               { return nil }
+func (s *testSuperviser) LastExitCode() int                      { return 0 }
+func (s *testSuperviser) LastLogLines() []string                 { return nil }
+func (s *testSuperviser) LastSeenBlockNum() uint64               { return s.lastSeenBlockNum }
+func (s *testSuperviser) LastSeenBlockTime() time.Time           { return s.lastSeenBlockTime }
+func (s *testSuperviser) Restore(string) error                   { return nil }
+func (s *testSuperviser) Backup(uint64) (string, error)          { return "backup-1", nil }
+func (s *testSuperviser) RequiresStop() bool                     { return false }
+
+type testReadiness struct{}
+
+func (testReadiness) IsReady() bool { return true }
+
+func newTestOperator(t *testing.T) (*Operator, *testSuperviser) {
+	t.Helper()
+
+	superviser := newTestSuperviser()
+	o, err := New(zap.NewNop(), superviser, testReadiness{}, &Options{})
+	require.NoError(t, err)
+
+	return o, superviser
+}
+
+func TestOperator_Status(t *testing.T) {
+	o, superviser := newTestOperator(t)
+	require.NoError(t, o.RegisterBackupModule("snapshot", superviser))
+	o.RegisterBackupSchedule(&BackupSchedule{BlocksBetweenRuns: 1000, BackuperName: "snapshot"})
+
+	status := o.Status()
+	assert.Equal(t, StateStopped, status.State)
+	assert.False(t, status.Ready)
+	assert.Nil(t, status.ProcessStartedAt)
+	assert.Nil(t, status.LastSeenBlockTime)
+	assert.Nil(t, status.CurrentCommand)
+	require.Len(t, status.Backups, 1)
+	assert.Equal(t, "snapshot", status.Backups[0].Name)
+	assert.True(t, status.Backups[0].Restorable)
+	require.Len(t, status.Backups[0].Schedules, 1)
+	assert.Equal(t, uint64(1000), status.Backups[0].Schedules[0].BlocksBetweenRuns)
+	assert.Nil(t, status.Backups[0].LastBackupAt)
+
+	require.NoError(t, o.runCommand(&Command{cmd: "start", logger: o.zlogger}))
+	superviser.lastSeenBlockNum = 42
+	superviser.lastSeenBlockTime = time.Unix(1700000000, 0)
+
+	require.NoError(t, o.runCommand(&Command{cmd: "backup", logger: o.zlogger}))
+
+	cmd := &Command{cmd: "maintenance", params: map[string]any{"sync": "true", "extra-env": map[string]string{"SECRET": "value"}}}
+	o.state.commandStarted(cmd)
+
+	status = o.Status()
+	assert.Equal(t, StateRunning, status.State)
+	assert.True(t, status.Ready)
+	assert.NotNil(t, status.ProcessStartedAt)
+	assert.Equal(t, uint64(42), status.LastSeenBlockNum)
+	require.NotNil(t, status.LastSeenBlockTime)
+	assert.True(t, superviser.lastSeenBlockTime.Equal(*status.LastSeenBlockTime))
+	require.NotNil(t, status.CurrentCommand)
+	assert.Equal(t, "maintenance", status.CurrentCommand.Name)
+	assert.Equal(t, "true", status.CurrentCommand.Params["sync"])
+	assert.Equal(t, map[string]string{"SECRET": "<redacted>"}, status.CurrentCommand.Params["extra-env"])
+	require.NotNil(t, status.Backups[0].LastBackupAt)
+	assert.Equal(t, "backup-1", status.Backups[0].LastBackupName)
+	assert.Equal(t, uint64(42), status.Backups[0].LastBackupBlockNum)
+
+	// The schema is stable, every field is present even when unknown
+	o.state.commandEnded()
+	data, err := json.Marshal(o.Status())
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	for _, field := range []string{"name", "state", "ready", "launched_at", "uptime_seconds", "process_started_at", "process_uptime_seconds", "last_exit_code", "last_seen_block_num", "last_seen_block_time", "current_command", "restarts", "backups"} {
+		assert.Contains(t, fields, field)
+	}
+	assert.Nil(t, fields["current_command"])
+
+	restarts, ok := fields["restarts"].(map[string]any)
+	require.True(t, ok)
+	for _, field := range []string{"enabled", "max_restarts", "window", "restarts_in_window", "total_restarts", "recoveries", "last_exit_code", "last_stopped_at", "next_restart_at", "last_recovery_error"} {
+		assert.Contains(t, restarts, field)
+	}
+	assert.Nil(t, restarts["next_restart_at"])
+}
+
+func TestOperator_StatusStream(t *testing.T) {
+	o, _ := newTestOperator(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/status/stream", nil).WithContext(ctx)
+	w := newStreamRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o.statusStreamHandler(w, req)
+	}()
+
+	events := w.events()
+
+	first := <-events
+	assert.Equal(t, StateStopped, first.State)
+	assert.Nil(t, first.CurrentCommand)
+
+	o.state.commandStarted(&Command{cmd: "backup"})
+
+	select {
+	case next := <-events:
+		require.NotNil(t, next.CurrentCommand)
+		assert.Equal(t, "backup", next.CurrentCommand.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no status event after state change")
+	}
+
+	cancel()
+	<-done
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+}
+
+// streamRecorder is an [http.ResponseWriter] handing the written Server-Sent Events over a pipe.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	writes chan string
+}
+
+func newStreamRecorder() *streamRecorder {
+	return &streamRecorder{ResponseRecorder: httptest.NewRecorder(), writes: make(chan string, 16)}
+}
+
+func (r *streamRecorder) Write(p []byte) (int, error) {
+	r.writes <- string(p)
+	return len(p), nil
+}
+
+func (r *streamRecorder) Flush() {}
+
+func (r *streamRecorder) events() <-chan *Status {
+	out := make(chan *Status, 16)
+	go func() {
+		for write := range r.writes {
+			scanner := bufio.NewScanner(strings.NewReader(write))
+			for scanner.Scan() {
+				if data, found := strings.CutPrefix(scanner.Text(), "data: "); found {
+					status := &Status{}
+					if err := json.Unmarshal([]byte(data), status); err == nil {
+						out <- status
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -17,6 +17,7 @@ package node_manager
 import (
 	"time"
 
+	"github.com/streamingfast/bstream"
 	logplugin "github.com/streamingfast/firehose-core/node-manager/log_plugin"
 )
 
@@ -76,6 +77,19 @@ type ChainSuperviser interface {
 	LastSeenBlockNum() uint64
 }
 
+// BlockTimeChainSuperviser is implemented by the supervisers knowing the time of the last block
+// seen, along its number from [ChainSuperviser.LastSeenBlockNum].
+type BlockTimeChainSuperviser interface {
+	LastSeenBlockTime() time.Time
+}
+
+// BlockIDChainSuperviser is implemented by the supervisers knowing the id (hash) of the last block
+// seen. [BlockIDChainSuperviser.LastSeenBlock] returns its number and id read together, it's nil
+// until the first block is seen.
+type BlockIDChainSuperviser interface {
+	LastSeenBlock() bstream.BlockRef
+}
+
 type MonitorableChainSuperviser interface {
 	Monitor()
 }
@@ -35,6 +35,7 @@ type mindreaderPlugin interface {
 	logplugin.LogPlugin
 
 	LastSeenBlock() bstream.BlockRef
+	LastSeenBlockTime() time.Time
 }
 
 type Superviser struct {
@@ -145,12 +146,33 @@ func (s *Superviser) LastLogLines() []string {
 func (s *Superviser) LastSeenBlockNum() uint64 {
 	for _, plugin := range s.GetLogPlugins() {
 		if v, ok := plugin.(mindreaderPlugin); ok {
-			return v.LastSeenBlock().Num()
+			// The last seen block is unset until the first block is read
+			if ref := v.LastSeenBlock(); ref != nil {
+				return ref.Num()
+			}
 		}
 	}
 	return 0
 }
 
+func (s *Superviser) LastSeenBlock() bstream.BlockRef {
+	for _, plugin := range s.GetLogPlugins() {
+		if v, ok := plugin.(mindreaderPlugin); ok {
+			return v.LastSeenBlock()
+		}
+	}
+	return nil
+}
+
+func (s *Superviser) LastSeenBlockTime() time.Time {
+	for _, plugin := range s.GetLogPlugins() {
+		if v, ok := plugin.(mindreaderPlugin); ok {
+			return v.LastSeenBlockTime()
+		}
+	}
+	return time.Time{}
+}
+
 func (s *Superviser) Start(options ...nodeManager.StartOption) error {
 	var startOptions nodeManager.StartOptions
 	for _, opt := range options {
@@ -0,0 +1,811 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: sf/firehose/batchfetch/v1/batch_fetch.proto
+
+package pbbatchfetch
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Request references the block(s) to fetch, the field numbers of the single block references are
+// the same as the ones of `sf.firehose.v2.SingleBlockRequest`.
+type Request struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Reference:
+	//	*Request_BlockNumber_
+	//	*Request_BlockHashAndNumber_
+	//	*Request_Cursor_
+	//	*Request_BlockRange_
+	//	*Request_BlockHash_
+	Reference isRequest_Reference `protobuf_oneof:"reference"`
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *Request) GetReference() isRequest_Reference {
+	if m != nil {
+		return m.Reference
+	}
+	return nil
+}
+
+func (x *Request) GetBlockNumber() *Request_BlockNumber {
+	if x, ok := x.GetReference().(*Request_BlockNumber_); ok {
+		return x.BlockNumber
+	}
+	return nil
+}
+
+func (x *Request) GetBlockHashAndNumber() *Request_BlockHashAndNumber {
+	if x, ok := x.GetReference().(*Request_BlockHashAndNumber_); ok {
+		return x.BlockHashAndNumber
+	}
+	return nil
+}
+
+func (x *Request) GetCursor() *Request_Cursor {
+	if x, ok := x.GetReference().(*Request_Cursor_); ok {
+		return x.Cursor
+	}
+	return nil
+}
+
+func (x *Request) GetBlockRange() *Request_BlockRange {
+	if x, ok := x.GetReference().(*Request_BlockRange_); ok {
+		return x.BlockRange
+	}
+	return nil
+}
+
+func (x *Request) GetBlockHash() *Request_BlockHash {
+	if x, ok := x.GetReference().(*Request_BlockHash_); ok {
+		return x.BlockHash
+	}
+	return nil
+}
+
+type isRequest_Reference interface {
+	isRequest_Reference()
+}
+
+type Request_BlockNumber_ struct {
+	BlockNumber *Request_BlockNumber `protobuf:"bytes,3,opt,name=block_number,json=blockNumber,proto3,oneof"`
+}
+
+type Request_BlockHashAndNumber_ struct {
+	BlockHashAndNumber *Request_BlockHashAndNumber `protobuf:"bytes,4,opt,name=block_hash_and_number,json=blockHashAndNumber,proto3,oneof"`
+}
+
+type Request_Cursor_ struct {
+	Cursor *Request_Cursor `protobuf:"bytes,5,opt,name=cursor,proto3,oneof"`
+}
+
+type Request_BlockRange_ struct {
+	BlockRange *Request_BlockRange `protobuf:"bytes,7,opt,name=block_range,json=blockRange,proto3,oneof"`
+}
+
+type Request_BlockHash_ struct {
+	BlockHash *Request_BlockHash `protobuf:"bytes,8,opt,name=block_hash,json=blockHash,proto3,oneof"`
+}
+
+func (*Request_BlockNumber_) isRequest_Reference() {}
+
+func (*Request_BlockHashAndNumber_) isRequest_Reference() {}
+
+func (*Request_Cursor_) isRequest_Reference() {}
+
+func (*Request_BlockRange_) isRequest_Reference() {}
+
+func (*Request_BlockHash_) isRequest_Reference() {}
+
+// Response holds a single block, it is wire compatible with `sf.firehose.v2.SingleBlockResponse`.
+type Response struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Block is the chain specific block, unset when the block was not found
+	Block    *anypb.Any     `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Metadata *BlockMetadata `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Response) GetBlock() *anypb.Any {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *Response) GetMetadata() *BlockMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// BlockMetadata is wire compatible with `sf.firehose.v2.BlockMetadata`.
+type BlockMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Num       uint64                 `protobuf:"varint,1,opt,name=num,proto3" json:"num,omitempty"`
+	Id        string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	ParentNum uint64                 `protobuf:"varint,3,opt,name=parent_num,json=parentNum,proto3" json:"parent_num,omitempty"`
+	ParentId  string                 `protobuf:"bytes,4,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	LibNum    uint64                 `protobuf:"varint,5,opt,name=lib_num,json=libNum,proto3" json:"lib_num,omitempty"`
+	Time      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=time,proto3" json:"time,omitempty"`
+}
+
+func (x *BlockMetadata) Reset() {
+	*x = BlockMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockMetadata) ProtoMessage() {}
+
+func (x *BlockMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockMetadata.ProtoReflect.Descriptor instead.
+func (*BlockMetadata) Descriptor() ([]byte, []int) {
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BlockMetadata) GetNum() uint64 {
+	if x != nil {
+		return x.Num
+	}
+	return 0
+}
+
+func (x *BlockMetadata) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BlockMetadata) GetParentNum() uint64 {
+	if x != nil {
+		return x.ParentNum
+	}
+	return 0
+}
+
+func (x *BlockMetadata) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *BlockMetadata) GetLibNum() uint64 {
+	if x != nil {
+		return x.LibNum
+	}
+	return 0
+}
+
+func (x *BlockMetadata) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+// Get the current known canonical version of a block at with this number
+type Request_BlockNumber struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Num uint64 `protobuf:"varint,1,opt,name=num,proto3" json:"num,omitempty"`
+}
+
+func (x *Request_BlockNumber) Reset() {
+	*x = Request_BlockNumber{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request_BlockNumber) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request_BlockNumber) ProtoMessage() {}
+
+func (x *Request_BlockNumber) ProtoReflect() protoreflect.Message {
+	mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request_BlockNumber.ProtoReflect.Descriptor instead.
+func (*Request_BlockNumber) Descriptor() ([]byte, []int) {
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *Request_BlockNumber) GetNum() uint64 {
+	if x != nil {
+		return x.Num
+	}
+	return 0
+}
+
+// Get the current block with specific hash and number
+type Request_BlockHashAndNumber struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Num  uint64 `protobuf:"varint,1,opt,name=num,proto3" json:"num,omitempty"`
+	Hash string `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (x *Request_BlockHashAndNumber) Reset() {
+	*x = Request_BlockHashAndNumber{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request_BlockHashAndNumber) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request_BlockHashAndNumber) ProtoMessage() {}
+
+func (x *Request_BlockHashAndNumber) ProtoReflect() protoreflect.Message {
+	mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request_BlockHashAndNumber.ProtoReflect.Descriptor instead.
+func (*Request_BlockHashAndNumber) Descriptor() ([]byte, []int) {
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP(), []int{0, 1}
+}
+
+func (x *Request_BlockHashAndNumber) GetNum() uint64 {
+	if x != nil {
+		return x.Num
+	}
+	return 0
+}
+
+func (x *Request_BlockHashAndNumber) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+// Get the block with a specific hash, whatever its number, canonical or forked
+type Request_BlockHash struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash string `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (x *Request_BlockHash) Reset() {
+	*x = Request_BlockHash{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request_BlockHash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request_BlockHash) ProtoMessage() {}
+
+func (x *Request_BlockHash) ProtoReflect() protoreflect.Message {
+	mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request_BlockHash.ProtoReflect.Descriptor instead.
+func (*Request_BlockHash) Descriptor() ([]byte, []int) {
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP(), []int{0, 2}
+}
+
+func (x *Request_BlockHash) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+// Get the block that generated a specific cursor
+type Request_Cursor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cursor string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+}
+
+func (x *Request_Cursor) Reset() {
+	*x = Request_Cursor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request_Cursor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request_Cursor) ProtoMessage() {}
+
+func (x *Request_Cursor) ProtoReflect() protoreflect.Message {
+	mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request_Cursor.ProtoReflect.Descriptor instead.
+func (*Request_Cursor) Descriptor() ([]byte, []int) {
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP(), []int{0, 3}
+}
+
+func (x *Request_Cursor) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+// Get the current known canonical version of every block from `start_block_num` to
+// `stop_block_num`, both inclusive
+type Request_BlockRange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	StartBlockNum uint64 `protobuf:"varint,1,opt,name=start_block_num,json=startBlockNum,proto3" json:"start_block_num,omitempty"`
+	StopBlockNum  uint64 `protobuf:"varint,2,opt,name=stop_block_num,json=stopBlockNum,proto3" json:"stop_block_num,omitempty"`
+}
+
+func (x *Request_BlockRange) Reset() {
+	*x = Request_BlockRange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request_BlockRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request_BlockRange) ProtoMessage() {}
+
+func (x *Request_BlockRange) ProtoReflect() protoreflect.Message {
+	mi := &file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request_BlockRange.ProtoReflect.Descriptor instead.
+func (*Request_BlockRange) Descriptor() ([]byte, []int) {
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP(), []int{0, 4}
+}
+
+func (x *Request_BlockRange) GetStartBlockNum() uint64 {
+	if x != nil {
+		return x.StartBlockNum
+	}
+	return 0
+}
+
+func (x *Request_BlockRange) GetStopBlockNum() uint64 {
+	if x != nil {
+		return x.StopBlockNum
+	}
+	return 0
+}
+
+var File_sf_firehose_batchfetch_v1_batch_fetch_proto protoreflect.FileDescriptor
+
+var file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDesc = []byte{
+	0x0a, 0x2b, 0x73, 0x66, 0x2f, 0x66, 0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2f, 0x62, 0x61,
+	0x74, 0x63, 0x68, 0x66, 0x65, 0x74, 0x63, 0x68, 0x2f, 0x76, 0x31, 0x2f, 0x62, 0x61, 0x74, 0x63,
+	0x68, 0x5f, 0x66, 0x65, 0x74, 0x63, 0x68, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x19, 0x73,
+	0x66, 0x2e, 0x66, 0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2e, 0x62, 0x61, 0x74, 0x63, 0x68,
+	0x66, 0x65, 0x74, 0x63, 0x68, 0x2e, 0x76, 0x31, 0x1a, 0x19, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x61, 0x6e, 0x79, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0xbf, 0x05, 0x0a, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x53, 0x0a, 0x0c, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x73, 0x66, 0x2e, 0x66, 0x69, 0x72, 0x65,
+	0x68, 0x6f, 0x73, 0x65, 0x2e, 0x62, 0x61, 0x74, 0x63, 0x68, 0x66, 0x65, 0x74, 0x63, 0x68, 0x2e,
+	0x76, 0x31, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x48, 0x00, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x4e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x6a, 0x0a, 0x15, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68,
+	0x61, 0x73, 0x68, 0x5f, 0x61, 0x6e, 0x64, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x35, 0x2e, 0x73, 0x66, 0x2e, 0x66, 0x69, 0x72, 0x65, 0x68, 0x6f,
+	0x73, 0x65, 0x2e, 0x62, 0x61, 0x74, 0x63, 0x68, 0x66, 0x65, 0x74, 0x63, 0x68, 0x2e, 0x76, 0x31,
+	0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61,
+	0x73, 0x68, 0x41, 0x6e, 0x64, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x48, 0x00, 0x52, 0x12, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x41, 0x6e, 0x64, 0x4e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x12, 0x43, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x29, 0x2e, 0x73, 0x66, 0x2e, 0x66, 0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2e,
+	0x62, 0x61, 0x74, 0x63, 0x68, 0x66, 0x65, 0x74, 0x63, 0x68, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x06,
+	0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12, 0x50, 0x0a, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f,
+	0x72, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2d, 0x2e, 0x73, 0x66,
+	0x2e, 0x66, 0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2e, 0x62, 0x61, 0x74, 0x63, 0x68, 0x66,
+	0x65, 0x74, 0x63, 0x68, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x4d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x73,
+	0x66, 0x2e, 0x66, 0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2e, 0x62, 0x61, 0x74, 0x63, 0x68,
+	0x66, 0x65, 0x74, 0x63, 0x68, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x48, 0x00, 0x52, 0x09, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x1a, 0x1f, 0x0a, 0x0b, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x75, 0x6d, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x03, 0x6e, 0x75, 0x6d, 0x1a, 0x3a, 0x0a, 0x12, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x48, 0x61, 0x73, 0x68, 0x41, 0x6e, 0x64, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x10,
+	0x0a, 0x03, 0x6e, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x6e, 0x75, 0x6d,
+	0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x68, 0x61, 0x73, 0x68, 0x1a, 0x1f, 0x0a, 0x09, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73,
+	0x68, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x68, 0x61, 0x73, 0x68, 0x1a, 0x20, 0x0a, 0x06, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12,
+	0x16, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x1a, 0x5a, 0x0a, 0x0a, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x26, 0x0a, 0x0f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d,
+	0x73, 0x74, 0x61, 0x72, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x4e, 0x75, 0x6d, 0x12, 0x24, 0x0a,
+	0x0e, 0x73, 0x74, 0x6f, 0x70, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6e, 0x75, 0x6d, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x73, 0x74, 0x6f, 0x70, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x4e, 0x75, 0x6d, 0x42, 0x0b, 0x0a, 0x09, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65,
+	0x4a, 0x04, 0x08, 0x06, 0x10, 0x07, 0x22, 0x7c, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x2a, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e, 0x79, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x44,
+	0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x28, 0x2e, 0x73, 0x66, 0x2e, 0x66, 0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2e, 0x62,
+	0x61, 0x74, 0x63, 0x68, 0x66, 0x65, 0x74, 0x63, 0x68, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x22, 0xb6, 0x01, 0x0a, 0x0d, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x4d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x75, 0x6d, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x03, 0x6e, 0x75, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x65,
+	0x6e, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x70, 0x61,
+	0x72, 0x65, 0x6e, 0x74, 0x4e, 0x75, 0x6d, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x72, 0x65, 0x6e,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x72, 0x65,
+	0x6e, 0x74, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x6c, 0x69, 0x62, 0x5f, 0x6e, 0x75, 0x6d, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6c, 0x69, 0x62, 0x4e, 0x75, 0x6d, 0x12, 0x2e, 0x0a,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x32, 0x63, 0x0a,
+	0x0a, 0x42, 0x61, 0x74, 0x63, 0x68, 0x46, 0x65, 0x74, 0x63, 0x68, 0x12, 0x55, 0x0a, 0x06, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x22, 0x2e, 0x73, 0x66, 0x2e, 0x66, 0x69, 0x72, 0x65, 0x68,
+	0x6f, 0x73, 0x65, 0x2e, 0x62, 0x61, 0x74, 0x63, 0x68, 0x66, 0x65, 0x74, 0x63, 0x68, 0x2e, 0x76,
+	0x31, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x73, 0x66, 0x2e, 0x66,
+	0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2e, 0x62, 0x61, 0x74, 0x63, 0x68, 0x66, 0x65, 0x74,
+	0x63, 0x68, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01,
+	0x30, 0x01, 0x42, 0x52, 0x5a, 0x50, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x69, 0x6e, 0x67, 0x66, 0x61, 0x73, 0x74, 0x2f, 0x66,
+	0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2d, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x70, 0x62, 0x2f,
+	0x73, 0x66, 0x2f, 0x66, 0x69, 0x72, 0x65, 0x68, 0x6f, 0x73, 0x65, 0x2f, 0x62, 0x61, 0x74, 0x63,
+	0x68, 0x66, 0x65, 0x74, 0x63, 0x68, 0x2f, 0x76, 0x31, 0x3b, 0x70, 0x62, 0x62, 0x61, 0x74, 0x63,
+	0x68, 0x66, 0x65, 0x74, 0x63, 0x68, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescOnce sync.Once
+	file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescData = file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDesc
+)
+
+func file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescGZIP() []byte {
+	file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescOnce.Do(func() {
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescData = protoimpl.X.CompressGZIP(file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescData)
+	})
+	return file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDescData
+}
+
+var file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_sf_firehose_batchfetch_v1_batch_fetch_proto_goTypes = []any{
+	(*Request)(nil),                    // 0: sf.firehose.batchfetch.v1.Request
+	(*Response)(nil),                   // 1: sf.firehose.batchfetch.v1.Response
+	(*BlockMetadata)(nil),              // 2: sf.firehose.batchfetch.v1.BlockMetadata
+	(*Request_BlockNumber)(nil),        // 3: sf.firehose.batchfetch.v1.Request.BlockNumber
+	(*Request_BlockHashAndNumber)(nil), // 4: sf.firehose.batchfetch.v1.Request.BlockHashAndNumber
+	(*Request_BlockHash)(nil),          // 5: sf.firehose.batchfetch.v1.Request.BlockHash
+	(*Request_Cursor)(nil),             // 6: sf.firehose.batchfetch.v1.Request.Cursor
+	(*Request_BlockRange)(nil),         // 7: sf.firehose.batchfetch.v1.Request.BlockRange
+	(*anypb.Any)(nil),                  // 8: google.protobuf.Any
+	(*timestamppb.Timestamp)(nil),      // 9: google.protobuf.Timestamp
+}
+var file_sf_firehose_batchfetch_v1_batch_fetch_proto_depIdxs = []int32{
+	3, // 0: sf.firehose.batchfetch.v1.Request.block_number:type_name -> sf.firehose.batchfetch.v1.Request.BlockNumber
+	4, // 1: sf.firehose.batchfetch.v1.Request.block_hash_and_number:type_name -> sf.firehose.batchfetch.v1.Request.BlockHashAndNumber
+	6, // 2: sf.firehose.batchfetch.v1.Request.cursor:type_name -> sf.firehose.batchfetch.v1.Request.Cursor
+	7, // 3: sf.firehose.batchfetch.v1.Request.block_range:type_name -> sf.firehose.batchfetch.v1.Request.BlockRange
+	5, // 4: sf.firehose.batchfetch.v1.Request.block_hash:type_name -> sf.firehose.batchfetch.v1.Request.BlockHash
+	8, // 5: sf.firehose.batchfetch.v1.Response.block:type_name -> google.protobuf.Any
+	2, // 6: sf.firehose.batchfetch.v1.Response.metadata:type_name -> sf.firehose.batchfetch.v1.BlockMetadata
+	9, // 7: sf.firehose.batchfetch.v1.BlockMetadata.time:type_name -> google.protobuf.Timestamp
+	0, // 8: sf.firehose.batchfetch.v1.BatchFetch.Blocks:input_type -> sf.firehose.batchfetch.v1.Request
+	1, // 9: sf.firehose.batchfetch.v1.BatchFetch.Blocks:output_type -> sf.firehose.batchfetch.v1.Response
+	9, // [9:10] is the sub-list for method output_type
+	8, // [8:9] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_sf_firehose_batchfetch_v1_batch_fetch_proto_init() }
+func file_sf_firehose_batchfetch_v1_batch_fetch_proto_init() {
+	if File_sf_firehose_batchfetch_v1_batch_fetch_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Request); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Response); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*BlockMetadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Request_BlockNumber); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*Request_BlockHashAndNumber); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*Request_BlockHash); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*Request_Cursor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Request_BlockRange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes[0].OneofWrappers = []any{
+		(*Request_BlockNumber_)(nil),
+		(*Request_BlockHashAndNumber_)(nil),
+		(*Request_Cursor_)(nil),
+		(*Request_BlockRange_)(nil),
+		(*Request_BlockHash_)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sf_firehose_batchfetch_v1_batch_fetch_proto_goTypes,
+		DependencyIndexes: file_sf_firehose_batchfetch_v1_batch_fetch_proto_depIdxs,
+		MessageInfos:      file_sf_firehose_batchfetch_v1_batch_fetch_proto_msgTypes,
+	}.Build()
+	File_sf_firehose_batchfetch_v1_batch_fetch_proto = out.File
+	file_sf_firehose_batchfetch_v1_batch_fetch_proto_rawDesc = nil
+	file_sf_firehose_batchfetch_v1_batch_fetch_proto_goTypes = nil
+	file_sf_firehose_batchfetch_v1_batch_fetch_proto_depIdxs = nil
+}
@@ -57,6 +57,15 @@ func DefaultReaderNodeBootstrapDataURLFlagDescription() string {
 		'reader-node-data-dir' location. The archive is expected to contain the full content of the 'reader-node-data-dir'
 		and is expanded as is.
 
+		If the bootstrap URL is of the form 'catalog+<catalog url>?<parameters>', the backup catalog at '<catalog url>'
+		(the 'reader-node-backup-catalog-url' of the node taking the backups) is read and the backup of the highest
+		block with a 'tar.zst' URL is extracted into the 'reader-node-data-dir' location like an archive URL. The query
+		parameters accepted are:
+
+			- module=<name> | Only select the backups of this backup module
+			- at_or_before_block=<num> | Only select the backups at or before this block
+			- node_version=<version> | Only select the backups compatible with this node version, defaults to 'reader-node-version'
+
 		Security note: The archive must be found a trusted source. The archive is uncompressed using the same
 		privileges as the reader node process. The paths in the archive are not sanitized and are extracted as is
 		relative to the 'reader-node-data-dir' location. A security consideration here is that it can unpack
@@ -109,8 +118,13 @@ func DefaultReaderNodeBootstrapper(
 		case strings.HasPrefix(bootstrapDataURL, "bash://"):
 			return NewBashNodeReaderBootstrapper(cmd, bootstrapDataURL, resolver, resolvedNodeArguments, logger), nil
 
+		case strings.HasPrefix(bootstrapDataURL, catalogBootstrapScheme):
+			// The flag is registered by the reader node app, it's absent when the chain has its own
+			nodeVersion, _ := sflags.GetString(cmd, "reader-node-version")
+			return NewCatalogReaderNodeBootstrapper(bootstrapDataURL, nodeVersion, nodeDataDir, logger)
+
 		default:
-			return nil, fmt.Errorf("'reader-node-bootstrap-data-url' config should point to either an archive ending in '.tar.zstd' or a genesis file ending in '.json', not %s", bootstrapDataURL)
+			return nil, fmt.Errorf("'reader-node-bootstrap-data-url' config should point to either an archive ending in '.tar.zstd', a 'bash://' script or a 'catalog+' backup catalog, not %s", bootstrapDataURL)
 		}
 	}
 }
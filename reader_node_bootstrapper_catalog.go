@@ -0,0 +1,114 @@
+package firecore
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/streamingfast/firehose-core/node-manager/backup"
+	"github.com/streamingfast/firehose-core/node-manager/operator"
+	"go.uber.org/zap"
+)
+
+const catalogBootstrapScheme = "catalog+"
+
+// NewCatalogReaderNodeBootstrapper returns a bootstrapper restoring the backup selected in the
+// backup catalog of [bootstrapURL], of the form `catalog+<catalog url>?<parameters>`. The backup
+// of the highest block having an URL is selected, see [operator.SelectBackup], the query parameters
+// accepted are:
+//
+//   - module=<name> | Only select the backups of this backup module
+//   - at_or_before_block=<num> | Only select the backups at or before this block
+//   - node_version=<version> | Only select the backups compatible with this node version, see [operator.NodeVersionsCompatible]
+//
+// [nodeVersion], when not empty, is used when `node_version` is not set.
+func NewCatalogReaderNodeBootstrapper(bootstrapURL string, nodeVersion string, dataDir string, logger *zap.Logger) (*CatalogNodeBootstrapper, error) {
+	catalogURL, query, err := parseCatalogBootstrapURL(bootstrapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.NodeVersion == "" {
+		query.NodeVersion = nodeVersion
+	}
+
+	catalog, err := backup.NewDstoreCatalog(catalogURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CatalogNodeBootstrapper{
+		catalog: catalog,
+		query:   query,
+		dataDir: dataDir,
+		logger:  logger,
+	}, nil
+}
+
+type CatalogNodeBootstrapper struct {
+	catalog operator.BackupCatalog
+	query   operator.BackupQuery
+	dataDir string
+	logger  *zap.Logger
+}
+
+func (b *CatalogNodeBootstrapper) Bootstrap() error {
+	if isBootstrapped(b.dataDir, b.logger) {
+		return nil
+	}
+
+	entries, err := b.catalog.Entries()
+	if err != nil {
+		return fmt.Errorf("read backup catalog: %w", err)
+	}
+
+	entry, err := operator.SelectBackup(entries, b.query)
+	if err != nil {
+		return err
+	}
+
+	b.logger.Info("bootstrapping native node chain data from backup catalog",
+		zap.Stringer("query", &b.query),
+		zap.String("backup_name", entry.Name),
+		zap.Uint64("block_num", entry.BlockNum),
+		zap.String("block_hash", entry.BlockHash),
+		zap.String("node_version", entry.NodeVersion),
+		zap.String("url", entry.URL),
+	)
+
+	if !strings.HasSuffix(entry.URL, "tar.zst") && !strings.HasSuffix(entry.URL, "tar.zstd") {
+		return fmt.Errorf("backup %q url %q is not a 'tar.zst' archive, only those can bootstrap a node", entry.Name, entry.URL)
+	}
+
+	return NewTarballReaderNodeBootstrapper(entry.URL, b.dataDir, b.logger).Bootstrap()
+}
+
+func parseCatalogBootstrapURL(bootstrapURL string) (string, operator.BackupQuery, error) {
+	query := operator.BackupQuery{RequireURL: true}
+
+	raw := strings.TrimPrefix(bootstrapURL, catalogBootstrapScheme)
+	catalogURL, err := url.Parse(raw)
+	if err != nil {
+		return "", query, fmt.Errorf("invalid catalog url %q: %w", raw, err)
+	}
+
+	values := catalogURL.Query()
+	query.Module = values.Get("module")
+	query.NodeVersion = values.Get("node_version")
+	if value := values.Get("at_or_before_block"); value != "" {
+		blockNum, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return "", query, fmt.Errorf("invalid at_or_before_block %q: %w", value, err)
+		}
+		query.AtOrBeforeBlock = &blockNum
+	}
+
+	// The remaining parameters are the catalog store ones
+	for _, key := range []string{"module", "node_version", "at_or_before_block"} {
+		values.Del(key)
+	}
+	catalogURL.RawQuery = values.Encode()
+
+	return catalogURL.String(), query, nil
+}
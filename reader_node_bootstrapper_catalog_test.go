@@ -0,0 +1,63 @@
+package firecore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/streamingfast/firehose-core/node-manager/backup"
+	"github.com/streamingfast/firehose-core/node-manager/operator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCatalogNodeBootstrapper(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "source")
+	require.NoError(t, os.MkdirAll(sourceDir, 0o755))
+
+	tarball, err := backup.NewTarball(operator.BackupModuleConfig{"store": t.TempDir()}, sourceDir, zap.NewNop())
+	require.NoError(t, err)
+
+	catalogDir := t.TempDir()
+	catalog, err := backup.NewDstoreCatalog(catalogDir)
+	require.NoError(t, err)
+
+	for _, blockNum := range []uint64{100, 200} {
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "head"), []byte{byte(blockNum)}, 0o644))
+
+		name, err := tarball.Backup(blockNum)
+		require.NoError(t, err)
+
+		url, size, err := tarball.Describe(name)
+		require.NoError(t, err)
+		require.NoError(t, catalog.Record(&operator.BackupCatalogEntry{Name: name, Module: "tarball", BlockNum: blockNum, NodeVersion: "v1.0.0", SizeBytes: size, URL: url}))
+	}
+
+	dataDir := filepath.Join(t.TempDir(), "data")
+	bootstrapper, err := NewCatalogReaderNodeBootstrapper("catalog+"+catalogDir+"?at_or_before_block=150", "v1.2.0", dataDir, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, bootstrapper.Bootstrap())
+
+	content, err := os.ReadFile(filepath.Join(dataDir, "head"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{100}, content)
+
+	bootstrapper, err = NewCatalogReaderNodeBootstrapper("catalog+"+catalogDir+"?node_version=v0.9.0", "", filepath.Join(t.TempDir(), "data"), zap.NewNop())
+	require.NoError(t, err)
+	assert.Error(t, bootstrapper.Bootstrap())
+}
+
+func TestParseCatalogBootstrapURL(t *testing.T) {
+	catalogURL, query, err := parseCatalogBootstrapURL("catalog+s3://bucket/catalog?region=us-east-1&module=tarball&at_or_before_block=5000000000&node_version=v1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, "s3://bucket/catalog?region=us-east-1", catalogURL)
+	assert.Equal(t, "tarball", query.Module)
+	assert.Equal(t, "v1.2.0", query.NodeVersion)
+	require.NotNil(t, query.AtOrBeforeBlock)
+	assert.Equal(t, uint64(5000000000), *query.AtOrBeforeBlock)
+	assert.True(t, query.RequireURL)
+
+	_, _, err = parseCatalogBootstrapURL("catalog+gs://bucket/catalog?at_or_before_block=latest")
+	assert.Error(t, err)
+}
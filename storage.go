@@ -52,20 +52,32 @@ func GetCommonStoresURLs(dataDir string) (mergedBlocksStoreURL, oneBlocksStoreUR
 	return
 }
 
+// GetIndexStoreURL returns the index store URL, creating its directory if it's a local store.
+// An empty URL is returned if no index store is configured.
+func GetIndexStoreURL(dataDir string) (indexStoreURL string, err error) {
+	indexStoreURL = MustReplaceDataDir(dataDir, viperExpandedEnvGetString("common-index-store-url"))
+
+	if indexStoreURL != "" && !indexStoreCreated {
+		if err = mkdirStorePathIfLocal(indexStoreURL); err != nil {
+			return "", err
+		}
+		indexStoreCreated = true
+	}
+
+	return
+}
+
 func GetIndexStore(dataDir string) (indexStore dstore.Store, possibleIndexSizes []uint64, err error) {
-	indexStoreURL := MustReplaceDataDir(dataDir, viperExpandedEnvGetString("common-index-store-url"))
+	indexStoreURL, err := GetIndexStoreURL(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	if indexStoreURL != "" {
 		s, err := dstore.NewStore(indexStoreURL, "", "", false)
 		if err != nil {
 			return nil, nil, fmt.Errorf("couldn't create index store: %w", err)
 		}
-		if !indexStoreCreated {
-			if err = mkdirStorePathIfLocal(indexStoreURL); err != nil {
-				return nil, nil, err
-			}
-		}
-		indexStoreCreated = true
 		indexStore = s
 	}
 
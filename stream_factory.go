@@ -6,15 +6,18 @@ import (
 
 	"github.com/streamingfast/dmetering"
 
+	fhmetrics "github.com/streamingfast/firehose-core/firehose/metrics"
 	"github.com/streamingfast/firehose-core/metering"
 
 	"github.com/streamingfast/bstream"
 	"github.com/streamingfast/bstream/hub"
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
 	"github.com/streamingfast/bstream/stream"
 	"github.com/streamingfast/bstream/transform"
 	"github.com/streamingfast/dauth"
 	"github.com/streamingfast/dstore"
 	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -23,10 +26,24 @@ import (
 var StreamMergedBlocksPreprocThreads = 25
 
 type StreamFactory struct {
-	mergedBlocksStore dstore.Store
-	forkedBlocksStore dstore.Store
-	hub               *hub.ForkableHub
-	transformRegistry *transform.Registry
+	mergedBlocksStore    dstore.Store
+	forkedBlocksStore    dstore.Store
+	hub                  *hub.ForkableHub
+	transformRegistry    *transform.Registry
+	firstStreamableBlock uint64
+
+	inflightPreprocessing atomic.Int64
+}
+
+type StreamFactoryOption func(*StreamFactory)
+
+// WithFirstStreamableBlock makes the streams start at [num] at the earliest, for a chain whose first
+// streamable block is above the process wide [bstream.GetProtocolFirstStreamableBlock] which is the
+// only bound applied by `bstream`.
+func WithFirstStreamableBlock(num uint64) StreamFactoryOption {
+	return func(sf *StreamFactory) {
+		sf.firstStreamableBlock = num
+	}
 }
 
 func NewStreamFactory(
@@ -34,13 +51,20 @@ func NewStreamFactory(
 	forkedBlocksStore dstore.Store,
 	hub *hub.ForkableHub,
 	transformRegistry *transform.Registry,
+	opts ...StreamFactoryOption,
 ) *StreamFactory {
-	return &StreamFactory{
+	sf := &StreamFactory{
 		mergedBlocksStore: mergedBlocksStore,
 		forkedBlocksStore: forkedBlocksStore,
 		hub:               hub,
 		transformRegistry: transformRegistry,
 	}
+
+	for _, opt := range opts {
+		opt(sf)
+	}
+
+	return sf
 }
 
 func (sf *StreamFactory) New(
@@ -67,7 +91,7 @@ func (sf *StreamFactory) New(
 		return nil, fmt.Errorf("building from transforms: %w", err)
 	}
 	if preprocFunc != nil {
-		options = append(options, stream.WithPreprocessFunc(preprocFunc, StreamMergedBlocksPreprocThreads))
+		options = append(options, stream.WithPreprocessFunc(sf.trackPreprocessing(preprocFunc), StreamMergedBlocksPreprocThreads))
 	}
 	if blockIndexProvider != nil {
 		reqLogger = reqLogger.With(zap.Bool("with_index_provider", true))
@@ -141,9 +165,55 @@ func (sf *StreamFactory) New(
 		forkedBlocksStore,
 		mergedBlocksStore,
 		sf.hub,
-		request.StartBlockNum,
+		sf.startBlockNum(request.StartBlockNum),
 		handler,
 		options...)
 
 	return str, nil
 }
+
+// startBlockNum bounds [startBlockNum] to the chain's first streamable block, a negative (relative to
+// the head) start block is only bounded when the hub knows the head.
+func (sf *StreamFactory) startBlockNum(startBlockNum int64) int64 {
+	if sf.firstStreamableBlock == 0 {
+		return startBlockNum
+	}
+
+	if startBlockNum < 0 {
+		if sf.hub == nil {
+			return startBlockNum
+		}
+
+		headNum, _, _, _, err := sf.hub.HeadInfo()
+		if err != nil || headNum >= sf.firstStreamableBlock+uint64(-startBlockNum) {
+			return startBlockNum
+		}
+
+		return int64(sf.firstStreamableBlock)
+	}
+
+	if uint64(startBlockNum) < sf.firstStreamableBlock {
+		return int64(sf.firstStreamableBlock)
+	}
+
+	return startBlockNum
+}
+
+// InflightPreprocessing returns the number of blocks currently being preprocessed across all
+// the streams created by this factory.
+func (sf *StreamFactory) InflightPreprocessing() int64 {
+	return sf.inflightPreprocessing.Load()
+}
+
+func (sf *StreamFactory) trackPreprocessing(preprocFunc bstream.PreprocessFunc) bstream.PreprocessFunc {
+	return func(blk *pbbstream.Block) (interface{}, error) {
+		sf.inflightPreprocessing.Inc()
+		fhmetrics.InflightPreprocessing.Inc()
+		defer func() {
+			sf.inflightPreprocessing.Dec()
+			fhmetrics.InflightPreprocessing.Dec()
+		}()
+
+		return preprocFunc(blk)
+	}
+}
@@ -0,0 +1,19 @@
+package firecore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamFactory_StartBlockNum(t *testing.T) {
+	sf := NewStreamFactory(nil, nil, nil, nil)
+	assert.Equal(t, int64(10), sf.startBlockNum(10))
+	assert.Equal(t, int64(-10), sf.startBlockNum(-10))
+
+	sf = NewStreamFactory(nil, nil, nil, nil, WithFirstStreamableBlock(100))
+	assert.Equal(t, int64(100), sf.startBlockNum(0))
+	assert.Equal(t, int64(100), sf.startBlockNum(100))
+	assert.Equal(t, int64(150), sf.startBlockNum(150))
+	assert.Equal(t, int64(-10), sf.startBlockNum(-10), "no hub to resolve the head")
+}
@@ -24,6 +24,14 @@ type Chain struct {
 	// You can generally get the genesis block ID by running `firecore tools print merged-blocks <path/to/merged-blocks> <first-streamable-block-number>` on the merged-blocks
 	GenesisBlockID     string
 	GenesisBlockNumber uint64
+	// Checkpoints are other final blocks of the chain, they follow the same encoding as the genesis block ID and
+	// are used to check the chain identity of stores and nodes which do not start at the genesis block
+	Checkpoints []*Checkpoint
+}
+
+type Checkpoint struct {
+	Number uint64
+	ID     string
 }
 
 type WellKnownProtocolList []WellKnownProtocol
@@ -321,6 +329,19 @@ func (p WellKnownProtocolList) ChainByGenesisBlock(blockNum uint64, blockID stri
 	return nil
 }
 
+// ChainByKnownBlock is like [WellKnownProtocolList.ChainByGenesisBlock] but also matches the
+// checkpoints of the chains.
+func (p WellKnownProtocolList) ChainByKnownBlock(blockNum uint64, blockID string) *Chain {
+	for _, protocol := range p {
+		for _, chain := range protocol.KnownChains {
+			if expected, found := chain.ExpectedBlockID(blockNum); found && expected == blockID {
+				return chain
+			}
+		}
+	}
+	return nil
+}
+
 func (p WellKnownProtocolList) ChainByName(name string) *Chain {
 	for _, protocol := range p {
 		for _, chain := range protocol.KnownChains {
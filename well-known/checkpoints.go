@@ -0,0 +1,56 @@
+package wellknown
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrChainIdentityMismatch is returned when a block at the genesis block or at a checkpoint of a chain
+// does not have the expected ID, meaning that the blocks come from another network.
+var ErrChainIdentityMismatch = errors.New("chain identity mismatch")
+
+// ExpectedBlockID returns the ID of the block at [blockNum] when it is the genesis block or a
+// checkpoint of the chain.
+func (c *Chain) ExpectedBlockID(blockNum uint64) (id string, found bool) {
+	if c == nil {
+		return "", false
+	}
+
+	if blockNum == c.GenesisBlockNumber {
+		return c.GenesisBlockID, true
+	}
+
+	for _, checkpoint := range c.Checkpoints {
+		if checkpoint.Number == blockNum {
+			return checkpoint.ID, true
+		}
+	}
+
+	return "", false
+}
+
+// HasCheckpoints returns true when the chain has other known blocks than its genesis block.
+func (c *Chain) HasCheckpoints() bool {
+	return c != nil && len(c.Checkpoints) > 0
+}
+
+// VerifyBlock returns an [ErrChainIdentityMismatch] error when the block at [blockNum] is the genesis
+// block or a checkpoint of the chain and [blockID] is not its ID. It is a no-op on a nil chain.
+func (c *Chain) VerifyBlock(blockNum uint64, blockID string) error {
+	if expected, found := c.ExpectedBlockID(blockNum); found && expected != blockID {
+		return fmt.Errorf("%w: block #%d has ID %q but chain %q expects %q", ErrChainIdentityMismatch, blockNum, blockID, c.Name, expected)
+	}
+
+	return nil
+}
+
+// VerifyBlockIDSuffix is like [Chain.VerifyBlock] for a truncated block ID, as found in one-block
+// file names, which must be a suffix of the expected ID.
+func (c *Chain) VerifyBlockIDSuffix(blockNum uint64, blockIDSuffix string) error {
+	if expected, found := c.ExpectedBlockID(blockNum); found && (blockIDSuffix == "" || !strings.HasSuffix(expected, blockIDSuffix)) {
+		return fmt.Errorf("%w: block #%d has ID suffix %q but chain %q expects %q", ErrChainIdentityMismatch, blockNum, blockIDSuffix, c.Name, expected)
+	}
+
+	return nil
+}
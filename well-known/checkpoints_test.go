@@ -0,0 +1,45 @@
+package wellknown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_VerifyBlock(t *testing.T) {
+	chain := &Chain{
+		Name:               "acme-mainnet",
+		GenesisBlockID:     "00aa",
+		GenesisBlockNumber: 1,
+		Checkpoints:        []*Checkpoint{{Number: 1000, ID: "0000000000000000000000000001000a"}},
+	}
+
+	assert.NoError(t, chain.VerifyBlock(1, "00aa"))
+	assert.NoError(t, chain.VerifyBlock(1000, "0000000000000000000000000001000a"))
+	assert.NoError(t, chain.VerifyBlock(999, "anything"), "blocks other than the genesis and checkpoints are not checked")
+
+	err := chain.VerifyBlock(1000, "0000000000000000000000000001000b")
+	require.ErrorIs(t, err, ErrChainIdentityMismatch)
+	assert.Equal(t, `chain identity mismatch: block #1000 has ID "0000000000000000000000000001000b" but chain "acme-mainnet" expects "0000000000000000000000000001000a"`, err.Error())
+	assert.ErrorIs(t, chain.VerifyBlock(1, "00bb"), ErrChainIdentityMismatch)
+
+	assert.NoError(t, chain.VerifyBlockIDSuffix(1000, "000000000001000a"))
+	assert.ErrorIs(t, chain.VerifyBlockIDSuffix(1000, "000000000001000b"), ErrChainIdentityMismatch)
+	assert.ErrorIs(t, chain.VerifyBlockIDSuffix(1000, ""), ErrChainIdentityMismatch)
+
+	var unknown *Chain
+	assert.NoError(t, unknown.VerifyBlock(1000, "0000000000000000000000000001000b"))
+	assert.False(t, unknown.HasCheckpoints())
+	assert.True(t, chain.HasCheckpoints())
+}
+
+func TestWellKnownProtocolList_ChainByKnownBlock(t *testing.T) {
+	chain := &Chain{Name: "acme-mainnet", GenesisBlockID: "aa", Checkpoints: []*Checkpoint{{Number: 1000, ID: "bb"}}}
+	protocols := WellKnownProtocolList{{Name: "acme", KnownChains: []*Chain{chain}}}
+
+	assert.Same(t, chain, protocols.ChainByKnownBlock(0, "aa"))
+	assert.Same(t, chain, protocols.ChainByKnownBlock(1000, "bb"))
+	assert.Nil(t, protocols.ChainByKnownBlock(1000, "cc"))
+	assert.Nil(t, protocols.ChainByGenesisBlock(1000, "bb"))
+}
@@ -0,0 +1,330 @@
+package wellknown
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/streamingfast/dstore"
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// maxRegistrySize bounds the registry fetched from an HTTP URL
+const maxRegistrySize = 16 * 1024 * 1024
+
+type registryFile struct {
+	Protocols []*registryProtocol `yaml:"protocols"`
+}
+
+type registryProtocol struct {
+	Name          string           `yaml:"name"`
+	BlockType     string           `yaml:"block-type"`
+	BufBuildURL   string           `yaml:"buf-build-url"`
+	BytesEncoding string           `yaml:"bytes-encoding"`
+	Chains        []*registryChain `yaml:"chains"`
+}
+
+type registryChain struct {
+	Name               string                `yaml:"name"`
+	Aliases            []string              `yaml:"aliases"`
+	GenesisBlockID     string                `yaml:"genesis-block-id"`
+	GenesisBlockNumber uint64                `yaml:"genesis-block-number"`
+	Checkpoints        []*registryCheckpoint `yaml:"checkpoints"`
+}
+
+type registryCheckpoint struct {
+	Number *uint64 `yaml:"number"`
+	ID     string  `yaml:"id"`
+}
+
+// LoadRegistry reads the registry at [source] (see [ReadRegistry]) and merges it over the
+// current [WellKnownProtocols] (see [WellKnownProtocolList.Merge]), which it replaces. It is
+// meant to be called once at startup, before the registry is used.
+func LoadRegistry(ctx context.Context, source string) error {
+	overrides, err := ReadRegistry(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	merged, err := WellKnownProtocols.Merge(overrides)
+	if err != nil {
+		return fmt.Errorf("merge registry %q: %w", source, err)
+	}
+
+	WellKnownProtocols = merged
+	return nil
+}
+
+// ReadRegistry reads a YAML (or JSON) registry from [source], either an `http://` or `https://`
+// URL or any file URL supported by dstore (local path, `gs://`, `s3://`, ...). The registry lists
+// protocols under `protocols`, each with `name`, `block-type`, `buf-build-url`, `bytes-encoding`
+// and `chains` (each with `name`, `aliases`, `genesis-block-id`, `genesis-block-number` and
+// `checkpoints`, a list of `number` and `id`).
+func ReadRegistry(ctx context.Context, source string) (WellKnownProtocolList, error) {
+	content, err := readRegistrySource(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("read registry %q: %w", source, err)
+	}
+
+	protocols, err := ParseRegistry(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse registry %q: %w", source, err)
+	}
+
+	return protocols, nil
+}
+
+func readRegistrySource(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return dstore.ReadObject(ctx, source)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(response.Body, maxRegistrySize))
+}
+
+// ParseRegistry parses and validates a YAML (or JSON) registry, unknown keys are rejected.
+// Protocols must at least be named, their other fields are only required when they are not
+// overriding an existing protocol, which [WellKnownProtocolList.Merge] checks.
+func ParseRegistry(content []byte) (WellKnownProtocolList, error) {
+	var file registryFile
+	if err := yaml.UnmarshalStrict(content, &file); err != nil {
+		return nil, err
+	}
+
+	protocols := make(WellKnownProtocolList, len(file.Protocols))
+	for i, entry := range file.Protocols {
+		if entry == nil || entry.Name == "" {
+			return nil, fmt.Errorf("protocol #%d: name is required", i)
+		}
+
+		protocol := WellKnownProtocol{
+			Name:        entry.Name,
+			BlockType:   strings.TrimPrefix(entry.BlockType, "type.googleapis.com/"),
+			BufBuildURL: entry.BufBuildURL,
+		}
+
+		if entry.BytesEncoding != "" {
+			encoding, err := ParseBytesEncoding(entry.BytesEncoding)
+			if err != nil {
+				return nil, fmt.Errorf("protocol %q: %w", entry.Name, err)
+			}
+			protocol.BytesEncoding = encoding
+		}
+
+		for j, chainEntry := range entry.Chains {
+			if chainEntry == nil || chainEntry.Name == "" {
+				return nil, fmt.Errorf("protocol %q: chain #%d: name is required", entry.Name, j)
+			}
+			if chainEntry.GenesisBlockID == "" {
+				return nil, fmt.Errorf("protocol %q: chain %q: genesis-block-id is required", entry.Name, chainEntry.Name)
+			}
+
+			chain := &Chain{
+				Name:               chainEntry.Name,
+				Aliases:            chainEntry.Aliases,
+				GenesisBlockID:     chainEntry.GenesisBlockID,
+				GenesisBlockNumber: chainEntry.GenesisBlockNumber,
+			}
+
+			for k, checkpointEntry := range chainEntry.Checkpoints {
+				if checkpointEntry == nil || checkpointEntry.Number == nil || checkpointEntry.ID == "" {
+					return nil, fmt.Errorf("protocol %q: chain %q: checkpoint #%d: number and id are required", entry.Name, chainEntry.Name, k)
+				}
+
+				chain.Checkpoints = append(chain.Checkpoints, &Checkpoint{Number: *checkpointEntry.Number, ID: checkpointEntry.ID})
+			}
+
+			protocol.KnownChains = append(protocol.KnownChains, chain)
+		}
+
+		protocols[i] = protocol
+	}
+
+	return protocols, nil
+}
+
+// ParseBytesEncoding parses a block ID encoding either in its short lowercase form (e.g. `hex`)
+// or as the full enum name (e.g. `BLOCK_ID_ENCODING_HEX`).
+func ParseBytesEncoding(in string) (pbfirehose.InfoResponse_BlockIdEncoding, error) {
+	value, found := pbfirehose.InfoResponse_BlockIdEncoding_value[in]
+	if !found {
+		value, found = pbfirehose.InfoResponse_BlockIdEncoding_value["BLOCK_ID_ENCODING_"+strings.ToUpper(in)]
+	}
+
+	if !found || value == int32(pbfirehose.InfoResponse_BLOCK_ID_ENCODING_UNSET) {
+		return pbfirehose.InfoResponse_BLOCK_ID_ENCODING_UNSET, fmt.Errorf("invalid block id encoding: %s", in)
+	}
+
+	return pbfirehose.InfoResponse_BlockIdEncoding(value), nil
+}
+
+// Merge returns a new list made of [p] with [overrides] applied over it, [p] is left untouched.
+// Protocols are matched by name, the fields set in the override replace the existing ones and
+// its chains replace the existing chains of the same name, or are added. Unknown protocols are
+// added, in which case their `BlockType` and `BytesEncoding` are required.
+//
+// The merged list is validated, chain names and aliases must be unique across all protocols, as
+// must genesis blocks and block types, genesis block and checkpoint IDs must be valid for their
+// protocol encoding.
+func (p WellKnownProtocolList) Merge(overrides WellKnownProtocolList) (WellKnownProtocolList, error) {
+	merged := make(WellKnownProtocolList, len(p), len(p)+len(overrides))
+	for i, protocol := range p {
+		protocol.KnownChains = append([]*Chain(nil), protocol.KnownChains...)
+		merged[i] = protocol
+	}
+
+	for _, override := range overrides {
+		existing := merged.protocolByName(override.Name)
+		if existing == nil {
+			if override.BlockType == "" {
+				return nil, fmt.Errorf("protocol %q: block-type is required for a new protocol", override.Name)
+			}
+			if override.BytesEncoding == pbfirehose.InfoResponse_BLOCK_ID_ENCODING_UNSET {
+				return nil, fmt.Errorf("protocol %q: bytes-encoding is required for a new protocol", override.Name)
+			}
+
+			override.KnownChains = append([]*Chain(nil), override.KnownChains...)
+			merged = append(merged, override)
+			continue
+		}
+
+		if override.BlockType != "" {
+			existing.BlockType = override.BlockType
+		}
+		if override.BufBuildURL != "" {
+			existing.BufBuildURL = override.BufBuildURL
+		}
+		if override.BytesEncoding != pbfirehose.InfoResponse_BLOCK_ID_ENCODING_UNSET {
+			existing.BytesEncoding = override.BytesEncoding
+		}
+
+		for _, chain := range override.KnownChains {
+			replaced := false
+			for i, existingChain := range existing.KnownChains {
+				if existingChain.Name == chain.Name {
+					existing.KnownChains[i] = chain
+					replaced = true
+					break
+				}
+			}
+
+			if !replaced {
+				existing.KnownChains = append(existing.KnownChains, chain)
+			}
+		}
+	}
+
+	if err := merged.validate(); err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+func (p WellKnownProtocolList) protocolByName(name string) *WellKnownProtocol {
+	for i := range p {
+		if p[i].Name == name {
+			return &p[i]
+		}
+	}
+	return nil
+}
+
+func (p WellKnownProtocolList) validate() error {
+	blockTypes := map[string]string{}
+	chainNames := map[string]string{}
+	genesisBlocks := map[string]string{}
+
+	for _, protocol := range p {
+		if other, found := blockTypes[protocol.BlockType]; found {
+			return fmt.Errorf("protocol %q: block type %q is already used by protocol %q", protocol.Name, protocol.BlockType, other)
+		}
+		blockTypes[protocol.BlockType] = protocol.Name
+
+		for _, chain := range protocol.KnownChains {
+			for _, name := range append([]string{chain.Name}, chain.Aliases...) {
+				if other, found := chainNames[name]; found {
+					return fmt.Errorf("protocol %q: chain %q: name %q is already used by chain %q", protocol.Name, chain.Name, name, other)
+				}
+				chainNames[name] = chain.Name
+			}
+
+			genesis := fmt.Sprintf("#%d (%s)", chain.GenesisBlockNumber, chain.GenesisBlockID)
+			if other, found := genesisBlocks[genesis]; found {
+				return fmt.Errorf("protocol %q: chain %q: genesis block %s is already used by chain %q", protocol.Name, chain.Name, genesis, other)
+			}
+			genesisBlocks[genesis] = chain.Name
+
+			if err := validateBlockID(chain.GenesisBlockID, protocol.BytesEncoding); err != nil {
+				return fmt.Errorf("protocol %q: chain %q: genesis block %w", protocol.Name, chain.Name, err)
+			}
+
+			checkpoints := map[uint64]bool{chain.GenesisBlockNumber: true}
+			for _, checkpoint := range chain.Checkpoints {
+				if checkpoints[checkpoint.Number] {
+					return fmt.Errorf("protocol %q: chain %q: block #%d is defined more than once in genesis block and checkpoints", protocol.Name, chain.Name, checkpoint.Number)
+				}
+				checkpoints[checkpoint.Number] = true
+
+				if err := validateBlockID(checkpoint.ID, protocol.BytesEncoding); err != nil {
+					return fmt.Errorf("protocol %q: chain %q: checkpoint #%d %w", protocol.Name, chain.Name, checkpoint.Number, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateBlockID only checks hex encoded IDs, which are the most common and the easiest to get
+// wrong, the `0x` prefix being part of the ID with the `0x_hex` encoding only. Odd lengths are
+// accepted, some chains (e.g. Starknet) drop the leading zero of their IDs.
+func validateBlockID(id string, encoding pbfirehose.InfoResponse_BlockIdEncoding) error {
+	switch encoding {
+	case pbfirehose.InfoResponse_BLOCK_ID_ENCODING_HEX:
+		if !isHex(id) {
+			return fmt.Errorf("id %q is not valid hex (without '0x' prefix)", id)
+		}
+
+	case pbfirehose.InfoResponse_BLOCK_ID_ENCODING_0X_HEX:
+		if !strings.HasPrefix(id, "0x") {
+			return fmt.Errorf("id %q is missing the '0x' prefix", id)
+		}
+		if !isHex(strings.TrimPrefix(id, "0x")) {
+			return fmt.Errorf("id %q is not valid '0x' prefixed hex", id)
+		}
+	}
+
+	return nil
+}
+
+func isHex(in string) bool {
+	if in == "" {
+		return false
+	}
+
+	for _, char := range in {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", char) {
+			return false
+		}
+	}
+	return true
+}
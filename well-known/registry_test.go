@@ -0,0 +1,203 @@
+package wellknown
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pbfirehose "github.com/streamingfast/pbgo/sf/firehose/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltInRegistryIsValid(t *testing.T) {
+	require.NoError(t, WellKnownProtocols.validate())
+}
+
+func TestParseRegistry(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{"yaml", "protocols:\n  - name: ethereum\n    chains:\n      - name: devnet\n        genesis-block-id: aa\n", ""},
+		{"json", `{"protocols": [{"name": "ethereum", "bytes-encoding": "hex", "chains": [{"name": "devnet", "genesis-block-id": "aa"}]}]}`, ""},
+		{"unknown key", "protocols:\n  - name: ethereum\n    genesis: aa\n", "field genesis not found"},
+		{"missing protocol name", "protocols:\n  - block-type: sf.acme.type.v1.Block\n", "protocol #0: name is required"},
+		{"missing chain name", "protocols:\n  - name: ethereum\n    chains:\n      - genesis-block-id: aa\n", `protocol "ethereum": chain #0: name is required`},
+		{"missing genesis", "protocols:\n  - name: ethereum\n    chains:\n      - name: devnet\n", `protocol "ethereum": chain "devnet": genesis-block-id is required`},
+		{"checkpoints", "protocols:\n  - name: ethereum\n    chains:\n      - name: devnet\n        genesis-block-id: aa\n        checkpoints:\n          - {number: 0, id: bb}\n", ""},
+		{"checkpoint without number", "protocols:\n  - name: ethereum\n    chains:\n      - name: devnet\n        genesis-block-id: aa\n        checkpoints:\n          - {id: bb}\n", `protocol "ethereum": chain "devnet": checkpoint #0: number and id are required`},
+		{"invalid encoding", "protocols:\n  - name: ethereum\n    bytes-encoding: base32\n", `protocol "ethereum": invalid block id encoding: base32`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRegistry([]byte(tt.content))
+			if tt.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestWellKnownProtocolList_Merge(t *testing.T) {
+	base := WellKnownProtocolList{
+		{
+			Name:          "acme",
+			BlockType:     "sf.acme.type.v1.Block",
+			BytesEncoding: pbfirehose.InfoResponse_BLOCK_ID_ENCODING_HEX,
+			KnownChains: []*Chain{
+				{Name: "acme-mainnet", GenesisBlockID: "aa"},
+				{Name: "acme-testnet", GenesisBlockID: "bb"},
+			},
+		},
+	}
+
+	merged, err := base.Merge(WellKnownProtocolList{
+		{
+			Name: "acme",
+			KnownChains: []*Chain{
+				{Name: "acme-testnet", Aliases: []string{"acme-test"}, GenesisBlockID: "cc", GenesisBlockNumber: 10},
+				{Name: "acme-devnet", GenesisBlockID: "dd"},
+			},
+		},
+		{
+			Name:          "other",
+			BlockType:     "sf.other.type.v1.Block",
+			BytesEncoding: pbfirehose.InfoResponse_BLOCK_ID_ENCODING_BASE58,
+			KnownChains:   []*Chain{{Name: "other-mainnet", GenesisBlockID: "3yZe7d"}},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, merged, 2)
+	assert.Equal(t, "sf.acme.type.v1.Block", merged[0].BlockType)
+	assert.Equal(t, pbfirehose.InfoResponse_BLOCK_ID_ENCODING_HEX, merged[0].BytesEncoding)
+	require.Len(t, merged[0].KnownChains, 3)
+	assert.Equal(t, "acme-testnet", merged.ChainByGenesisBlock(10, "cc").Name)
+	assert.Equal(t, "acme-testnet", merged.ChainByName("acme-test").Name)
+	assert.Nil(t, merged.ChainByGenesisBlock(0, "bb"))
+	assert.Equal(t, "acme-devnet", merged.ChainByName("acme-devnet").Name)
+	assert.Equal(t, "other-mainnet", merged.ChainByName("other-mainnet").Name)
+
+	// The base list is left untouched
+	require.Len(t, base[0].KnownChains, 2)
+	assert.Equal(t, "bb", base.ChainByName("acme-testnet").GenesisBlockID)
+}
+
+func TestWellKnownProtocolList_MergeErrors(t *testing.T) {
+	base := WellKnownProtocolList{
+		{
+			Name:          "acme",
+			BlockType:     "sf.acme.type.v1.Block",
+			BytesEncoding: pbfirehose.InfoResponse_BLOCK_ID_ENCODING_HEX,
+			KnownChains:   []*Chain{{Name: "acme-mainnet", Aliases: []string{"acme"}, GenesisBlockID: "aa"}},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		overrides     WellKnownProtocolList
+		expectedError string
+	}{
+		{
+			"new protocol without block type",
+			WellKnownProtocolList{{Name: "other", BytesEncoding: pbfirehose.InfoResponse_BLOCK_ID_ENCODING_HEX}},
+			`protocol "other": block-type is required for a new protocol`,
+		},
+		{
+			"new protocol without encoding",
+			WellKnownProtocolList{{Name: "other", BlockType: "sf.other.type.v1.Block"}},
+			`protocol "other": bytes-encoding is required for a new protocol`,
+		},
+		{
+			"duplicated block type",
+			WellKnownProtocolList{{Name: "other", BlockType: "sf.acme.type.v1.Block", BytesEncoding: pbfirehose.InfoResponse_BLOCK_ID_ENCODING_HEX}},
+			`protocol "other": block type "sf.acme.type.v1.Block" is already used by protocol "acme"`,
+		},
+		{
+			"name clashing with an alias",
+			WellKnownProtocolList{{Name: "acme", KnownChains: []*Chain{{Name: "acme", GenesisBlockID: "bb"}}}},
+			`protocol "acme": chain "acme": name "acme" is already used by chain "acme-mainnet"`,
+		},
+		{
+			"duplicated genesis block",
+			WellKnownProtocolList{{Name: "acme", KnownChains: []*Chain{{Name: "acme-fork", GenesisBlockID: "aa"}}}},
+			`protocol "acme": chain "acme-fork": genesis block #0 (aa) is already used by chain "acme-mainnet"`,
+		},
+		{
+			"checkpoint at the genesis block",
+			WellKnownProtocolList{{Name: "acme", KnownChains: []*Chain{{Name: "acme-devnet", GenesisBlockID: "bb", Checkpoints: []*Checkpoint{{Number: 0, ID: "cc"}}}}}},
+			`protocol "acme": chain "acme-devnet": block #0 is defined more than once in genesis block and checkpoints`,
+		},
+		{
+			"invalid hex checkpoint id",
+			WellKnownProtocolList{{Name: "acme", KnownChains: []*Chain{{Name: "acme-devnet", GenesisBlockID: "bb", Checkpoints: []*Checkpoint{{Number: 100, ID: "0xcc"}}}}}},
+			`protocol "acme": chain "acme-devnet": checkpoint #100 id "0xcc" is not valid hex (without '0x' prefix)`,
+		},
+		{
+			"0x prefixed genesis block id missing its prefix",
+			WellKnownProtocolList{{Name: "beacon", BlockType: "sf.beacon.type.v1.Block", BytesEncoding: pbfirehose.InfoResponse_BLOCK_ID_ENCODING_0X_HEX, KnownChains: []*Chain{{Name: "beacon-devnet", GenesisBlockID: "bb"}}}},
+			`protocol "beacon": chain "beacon-devnet": genesis block id "bb" is missing the '0x' prefix`,
+		},
+		{
+			"invalid hex genesis block id",
+			WellKnownProtocolList{{Name: "acme", KnownChains: []*Chain{{Name: "acme-devnet", GenesisBlockID: "0xbb"}}}},
+			`protocol "acme": chain "acme-devnet": genesis block id "0xbb" is not valid hex (without '0x' prefix)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := base.Merge(tt.overrides)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestReadRegistry(t *testing.T) {
+	content := "protocols:\n  - name: acme\n    chains:\n      - name: acme-devnet\n        genesis-block-id: aa\n"
+
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/registry.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	for _, source := range []string{path, server.URL + "/registry.yaml"} {
+		protocols, err := ReadRegistry(context.Background(), source)
+		require.NoError(t, err, source)
+		assert.Equal(t, "acme-devnet", protocols.ChainByName("acme-devnet").Name, source)
+	}
+
+	_, err := ReadRegistry(context.Background(), server.URL+"/missing.yaml")
+	assert.ErrorContains(t, err, "unexpected status 404 Not Found")
+}
+
+func TestLoadRegistry(t *testing.T) {
+	original := WellKnownProtocols
+	defer func() { WellKnownProtocols = original }()
+
+	path := filepath.Join(t.TempDir(), "registry.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"protocols": [{"name": "ethereum", "chains": [{"name": "my-devnet", "aliases": ["devnet"], "genesis-block-id": "abcdef"}]}]}`), 0644))
+
+	require.NoError(t, LoadRegistry(context.Background(), path))
+
+	assert.Equal(t, "my-devnet", WellKnownProtocols.ChainByGenesisBlock(0, "abcdef").Name)
+	assert.Equal(t, "mainnet", WellKnownProtocols.ChainByName("ethereum").Name)
+	assert.Nil(t, original.ChainByName("devnet"))
+}